@@ -0,0 +1,63 @@
+package id3v24
+
+import (
+	"io"
+)
+
+// HasAppendedID3v2Tag reports whether the size bytes of r end with an
+// ID3v2.4 tag appended after the audio, found by its footer and
+// optionally followed by an ID3v1 tag. offset is where the appended
+// tag starts and tagSize its total size including header and footer.
+func HasAppendedID3v2Tag(r io.ReaderAt, size int64) (offset int64, tagSize int, ok bool) {
+	for _, end := range []int64{size, size - id3v1TagSize} {
+		footer := make([]byte, id3v2HeaderSize)
+		if end < 2*id3v2HeaderSize {
+			continue
+		}
+		if n, _ := r.ReadAt(footer, end-id3v2HeaderSize); n < id3v2HeaderSize || string(footer[0:3]) != "3DI" {
+			continue
+		}
+		copy(footer, "ID3")
+		total := id3v2TagSize(footer)
+		if total == 0 || total > end {
+			continue
+		}
+		header := make([]byte, id3v2HeaderSize)
+		if n, _ := r.ReadAt(header, end-total); n < id3v2HeaderSize || string(header[0:3]) != "ID3" {
+			continue
+		}
+		return end - total, int(total), true
+	}
+	return 0, 0, false
+}
+
+// appendedTag returns the offset and size of the ID3v2 tag appended to
+// r, or a zero size if there is none.
+func appendedTag(r io.ReadSeeker) (offset, size int64, err error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, 0, err
+	}
+	offset, tagSize, ok := HasAppendedID3v2Tag(readSeekerAt{r}, end)
+	if !ok {
+		return 0, 0, nil
+	}
+	return offset, int64(tagSize), nil
+}
+
+// readAppendedTag returns the bytes of the ID3v2 tag appended to r,
+// or none if it has no appended tag.
+func readAppendedTag(r io.ReadSeeker) ([]byte, error) {
+	offset, size, err := appendedTag(r)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+	tag := make([]byte, size)
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
@@ -0,0 +1,49 @@
+package id3v24
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+func TestAppendedID3v2Tag(t *testing.T) {
+	data, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tagged bytes.Buffer
+	if err := WriteID3v2TagTo(&tagged, bytes.NewReader(data), TrackInfo{Title: "Old", Artist: "The Hosts"}); err != nil {
+		t.Fatal(err)
+	}
+	appended := append([]byte{}, tagged.Bytes()[:id3v2TagSize(tagged.Bytes())]...)
+	appended[5] |= 0x10 // footer present
+	appended = append(append(appended, "3DI"...), appended[3:10]...)
+	file := append(append([]byte{}, data...), appended...)
+	for _, trailer := range [][]byte{nil, append([]byte("TAG"), make([]byte, id3v1TagSize-3)...)} {
+		file := append(file, trailer...)
+		offset, size, ok := HasAppendedID3v2Tag(bytes.NewReader(file), int64(len(file)))
+		if !ok || offset != int64(len(data)) || size != len(appended) {
+			t.Errorf("expected an appended tag at %d of %d bytes, got %d, %d and %t", len(data), len(appended), offset, size, ok)
+		}
+	}
+	if _, _, ok := HasAppendedID3v2Tag(bytes.NewReader(data), int64(len(data))); ok {
+		t.Error("expected no appended tag")
+	}
+	var out bytes.Buffer
+	if err := WriteID3v2TagTo(&out, bytes.NewReader(file), TrackInfo{Title: "New"}, WithTagMerge()); err != nil {
+		t.Fatal(err)
+	}
+	tagSize := id3v2TagSize(out.Bytes())
+	if !bytes.Equal(out.Bytes()[tagSize:], data) {
+		t.Errorf("expected the appended tag to be removed, got %d bytes of audio", int64(out.Len())-tagSize)
+	}
+	tag, err := id3v2.ParseReader(bytes.NewReader(out.Bytes()), id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.Title() != "New" || tag.Artist() != "The Hosts" {
+		t.Errorf("expected %q by %q, got %q by %q", "New", "The Hosts", tag.Title(), tag.Artist())
+	}
+}
@@ -0,0 +1,92 @@
+package id3v24
+
+import (
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/tcolgate/mp3"
+)
+
+// ChapterByteRange maps a chapter to the part of an MP3 file that
+// holds its audio, for use by streaming servers implementing
+// time-based seeking or per-chapter partial downloads (e.g HTTP
+// "Range: bytes=Start-(End-1)").
+type ChapterByteRange struct {
+	Chapter Chapter
+	// StartTime and EndTime are the chapter start and end, where the
+	// end is Chapter.End if set, otherwise the start of the next
+	// chapter or the end of the audio.
+	StartTime time.Duration
+	EndTime   time.Duration
+	// Start is the offset of the first byte of the MPEG frame
+	// containing StartTime and End is the offset right after the last
+	// frame of the chapter (exclusive). Offsets are from the start of
+	// the file, including any leading ID3v2 tag.
+	Start int64
+	End   int64
+}
+
+// GetChapterByteRanges opens mp3path and returns ChapterByteRanges.
+func GetChapterByteRanges(mp3path string, chapters []Chapter) ([]ChapterByteRange, error) {
+	f, err := os.Open(mp3path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ChapterByteRanges(f, chapters)
+}
+
+// ChapterByteRanges scans the MP3 in r and returns the byte range of
+// each chapter in chapters. Returns ErrZeroDuration if r has no MPEG
+// audio frames.
+func ChapterByteRanges(r io.Reader, chapters []Chapter) ([]ChapterByteRange, error) {
+	var offsets []int64
+	var times []time.Duration
+	var duration time.Duration
+	length, err := scanMP3Frames(r, func(offset int64, frame *mp3.Frame) {
+		offsets = append(offsets, offset)
+		times = append(times, duration)
+		duration += frame.Duration()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(chapters) == 0 {
+		return nil, nil
+	}
+	if duration == 0 {
+		return nil, ErrZeroDuration
+	}
+	starts, ends, err := chapterTimes(chapters, uint32(duration/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	// offsetAt returns the offset of the frame playing at t, or length
+	// if t is at or beyond the end of the audio (in whole milliseconds,
+	// like the chapter times).
+	offsetAt := func(t time.Duration) int64 {
+		if t >= duration.Truncate(time.Millisecond) {
+			return length
+		}
+		i := sort.Search(len(times), func(i int) bool { return times[i] > t })
+		if i == 0 {
+			return offsets[0]
+		}
+		return offsets[i-1]
+	}
+	ranges := make([]ChapterByteRange, len(chapters))
+	for i, ch := range chapters {
+		startTime := time.Duration(starts[i]) * time.Millisecond
+		endTime := time.Duration(ends[i]) * time.Millisecond
+		ranges[i] = ChapterByteRange{
+			Chapter:   ch,
+			StartTime: startTime,
+			EndTime:   endTime,
+			Start:     offsetAt(startTime),
+			End:       offsetAt(endTime),
+		}
+	}
+	return ranges, nil
+}
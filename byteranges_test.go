@@ -0,0 +1,73 @@
+package id3v24
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestChapterByteRanges(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chapters := []Chapter{
+		{Title: "Chapter 1", Start: "00:00:00"},
+		{Title: "Chapter 2", Start: "00:00:01.5"},
+	}
+	var tagged bytes.Buffer
+	if err := WriteID3v2TagTo(&tagged, bytes.NewReader(mp3), TrackInfo{Title: "Hello world", Chapters: chapters}); err != nil {
+		t.Fatal(err)
+	}
+	tagSize := int64(tagged.Len() - len(mp3))
+	ranges, err := ChapterByteRanges(bytes.NewReader(tagged.Bytes()), chapters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) != 2 {
+		t.Fatalf("expected 2 ranges, got %d", len(ranges))
+	}
+	if ranges[0].Start != tagSize {
+		t.Errorf("expected first chapter to start after the tag at %d, got %d", tagSize, ranges[0].Start)
+	}
+	if ranges[0].End != ranges[1].Start {
+		t.Errorf("expected contiguous ranges, got %d and %d", ranges[0].End, ranges[1].Start)
+	}
+	if ranges[1].End != int64(tagged.Len()) {
+		t.Errorf("expected last chapter to end at %d, got %d", tagged.Len(), ranges[1].End)
+	}
+	// The second chapter starts roughly halfway through the audio.
+	half := tagSize + int64(len(mp3))/2
+	if d := ranges[1].Start - half; d < -int64(len(mp3))/10 || d > int64(len(mp3))/10 {
+		t.Errorf("second chapter starts at %d, expected close to %d", ranges[1].Start, half)
+	}
+	if ranges[1].StartTime.Milliseconds() != 1500 {
+		t.Errorf("expected second chapter to start at 1500ms, got %v", ranges[1].StartTime)
+	}
+}
+
+func TestChapterByteRangesEarlyEnd(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chapters := []Chapter{
+		{Title: "Chapter 1", Start: "00:00:00"},
+		{Title: "Chapter 2", Start: "00:00:01", End: "00:00:02"},
+	}
+	ranges, err := ChapterByteRanges(bytes.NewReader(mp3), chapters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ranges[1].EndTime.Milliseconds() != 2000 {
+		t.Errorf("expected last chapter to end at 2000ms, got %v", ranges[1].EndTime)
+	}
+	// The last chapter ends roughly two thirds into the 3 second audio.
+	twoThirds := int64(len(mp3)) * 2 / 3
+	if d := ranges[1].End - twoThirds; d < -int64(len(mp3))/10 || d > int64(len(mp3))/10 {
+		t.Errorf("last chapter ends at %d, expected close to %d", ranges[1].End, twoThirds)
+	}
+	if ranges[1].End <= ranges[1].Start || ranges[1].End >= int64(len(mp3)) {
+		t.Errorf("expected the last chapter to end before the end of the audio, got %d-%d of %d", ranges[1].Start, ranges[1].End, len(mp3))
+	}
+}
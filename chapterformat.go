@@ -0,0 +1,304 @@
+package id3v24
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+var ErrUnknownChapterFormat error = errors.New("unknown chapter format")
+
+// ChapterFormat is a file format chapters can be read from and/or
+// written to, registered by name for ConvertChapters, see
+// RegisterChapterFormat.
+type ChapterFormat struct {
+	Name       string
+	Extensions []string // e.g ".cue", for ChapterFormatByExtension
+	// Parse reads chapters from r, nil if the format can not be read.
+	Parse func(r io.Reader) ([]Chapter, error)
+	// Format returns chapters in the format, nil if the format can
+	// not be written. duration is the length of the audio, zero if
+	// unknown.
+	Format func(chapters []Chapter, duration time.Duration) ([]byte, error)
+}
+
+var (
+	chapterFormatsMu sync.RWMutex
+	chapterFormats   = map[string]ChapterFormat{}
+)
+
+func init() {
+	for _, f := range []ChapterFormat{
+		{Name: "json", Extensions: []string{".json"}, Parse: parseJSONChapters, Format: formatJSONChapters},
+		{Name: "cue", Extensions: []string{".cue"}, Parse: ParseCueSheet, Format: formatCueSheet},
+		{Name: "chaptertool", Extensions: []string{".xml"}, Parse: ParseChapterToolXML, Format: formatChapterToolXML},
+		{Name: "plist", Extensions: []string{".plist"}, Parse: ParseChapterPlist, Format: formatChapterPlist},
+		{Name: "ffmetadata", Extensions: []string{".txt", ".ffmetadata"}, Parse: ParseFFmetadataChapters, Format: formatFFmetadataChapters},
+		{Name: "mp3", Extensions: []string{".mp3"}, Parse: parseMP3Chapters},
+	} {
+		RegisterChapterFormat(f)
+	}
+}
+
+// RegisterChapterFormat adds f to the chapter formats, replacing any
+// format with the same name.
+func RegisterChapterFormat(f ChapterFormat) {
+	chapterFormatsMu.Lock()
+	defer chapterFormatsMu.Unlock()
+	chapterFormats[f.Name] = f
+}
+
+// LookupChapterFormat returns the chapter format named name, or
+// ErrUnknownChapterFormat.
+func LookupChapterFormat(name string) (ChapterFormat, error) {
+	chapterFormatsMu.RLock()
+	defer chapterFormatsMu.RUnlock()
+	f, ok := chapterFormats[name]
+	if !ok {
+		return f, fmt.Errorf("%w: %s", ErrUnknownChapterFormat, name)
+	}
+	return f, nil
+}
+
+// ChapterFormatByExtension returns the chapter format of path by its
+// file name extension, or ErrUnknownChapterFormat.
+func ChapterFormatByExtension(path string) (ChapterFormat, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, f := range ChapterFormats() {
+		for _, e := range f.Extensions {
+			if e == ext {
+				return f, nil
+			}
+		}
+	}
+	return ChapterFormat{}, fmt.Errorf("%w: %s", ErrUnknownChapterFormat, path)
+}
+
+// ChapterFormats returns the registered chapter formats by name.
+func ChapterFormats() []ChapterFormat {
+	chapterFormatsMu.RLock()
+	defer chapterFormatsMu.RUnlock()
+	formats := make([]ChapterFormat, 0, len(chapterFormats))
+	for _, f := range chapterFormats {
+		formats = append(formats, f)
+	}
+	sort.Slice(formats, func(i, j int) bool { return formats[i].Name < formats[j].Name })
+	return formats
+}
+
+// ConvertChapters reads chapters in the format named from from r and
+// writes them to w in the format named to. duration is the length of
+// the audio, needed by formats with chapter ends (e.g ffmetadata)
+// unless the last chapter has an End.
+func ConvertChapters(w io.Writer, r io.Reader, from, to string, duration time.Duration) error {
+	src, err := LookupChapterFormat(from)
+	if err != nil {
+		return err
+	}
+	dst, err := LookupChapterFormat(to)
+	if err != nil {
+		return err
+	}
+	if src.Parse == nil {
+		return fmt.Errorf("chapter format %s can not be read", from)
+	}
+	if dst.Format == nil {
+		return fmt.Errorf("chapter format %s can not be written", to)
+	}
+	chapters, err := src.Parse(r)
+	if err != nil {
+		return err
+	}
+	output, err := dst.Format(chapters, duration)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(output)
+	return err
+}
+
+// ParseFFmetadataChapters returns the [CHAPTER] sections of the ffmpeg
+// metadata file in r as chapters. End is set on chapters that do not
+// end where the next one starts and on the last chapter.
+func ParseFFmetadataChapters(r io.Reader) ([]Chapter, error) {
+	type chapter struct {
+		Chapter
+		timebase   [2]int64
+		start, end int64
+	}
+	var sections []chapter
+	inChapter := false
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "" || strings.HasPrefix(text, ";") || strings.HasPrefix(text, "#"):
+			continue
+		case strings.HasPrefix(text, "["):
+			inChapter = strings.EqualFold(text, "[CHAPTER]")
+			if inChapter {
+				sections = append(sections, chapter{timebase: [2]int64{1, 1000}, start: -1, end: -1})
+			}
+			continue
+		case !inChapter:
+			continue
+		}
+		key, value, ok := cutFFmetadataKV(text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key=value", line)
+		}
+		ch := &sections[len(sections)-1]
+		var err error
+		switch strings.ToLower(key) {
+		case "timebase":
+			num, den, ok := strings.Cut(value, "/")
+			if !ok {
+				return nil, fmt.Errorf("line %d: bad TIMEBASE %q", line, value)
+			}
+			if ch.timebase[0], err = strconv.ParseInt(num, 10, 64); err == nil {
+				ch.timebase[1], err = strconv.ParseInt(den, 10, 64)
+			}
+			if err == nil && (ch.timebase[0] <= 0 || ch.timebase[1] <= 0) {
+				err = fmt.Errorf("bad TIMEBASE %q", value)
+			}
+		case "start":
+			ch.start, err = strconv.ParseInt(value, 10, 64)
+		case "end":
+			ch.end, err = strconv.ParseInt(value, 10, 64)
+		case "title":
+			ch.Title = value
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	toMillis := func(t int64, timebase [2]int64) uint32 {
+		return uint32(t * timebase[0] * 1000 / timebase[1])
+	}
+	chapters := make([]Chapter, len(sections))
+	for i, ch := range sections {
+		if ch.start < 0 || ch.end < 0 {
+			return nil, fmt.Errorf("chapter %d has no START or END", i+1)
+		}
+		start, end := toMillis(ch.start, ch.timebase), toMillis(ch.end, ch.timebase)
+		ch.Start = MillisToStringTime(start)
+		if i == len(sections)-1 || end != toMillis(sections[i+1].start, sections[i+1].timebase) {
+			ch.End = MillisToStringTime(end)
+		}
+		chapters[i] = ch.Chapter
+	}
+	return chapters, nil
+}
+
+// cutFFmetadataKV splits an ffmetadata line into key and value at
+// the first unescaped "=", removing the backslash escapes.
+func cutFFmetadataKV(line string) (key, value string, ok bool) {
+	var b strings.Builder
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			b.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '=' && !ok:
+			key, ok = b.String(), true
+			b.Reset()
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return key, b.String(), ok
+}
+
+func parseJSONChapters(r io.Reader) ([]Chapter, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var chapters []Chapter
+	if err := json.Unmarshal(data, &chapters); err == nil {
+		return chapters, nil
+	}
+	var input TrackInfo
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, err
+	}
+	return input.Chapters, nil
+}
+
+func formatJSONChapters(chapters []Chapter, _ time.Duration) ([]byte, error) {
+	output, err := json.MarshalIndent(chapters, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(output, '\n'), nil
+}
+
+func formatChapterToolXML(chapters []Chapter, _ time.Duration) ([]byte, error) {
+	return ChapterToolXML(chapters)
+}
+
+func formatChapterPlist(chapters []Chapter, _ time.Duration) ([]byte, error) {
+	return ChapterPlist(chapters)
+}
+
+func formatCueSheet(chapters []Chapter, _ time.Duration) ([]byte, error) {
+	return CueSheet(chapters, "")
+}
+
+// formatFFmetadataChapters returns chapters as ffmpeg metadata, a
+// zero duration is taken from the End of the last chapter.
+func formatFFmetadataChapters(chapters []Chapter, duration time.Duration) ([]byte, error) {
+	if flat := FlattenChapters(chapters); duration == 0 && len(flat) > 0 && flat[len(flat)-1].End != "" {
+		end, err := StringTimeToMillis(flat[len(flat)-1].End)
+		if err != nil {
+			return nil, ErrBadChapterEndTime
+		}
+		duration = time.Duration(end) * time.Millisecond
+	}
+	return GetFFmpegChapters(duration, chapters)
+}
+
+func parseMP3Chapters(r io.Reader) ([]Chapter, error) {
+	tag, err := id3v2.ParseReader(r, id3v2.Options{Parse: true, ParseFrames: []string{"CHAP", "CTOC"}})
+	if err != nil {
+		return nil, err
+	}
+	return ParseChapters(tag)
+}
+
+// CueSheet returns chapters as a cue sheet with one TRACK per
+// chapter, for file (the FILE line is left out if empty). Nested
+// chapters are flattened, see FlattenChapters.
+func CueSheet(chapters []Chapter, file string) ([]byte, error) {
+	var b bytes.Buffer
+	if file != "" {
+		fmt.Fprintf(&b, "FILE %s MP3\n", strconv.Quote(file))
+	}
+	for i, ch := range FlattenChapters(chapters) {
+		millis, err := StringTimeToMillis(ch.Start)
+		if err != nil {
+			return nil, fmt.Errorf("chapter %d: %w", i+1, err)
+		}
+		frames := millis % 1000 * 75 / 1000
+		fmt.Fprintf(&b, "  TRACK %02d AUDIO\n    TITLE %s\n    INDEX 01 %02d:%02d:%02d\n",
+			i+1, strconv.Quote(ch.Title), millis/60000, millis/1000%60, frames)
+	}
+	return b.Bytes(), nil
+}
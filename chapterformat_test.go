@@ -0,0 +1,114 @@
+package id3v24
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFFmetadataChapters(t *testing.T) {
+	input := `;FFMETADATA1
+title=Episode 1
+
+[CHAPTER]
+TIMEBASE=1/44100
+START=0
+END=220500
+title=Intro\; part 1
+
+[CHAPTER]
+TIMEBASE=1/1000
+START=10000
+END=20000
+title=Main
+
+[STREAM]
+title=ignored
+`
+	chapters, err := ParseFFmetadataChapters(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Chapter{
+		{Title: "Intro; part 1", Start: "00:00:00.000", End: "00:00:05.000"},
+		{Title: "Main", Start: "00:00:10.000", End: "00:00:20.000"},
+	}
+	if !reflect.DeepEqual(chapters, expected) {
+		t.Errorf("expected %v, got %v", expected, chapters)
+	}
+	if _, err := ParseFFmetadataChapters(strings.NewReader("[CHAPTER]\nTIMEBASE=1/1000\nSTART=0\n")); err == nil {
+		t.Error("expected an error for a chapter without END")
+	}
+}
+
+func TestFFmetadataEscapeRoundTrip(t *testing.T) {
+	title := `A=B; C#1 \ D`
+	var b FFMetadataBuilder
+	b.AddChapter(TimebaseMillis, 0, 1000, title)
+	if expected := `title=A\=B\; C\#1 \\ D`; !strings.Contains(string(b.Bytes()), expected+"\n") {
+		t.Errorf("expected %q in %q", expected, b.Bytes())
+	}
+	var ffmetadata bytes.Buffer
+	if err := ConvertChapters(&ffmetadata, bytes.NewReader(b.Bytes()), "ffmetadata", "ffmetadata", 0); err != nil {
+		t.Fatal(err)
+	}
+	if ffmetadata.String() != string(b.Bytes()) {
+		t.Errorf("expected %q, got %q", b.Bytes(), ffmetadata.String())
+	}
+	chapters, err := ParseFFmetadataChapters(&ffmetadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chapters) != 1 || chapters[0].Title != title {
+		t.Errorf("expected title %q, got %+v", title, chapters)
+	}
+}
+
+func TestConvertChapters(t *testing.T) {
+	cue := `FILE "episode.mp3" MP3
+  TRACK 01 AUDIO
+    TITLE "Intro"
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    TITLE "Main"
+    INDEX 01 00:10:00
+`
+	var ffmetadata bytes.Buffer
+	if err := ConvertChapters(&ffmetadata, strings.NewReader(cue), "cue", "ffmetadata", 20*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(ffmetadata.String(), "START=10000\nEND=20000\ntitle=Main\n") {
+		t.Errorf("unexpected ffmetadata %q", ffmetadata.String())
+	}
+	var json bytes.Buffer
+	if err := ConvertChapters(&json, bytes.NewReader(ffmetadata.Bytes()), "ffmetadata", "json", 0); err != nil {
+		t.Fatal(err)
+	}
+	var roundTrip bytes.Buffer
+	if err := ConvertChapters(&roundTrip, bytes.NewReader(json.Bytes()), "json", "ffmetadata", 0); err != nil {
+		t.Fatal(err)
+	}
+	if roundTrip.String() != ffmetadata.String() {
+		t.Errorf("expected %q, got %q", ffmetadata.String(), roundTrip.String())
+	}
+	var sheet bytes.Buffer
+	if err := ConvertChapters(&sheet, bytes.NewReader(json.Bytes()), "json", "cue", 0); err != nil {
+		t.Fatal(err)
+	}
+	chapters, err := ParseCueSheet(&sheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chapters) != 2 || chapters[1].Title != "Main" || chapters[1].Start != "00:00:10.000" {
+		t.Errorf("unexpected chapters %v", chapters)
+	}
+	if err := ConvertChapters(&sheet, strings.NewReader(cue), "cue", "podlove", 0); !errors.Is(err, ErrUnknownChapterFormat) {
+		t.Errorf("expected %v, got %v", ErrUnknownChapterFormat, err)
+	}
+	if f, err := ChapterFormatByExtension("chapters/episode.XML"); err != nil || f.Name != "chaptertool" {
+		t.Errorf("expected chaptertool, got %q and %v", f.Name, err)
+	}
+}
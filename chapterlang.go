@@ -0,0 +1,71 @@
+package id3v24
+
+import (
+	"sort"
+	"strings"
+)
+
+// ChapterTitleDescriptionPrefix prefixes the description of the TXXX
+// sub-frames holding the titles of a chapter in other languages than
+// its TIT2 sub-frame, followed by the ISO 639-2 code of the language,
+// e.g "TITLE:swe". The TIT2 sub-frame keeps the primary title, so
+// players unaware of the scheme still show one. TagChapters reads the
+// titles back into Chapter.Titles.
+const ChapterTitleDescriptionPrefix = "TITLE:"
+
+// LocalizeChapters returns a copy of chapters titled in lang where
+// they have a title in it (see Chapter.Titles), e.g to export the
+// chapters of one language with GetFFmpegChapters or ChapterToolXML.
+func LocalizeChapters(chapters []Chapter, lang string) []Chapter {
+	localized := make([]Chapter, len(chapters))
+	for i, ch := range chapters {
+		if title, ok := ch.Titles[lang]; ok {
+			ch.Title = title
+		}
+		if len(ch.Children) > 0 {
+			ch.Children = LocalizeChapters(ch.Children, lang)
+		}
+		localized[i] = ch
+	}
+	return localized
+}
+
+// chapterTitleTXXX returns a TXXX sub-frame for each title in titles,
+// ordered by language.
+func (o *options) chapterTitleTXXX(titles map[string]string) []byte {
+	langs := make([]string, 0, len(titles))
+	for lang := range titles {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	e := UTF16LEBOM
+	if o.textEncoding != nil {
+		e = *o.textEncoding
+	}
+	var frames []byte
+	for _, lang := range langs {
+		body := EncodeText(ChapterTitleDescriptionPrefix+lang, e)
+		if e == UTF8 {
+			body = append(body, 0x00)
+		} else {
+			body = append(body, 0x00, 0x00)
+		}
+		body = append(body, EncodeText(titles[lang], e)[1:]...)
+		frames = append(frames, subFrame("TXXX", body)...)
+	}
+	return frames
+}
+
+// parseChapterTitleTXXX returns the language and title of a TXXX
+// sub-frame body written by chapterTitleTXXX.
+func parseChapterTitleTXXX(data []byte) (lang, title string, ok bool) {
+	description, value, ok := cutEncodedString(data[1:], data[0])
+	if !ok {
+		return "", "", false
+	}
+	lang, ok = strings.CutPrefix(decodeText(description, data[0]), ChapterTitleDescriptionPrefix)
+	if !ok || lang == "" {
+		return "", "", false
+	}
+	return lang, decodeText(value, data[0]), true
+}
@@ -0,0 +1,757 @@
+package id3v24
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// Kinds of ChapterFix.
+const (
+	ChapterFixSorted         = "sorted"          // chapters were not ordered by start
+	ChapterFixDuplicate      = "duplicate"       // chapter had the same start as a previous one and was dropped
+	ChapterFixBeyondDuration = "beyond-duration" // chapter started at or after the end of the audio and was dropped
+	ChapterFixClamped        = "clamped"         // chapter ended after the end of the audio and was ended there
+)
+
+// ChapterFix describes one change made by FixChapters. ChapterFix
+// implements error so that it can be reported through WithWarnings.
+type ChapterFix struct {
+	Kind    string
+	Chapter Chapter
+}
+
+func (f ChapterFix) Error() string {
+	switch f.Kind {
+	case ChapterFixSorted:
+		return "chapters sorted by start time"
+	case ChapterFixDuplicate:
+		return fmt.Sprintf("dropped chapter %q with duplicate start %s", f.Chapter.Title, f.Chapter.Start)
+	case ChapterFixBeyondDuration:
+		return fmt.Sprintf("dropped chapter %q starting at %s, beyond the end of the audio", f.Chapter.Title, f.Chapter.Start)
+	case ChapterFixClamped:
+		return fmt.Sprintf("chapter %q ending at %s ended at the end of the audio", f.Chapter.Title, f.Chapter.End)
+	}
+	return f.Kind
+}
+
+// FixChapters returns a sanitized copy of chapters, useful when
+// ingesting messy third-party chapter lists: chapters are sorted by
+// start time and chapters with the same start as a previous chapter
+// are dropped. If duration is non-zero, chapters with an End after it
+// are ended at duration and chapters starting at or after it are
+// dropped, as no part of the audio is left for them. Every change is
+// reported as a ChapterFix. Returns error only if a start time can
+// not be parsed.
+func FixChapters(chapters []Chapter, duration time.Duration) ([]Chapter, []ChapterFix, error) {
+	index, fixes, err := fixChapters(chapters, duration)
+	if err != nil {
+		return nil, nil, err
+	}
+	fixed := make([]Chapter, len(index))
+	for i, j := range index {
+		fixed[i] = chapters[j]
+		clampChapterEnd(&fixed[i], duration)
+	}
+	return fixed, fixes, nil
+}
+
+// fixChapters is FixChapters returning the indexes in chapters of the
+// sanitized chapters, leaving the ends to clampChapterEnd.
+func fixChapters(chapters []Chapter, duration time.Duration) ([]int, []ChapterFix, error) {
+	starts, order, err := chapterStartOrder(chapters)
+	if err != nil {
+		return nil, nil, err
+	}
+	var fixes []ChapterFix
+	if !sort.IntsAreSorted(order) {
+		fixes = append(fixes, ChapterFix{Kind: ChapterFixSorted})
+	}
+	index := make([]int, 0, len(order))
+	for i, j := range order {
+		switch {
+		case i > 0 && starts[j] == starts[order[i-1]]:
+			fixes = append(fixes, ChapterFix{Kind: ChapterFixDuplicate, Chapter: chapters[j]})
+		case duration > 0 && time.Duration(starts[j])*time.Millisecond >= duration:
+			fixes = append(fixes, ChapterFix{Kind: ChapterFixBeyondDuration, Chapter: chapters[j]})
+		default:
+			if ch := chapters[j]; clampChapterEnd(&ch, duration) {
+				fixes = append(fixes, ChapterFix{Kind: ChapterFixClamped, Chapter: chapters[j]})
+			}
+			index = append(index, j)
+		}
+	}
+	return index, fixes, nil
+}
+
+// chapterStartOrder returns the start of each chapter in milliseconds
+// and the indexes of chapters stably sorted by start.
+func chapterStartOrder(chapters []Chapter) (starts []uint32, order []int, err error) {
+	starts = make([]uint32, len(chapters))
+	order = make([]int, len(chapters))
+	for i, ch := range chapters {
+		if starts[i], err = StringTimeToMillis(ch.Start); err != nil {
+			return nil, nil, err
+		}
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return starts[order[i]] < starts[order[j]] })
+	return starts, order, nil
+}
+
+// WithChapterAutoFix makes the chapter writers (AddCHAPAndCTOC,
+// GetFFmpegChapters and the functions built on them) pass chapters
+// through FixChapters before encoding them, reporting each fix to the
+// WithWarnings handler.
+func WithChapterAutoFix() Option {
+	return func(o *options) {
+		o.chapterAutoFix = true
+	}
+}
+
+// WithSortChapters makes the chapter writers sort chapters by start
+// time before encoding them, instead of failing with a
+// ChapterOrderError. Chapters with the same start keep their order.
+// Nested chapters (see Chapter.Children) are sorted within their
+// parent, and the parents by their first chapter.
+func WithSortChapters() Option {
+	return func(o *options) {
+		o.chapterSort = true
+	}
+}
+
+// WithClampChapters makes the chapter writers drop chapters starting
+// at or after the end of the audio and end chapters with an End
+// beyond it at the end, reporting each change as a ChapterFix to the
+// WithWarnings handler, instead of failing with
+// ErrChapterBeyondDuration. Chapters with Children left without any
+// are dropped too.
+func WithClampChapters() Option {
+	return func(o *options) {
+		o.chapterClamp = true
+	}
+}
+
+// ChapterOrderError is returned by the chapter writers for a chapter
+// starting before the previous one, as the previous chapter would
+// end before it starts. See WithSortChapters and WithChapterAutoFix.
+type ChapterOrderError struct {
+	Index    int // of Chapter in the (flattened) chapters
+	Chapter  Chapter
+	Previous Chapter
+}
+
+func (e *ChapterOrderError) Error() string {
+	return fmt.Sprintf("chapter %d %q starts at %s, before the previous chapter %q at %s",
+		e.Index+1, e.Chapter.Title, e.Chapter.Start, e.Previous.Title, e.Previous.Start)
+}
+
+// FlattenChapters returns the chapters in chapters that have no
+// Children, and, in their place, the flattened children of those that
+// have, i.e the chapters written as CHAP frames in order.
+func FlattenChapters(chapters []Chapter) []Chapter {
+	var flat []Chapter
+	for _, ch := range chapters {
+		if len(ch.Children) > 0 {
+			flat = append(flat, FlattenChapters(ch.Children)...)
+		} else {
+			flat = append(flat, ch)
+		}
+	}
+	return flat
+}
+
+// PrepareChapters returns chapters as the chapter writers encode them
+// with opts for audio of duration, i.e fixed, sorted and clamped
+// according to WithChapterAutoFix, WithSortChapters and
+// WithClampChapters. Useful to compare chapters read back from a
+// written tag with those written.
+func PrepareChapters(chapters []Chapter, duration time.Duration, opts ...Option) ([]Chapter, error) {
+	return newOptions(opts...).prepareChapterTree(chapters, duration)
+}
+
+// prepareChapterTree is prepareChapters or, if any of chapters has
+// Children, prepareNestedChapters.
+func (o *options) prepareChapterTree(chapters []Chapter, total time.Duration) ([]Chapter, error) {
+	for _, ch := range chapters {
+		if len(ch.Children) > 0 {
+			return o.prepareNestedChapters(chapters, total)
+		}
+	}
+	return o.prepareChapters(chapters, total)
+}
+
+// prepareChapters applies the chapter options to chapters before
+// they are encoded, total is the duration of the audio.
+func (o *options) prepareChapters(chapters []Chapter, total time.Duration) ([]Chapter, error) {
+	index, err := o.prepareChapterIndex(chapters, total)
+	if err != nil {
+		return nil, err
+	}
+	prepared := make([]Chapter, len(index))
+	for i, j := range index {
+		prepared[i] = chapters[j]
+		if o.chapterAutoFix || o.chapterClamp {
+			clampChapterEnd(&prepared[i], total)
+		}
+	}
+	return prepared, nil
+}
+
+// prepareNestedChapters is prepareChapters for chapters with Children,
+// preparing their leaves (see FlattenChapters) as one list. Leaves stay
+// with their parent, ordered within it and the parents by their first
+// leaf, and parents without leaves left are dropped.
+func (o *options) prepareNestedChapters(chapters []Chapter, total time.Duration) ([]Chapter, error) {
+	leaves := FlattenChapters(chapters)
+	index, err := o.prepareChapterIndex(leaves, total)
+	if err != nil {
+		return nil, err
+	}
+	rank := make(map[int]int, len(index)) // leaf to position in index
+	for i, j := range index {
+		rank[j] = i
+	}
+	next := 0
+	var prepare func(chapters []Chapter) ([]Chapter, int)
+	prepare = func(chapters []Chapter) ([]Chapter, int) {
+		type ranked struct {
+			Chapter
+			rank int
+		}
+		var level []ranked
+		for _, ch := range chapters {
+			if len(ch.Children) > 0 {
+				children, first := prepare(ch.Children)
+				if len(children) > 0 {
+					ch.Children = children
+					level = append(level, ranked{ch, first})
+				}
+				continue
+			}
+			if r, ok := rank[next]; ok {
+				if o.chapterAutoFix || o.chapterClamp {
+					clampChapterEnd(&ch, total)
+				}
+				level = append(level, ranked{ch, r})
+			}
+			next++
+		}
+		sort.SliceStable(level, func(i, j int) bool { return level[i].rank < level[j].rank })
+		prepared := make([]Chapter, len(level))
+		for i, ch := range level {
+			prepared[i] = ch.Chapter
+		}
+		if len(level) == 0 {
+			return nil, 0
+		}
+		return prepared, level[0].rank
+	}
+	prepared, _ := prepare(chapters)
+	return prepared, nil
+}
+
+// prepareChapterIndex returns the indexes in chapters of the chapters
+// to encode, in order, according to the chapter options.
+func (o *options) prepareChapterIndex(chapters []Chapter, total time.Duration) ([]int, error) {
+	index := make([]int, len(chapters))
+	for i := range index {
+		index[i] = i
+	}
+	switch {
+	case o.chapterAutoFix:
+		fixed, fixes, err := fixChapters(chapters, total)
+		if err != nil {
+			return nil, err
+		}
+		for _, fix := range fixes {
+			o.warn(fix)
+		}
+		index = fixed
+	case o.chapterSort:
+		_, order, err := chapterStartOrder(chapters)
+		if err != nil {
+			return nil, err
+		}
+		index = order
+	}
+	if o.chapterClamp && total > 0 {
+		return o.clampChapterIndex(chapters, index, total)
+	}
+	return index, nil
+}
+
+// clampChapterIndex drops the chapters of index starting at or after
+// total, reporting each as a ChapterFix, as well as those ending
+// after it, see clampChapterEnd.
+func (o *options) clampChapterIndex(chapters []Chapter, index []int, total time.Duration) ([]int, error) {
+	clamped := make([]int, 0, len(index))
+	for _, j := range index {
+		ch := chapters[j]
+		start, err := StringTimeToDuration(ch.Start)
+		if err != nil {
+			return nil, err
+		}
+		if start >= total {
+			o.warn(ChapterFix{Kind: ChapterFixBeyondDuration, Chapter: ch})
+			continue
+		}
+		if !o.chapterAutoFix && clampChapterEnd(&ch, total) { // else reported by fixChapters
+			o.warn(ChapterFix{Kind: ChapterFixClamped, Chapter: chapters[j]})
+		}
+		clamped = append(clamped, j)
+	}
+	return clamped, nil
+}
+
+// clampChapterEnd ends ch at total, unless zero, if it has an End
+// after it, returns true if it did.
+func clampChapterEnd(ch *Chapter, total time.Duration) bool {
+	if total <= 0 || ch.End == "" {
+		return false
+	}
+	if d, err := StringTimeToDuration(ch.End); err == nil && d > total {
+		ch.End = MillisToStringTime(uint32(total / time.Millisecond))
+		return true
+	}
+	return false
+}
+
+// TagChapters decodes the CHAP frames of tag into chapters ordered by
+// start time, titled by their TIT2 sub-frame and with the titles in
+// other languages of their TXXX sub-frames, see
+// ChapterTitleDescriptionPrefix. End is set for chapters that do not
+// end where the next one starts. Returns nil if tag has no CHAP
+// frames.
+func TagChapters(tag *id3v2.Tag) ([]Chapter, error) {
+	frames, err := ChapterFrames(tag)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(frames, func(i, j int) bool { return frames[i].StartMillis < frames[j].StartMillis })
+	var result []Chapter
+	for i, f := range frames {
+		if i < len(frames)-1 && f.EndMillis != frames[i+1].StartMillis {
+			f.End = MillisToStringTime(f.EndMillis)
+		}
+		result = append(result, f.Chapter)
+	}
+	return result, nil
+}
+
+// ChapterFrame is a decoded CHAP frame.
+type ChapterFrame struct {
+	Chapter
+	ElementID   string
+	StartMillis uint32
+	EndMillis   uint32
+}
+
+// ChapterFrames decodes the CHAP frames of tag in the order they
+// appear in the tag.
+func ChapterFrames(tag *id3v2.Tag) ([]ChapterFrame, error) {
+	var frames []ChapterFrame
+	for _, f := range tag.GetFrames("CHAP") {
+		uf, ok := f.(id3v2.UnknownFrame)
+		if !ok {
+			continue
+		}
+		cf, err := parseCHAP(uf.Body)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, cf)
+	}
+	return frames, nil
+}
+
+// ParseChapters decodes the CHAP frames of tag into chapters in the
+// order of the top-level CTOC frame, following nested tables of
+// contents, as players present them. CHAP frames not referenced from
+// a table of contents (or all of them, if tag has no CTOC frame)
+// follow ordered by start time. Returns nil if tag has no CHAP
+// frames.
+func ParseChapters(tag *id3v2.Tag) ([]Chapter, error) {
+	frames, err := ChapterFrames(tag)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[string]int{}
+	for i, f := range frames {
+		byID[f.ElementID] = i
+	}
+	tocs := map[string][]string{}
+	var topLevel []string
+	for _, f := range tag.GetFrames("CTOC") {
+		uf, ok := f.(id3v2.UnknownFrame)
+		if !ok {
+			continue
+		}
+		id, top, children, err := parseCTOC(uf.Body)
+		if err != nil {
+			return nil, err
+		}
+		tocs[id] = children
+		if top {
+			topLevel = append(topLevel, id)
+		}
+	}
+	var result []Chapter
+	used := make([]bool, len(frames))
+	visited := map[string]bool{}
+	var walk func(ids []string)
+	walk = func(ids []string) {
+		for _, id := range ids {
+			if i, ok := byID[id]; ok && !used[i] {
+				used[i] = true
+				result = append(result, frames[i].Chapter)
+			} else if children, ok := tocs[id]; ok && !visited[id] {
+				visited[id] = true
+				walk(children)
+			}
+		}
+	}
+	walk(topLevel)
+	var rest []ChapterFrame
+	for i, f := range frames {
+		if !used[i] {
+			rest = append(rest, f)
+		}
+	}
+	sort.SliceStable(rest, func(i, j int) bool { return rest[i].StartMillis < rest[j].StartMillis })
+	for _, f := range rest {
+		result = append(result, f.Chapter)
+	}
+	return result, nil
+}
+
+// parseCHAP decodes a CHAP frame body, the chapter is titled by the
+// TIT2 sub-frame, described by the TIT3 sub-frame and linked by the
+// URL of the WXXX sub-frame. Image is set from an APIC sub-frame
+// linking to the picture by URL, embedded pictures are not read.
+func parseCHAP(body []byte) (cf ChapterFrame, err error) {
+	i := bytes.IndexByte(body, 0x00)
+	if i < 0 || len(body) < i+1+16 {
+		return cf, ErrBadFrame
+	}
+	cf.ElementID = string(body[:i])
+	cf.StartMillis = binary.BigEndian.Uint32(body[i+1:])
+	cf.EndMillis = binary.BigEndian.Uint32(body[i+5:])
+	cf.Start = MillisToStringTime(cf.StartMillis)
+	subFrames := body[i+1+16:]
+	for len(subFrames) >= id3v2HeaderSize {
+		id := string(subFrames[0:4])
+		size, ok := subFrameSize(subFrames[4:8], len(subFrames)-id3v2HeaderSize)
+		if !ok {
+			return cf, ErrBadFrame
+		}
+		data := subFrames[id3v2HeaderSize : id3v2HeaderSize+size]
+		switch {
+		case id == "TIT2" && len(data) > 0:
+			cf.Title = decodeText(data[1:], data[0])
+		case id == "TIT3" && len(data) > 0:
+			cf.Description = decodeText(data[1:], data[0])
+		case id == "WXXX" && len(data) > 0:
+			if _, url, ok := cutEncodedString(data[1:], data[0]); ok {
+				cf.URL = string(url)
+			}
+		case id == "APIC" && len(data) > 0:
+			if mimeType, rest, ok := bytes.Cut(data[1:], []byte{0x00}); ok && string(mimeType) == chapterImageLink && len(rest) > 0 {
+				if _, link, ok := cutEncodedString(rest[1:], data[0]); ok {
+					cf.Image = string(link)
+				}
+			}
+		case id == "TXXX" && len(data) > 0:
+			if lang, title, ok := parseChapterTitleTXXX(data); ok {
+				if cf.Titles == nil {
+					cf.Titles = map[string]string{}
+				}
+				cf.Titles[lang] = title
+			}
+		}
+		subFrames = subFrames[id3v2HeaderSize+size:]
+	}
+	return cf, nil
+}
+
+// parseCTOC returns the element ID, top-level flag and child element
+// IDs of a CTOC frame body.
+func parseCTOC(body []byte) (id string, topLevel bool, children []string, err error) {
+	i := bytes.IndexByte(body, 0x00)
+	if i < 0 || len(body) < i+3 {
+		return "", false, nil, ErrBadFrame
+	}
+	id = string(body[:i])
+	topLevel = body[i+1]&0x02 != 0
+	count := int(body[i+2])
+	rest := body[i+3:]
+	for ; count > 0; count-- {
+		child, tail, ok := bytes.Cut(rest, []byte{0x00})
+		if !ok {
+			return "", false, nil, ErrBadFrame
+		}
+		children = append(children, string(child))
+		rest = tail
+	}
+	return id, topLevel, children, nil
+}
+
+// subFrameSize returns the size of an embedded frame, synchsafe as
+// per ID3v2.4 or, if that does not fit in max bytes, plain 32 bit as
+// written by ID3v2.3 taggers.
+func subFrameSize(b []byte, max int) (int, bool) {
+	synchsafe := 0
+	for _, c := range b {
+		if c&0x80 != 0 {
+			synchsafe = -1
+			break
+		}
+		synchsafe = synchsafe<<7 | int(c)
+	}
+	if synchsafe >= 0 && synchsafe <= max {
+		return synchsafe, true
+	}
+	if plain := binary.BigEndian.Uint32(b); int64(plain) <= int64(max) {
+		return int(plain), true
+	}
+	return 0, false
+}
+
+// MergeChapters returns the union of existing and updates ordered by
+// start time. Where both have a chapter with the same start time, the
+// one in updates wins.
+func MergeChapters(existing, updates []Chapter) ([]Chapter, error) {
+	type chapter struct {
+		Chapter
+		start uint32
+	}
+	byStart := map[uint32]int{}
+	var merged []chapter
+	for _, chapters := range [][]Chapter{existing, updates} {
+		for _, ch := range chapters {
+			m, err := StringTimeToMillis(ch.Start)
+			if err != nil {
+				return nil, err
+			}
+			if i, ok := byStart[m]; ok {
+				merged[i].Chapter = ch
+				continue
+			}
+			byStart[m] = len(merged)
+			merged = append(merged, chapter{Chapter: ch, start: m})
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].start < merged[j].start })
+	result := make([]Chapter, len(merged))
+	for i, ch := range merged {
+		result[i] = ch.Chapter
+	}
+	return result, nil
+}
+
+// WithChapterMerge makes WriteID3v2Tag, WriteID3v2TagTo and the DSF
+// writers merge TrackInfo.Chapters with the CHAP frames already in
+// the file (see MergeChapters) instead of replacing them, for
+// incremental chapter editing.
+func WithChapterMerge() Option {
+	return func(o *options) {
+		o.chapterMerge = true
+	}
+}
+
+// mergeExistingChapters returns input with its chapters merged with
+// those of the tagSize bytes long ID3v2 tag at the current position
+// of r if WithChapterMerge was given.
+func (o *options) mergeExistingChapters(r io.Reader, tagSize int64, input TrackInfo) (TrackInfo, error) {
+	if !o.chapterMerge || tagSize <= 0 {
+		return input, nil
+	}
+	existing, err := id3v2.ParseReader(io.LimitReader(r, tagSize), id3v2.Options{
+		Parse:       true,
+		ParseFrames: []string{"CHAP"},
+	})
+	if err != nil {
+		return input, err
+	}
+	chapters, err := TagChapters(existing)
+	if err != nil {
+		return input, err
+	}
+	input.Chapters, err = MergeChapters(chapters, input.Chapters)
+	return input, err
+}
+
+// ChapterElementID returns the element ID of the CHAP frame written
+// for the chapter at index i, referenced from the CTOC frame.
+func ChapterElementID(i int) string {
+	return strconv.Itoa(i + 1)
+}
+
+var autoNumberedTitle = regexp.MustCompile(`^(Chapter|Track|Cue|Part) (\d+)$`)
+
+// RenumberChapters returns a copy of chapters sorted by start time
+// with auto-numbered titles ("Chapter N", "Track N", "Cue N" or "Part N")
+// renumbered by position, keeping zero padding, e.g after inserting
+// or deleting chapters of a list decoded with TagChapters. Element
+// IDs are assigned by position (see ChapterElementID) when the
+// chapters are written, so they follow the new order. Other titles
+// are left as is.
+func RenumberChapters(chapters []Chapter) ([]Chapter, error) {
+	starts := make([]uint32, len(chapters))
+	index := make([]int, len(chapters))
+	for i, ch := range chapters {
+		m, err := StringTimeToMillis(ch.Start)
+		if err != nil {
+			return nil, err
+		}
+		starts[i] = m
+		index[i] = i
+	}
+	sort.SliceStable(index, func(i, j int) bool { return starts[index[i]] < starts[index[j]] })
+	renumbered := make([]Chapter, len(chapters))
+	for i, j := range index {
+		ch := chapters[j]
+		if m := autoNumberedTitle.FindStringSubmatch(ch.Title); m != nil {
+			ch.Title = fmt.Sprintf("%s %0*d", m[1], len(m[2]), i+1)
+		}
+		renumbered[i] = ch
+	}
+	return renumbered, nil
+}
+
+// GenerateIntervalChapters returns chapters starting every every up
+// to duration, e.g for long unstructured recordings like lectures.
+// titleTemplate is a fmt format for the 1-based chapter number, e.g
+// "Part %d" (the default if empty), or a prefix that the number is
+// appended to.
+func GenerateIntervalChapters(duration, every time.Duration, titleTemplate string) ([]Chapter, error) {
+	if duration <= 0 {
+		return nil, ErrZeroDuration
+	}
+	if every < time.Millisecond {
+		return nil, fmt.Errorf("chapter interval %v is less than 1ms", every)
+	}
+	if titleTemplate == "" {
+		titleTemplate = "Part %d"
+	} else if !strings.Contains(titleTemplate, "%") {
+		titleTemplate += " %d"
+	}
+	var chapters []Chapter
+	for start := time.Duration(0); start < duration; start += every {
+		chapters = append(chapters, Chapter{
+			Title: fmt.Sprintf(titleTemplate, len(chapters)+1),
+			Start: MillisToStringTime(uint32(start / time.Millisecond)),
+		})
+	}
+	return chapters, nil
+}
+
+// AdInsertion is an ad of Duration inserted at At, a position in the
+// original audio, e.g by dynamic ad stitching.
+type AdInsertion struct {
+	At       time.Duration
+	Duration time.Duration
+}
+
+// InsertAdBreaks returns chapters, sorted by start time, shifted to
+// where they start in the audio with ads inserted. A chapter starting
+// at or after an insertion point is shifted by the duration of the
+// ad. Unless adBreakTitle is empty, a chapter titled adBreakTitle is
+// added for every ad and, if an ad interrupts a chapter, the
+// interrupted chapter is resumed after it by a chapter with the same
+// title. An End is shifted by the ads inserted before it.
+func InsertAdBreaks(chapters []Chapter, ads []AdInsertion, adBreakTitle string) ([]Chapter, error) {
+	type chapter struct {
+		Chapter
+		start time.Duration
+		end   time.Duration // 0 unless End is set
+	}
+	sorted := make([]chapter, len(chapters))
+	for i, ch := range chapters {
+		m, err := StringTimeToMillis(ch.Start)
+		if err != nil {
+			return nil, err
+		}
+		sorted[i] = chapter{Chapter: ch, start: time.Duration(m) * time.Millisecond}
+		if ch.End != "" {
+			m, err := StringTimeToMillis(ch.End)
+			if err != nil {
+				return nil, ErrBadChapterEndTime
+			}
+			sorted[i].end = time.Duration(m) * time.Millisecond
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].start < sorted[j].start })
+	ads = append([]AdInsertion(nil), ads...)
+	sort.SliceStable(ads, func(i, j int) bool { return ads[i].At < ads[j].At })
+	var result []chapter
+	shift := time.Duration(0)
+	next := 0 // next chapter of sorted
+	for _, ad := range ads {
+		if ad.Duration <= 0 {
+			continue
+		}
+		for ; next < len(sorted) && sorted[next].start < ad.At; next++ {
+			ch := sorted[next]
+			ch.start += shift
+			result = append(result, ch)
+		}
+		if adBreakTitle == "" {
+			shift += ad.Duration
+			continue
+		}
+		if last := len(result) - 1; last >= 0 && result[last].start == ad.At+shift {
+			// Back to back ads, the chapter resumed after the
+			// previous one is resumed after this one instead.
+			result = result[:last]
+		}
+		interrupted := next > 0 && (sorted[next-1].end == 0 || sorted[next-1].end > ad.At)
+		if last := len(result) - 1; interrupted && last >= 0 && result[last].end > 0 {
+			// Ends where the ad break starts.
+			result[last].end = 0
+			result[last].Chapter.End = ""
+		}
+		result = append(result, chapter{Chapter: Chapter{Title: adBreakTitle}, start: ad.At + shift})
+		shift += ad.Duration
+		if interrupted && (next == len(sorted) || sorted[next].start > ad.At) {
+			resumed := sorted[next-1]
+			resumed.start = ad.At + shift
+			result = append(result, resumed)
+		}
+	}
+	for ; next < len(sorted); next++ {
+		ch := sorted[next]
+		ch.start += shift
+		result = append(result, ch)
+	}
+	shifted := make([]Chapter, len(result))
+	for i, ch := range result {
+		if ch.start/time.Millisecond > math.MaxUint32 {
+			return nil, ErrBadChapterStartTime
+		}
+		ch.Chapter.Start = MillisToStringTime(uint32(ch.start / time.Millisecond))
+		if ch.end > 0 {
+			end := ch.end
+			for _, ad := range ads {
+				if ad.Duration > 0 && ad.At < ch.end {
+					end += ad.Duration
+				}
+			}
+			if end/time.Millisecond > math.MaxUint32 {
+				return nil, ErrBadChapterEndTime
+			}
+			ch.Chapter.End = MillisToStringTime(uint32(end / time.Millisecond))
+		}
+		shifted[i] = ch.Chapter
+	}
+	return shifted, nil
+}
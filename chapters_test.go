@@ -0,0 +1,684 @@
+package id3v24
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	id3v2 "github.com/bogem/id3v2"
+	"github.com/sa6mwa/mp3duration"
+)
+
+func TestFixChapters(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Chapter 2", Start: "00:00:10"},
+		{Title: "Chapter 1", Start: "00:00:00"},
+		{Title: "Chapter 2 again", Start: "00:00:10.000"},
+		{Title: "Chapter 3", Start: "00:00:20", End: "00:00:45"},
+		{Title: "Chapter 4", Start: "00:00:40"},
+	}
+	fixed, fixes, err := FixChapters(chapters, 30*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Chapter 4 is dropped as it would be empty when clamped.
+	expected := []Chapter{chapters[1], chapters[0], {Title: "Chapter 3", Start: "00:00:20", End: "00:00:30.000"}}
+	if !reflect.DeepEqual(fixed, expected) {
+		t.Errorf("expected %v, got %v", expected, fixed)
+	}
+	expectedFixes := []ChapterFix{
+		{Kind: ChapterFixSorted},
+		{Kind: ChapterFixDuplicate, Chapter: chapters[2]},
+		{Kind: ChapterFixClamped, Chapter: chapters[3]},
+		{Kind: ChapterFixBeyondDuration, Chapter: chapters[4]},
+	}
+	if !reflect.DeepEqual(fixes, expectedFixes) {
+		t.Errorf("expected fixes %v, got %v", expectedFixes, fixes)
+	}
+}
+
+func TestGetFFmpegChaptersAutoFix(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Chapter 2", Start: "00:00:10"},
+		{Title: "Chapter 1", Start: "00:00:00"},
+	}
+	var warnings []error
+	output, err := GetFFmpegChapters(30*time.Second, chapters,
+		WithChapterAutoFix(),
+		WithWarnings(func(err error) { warnings = append(warnings, err) }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(output), "START=0\nEND=10000\ntitle=Chapter 1\n") {
+		t.Errorf("chapters were not sorted: %q", output)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("expected 1 warning, got %v", warnings)
+	}
+}
+
+func TestChapterOrder(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Chapter 1", Start: "00:00:00"},
+		{Title: "Chapter 3", Start: "00:00:20"},
+		{Title: "Chapter 2", Start: "00:00:10"},
+	}
+	tag := id3v2.NewEmptyTag()
+	err := AddCHAPAndCTOC(mp3duration.Info{TimeDuration: 30 * time.Second}, tag, chapters)
+	var orderErr *ChapterOrderError
+	if !errors.As(err, &orderErr) {
+		t.Fatalf("expected a ChapterOrderError, got %v", err)
+	}
+	if orderErr.Index != 2 || orderErr.Chapter.Title != "Chapter 2" || orderErr.Previous.Title != "Chapter 3" {
+		t.Errorf("expected chapter 3 before chapter 2, got %+v", orderErr)
+	}
+	if _, err := GetFFmpegChapters(30*time.Second, chapters); !errors.As(err, &orderErr) {
+		t.Errorf("expected a ChapterOrderError, got %v", err)
+	}
+	output, err := GetFFmpegChapters(30*time.Second, chapters, WithSortChapters())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(output), "START=10000\nEND=20000\ntitle=Chapter 2\n") {
+		t.Errorf("chapters were not sorted: %q", output)
+	}
+	if err := AddCHAPAndCTOC(mp3duration.Info{TimeDuration: 30 * time.Second}, tag, chapters, WithSortChapters()); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := TagChapters(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 3 || decoded[1].Title != "Chapter 2" || decoded[1].End != "" {
+		t.Errorf("expected the sorted chapters, got %v", decoded)
+	}
+}
+
+func TestChapterBeyondDuration(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Chapter 1", Start: "00:00:00"},
+		{Title: "Chapter 2", Start: "00:00:20", End: "00:00:40"},
+		{Title: "Chapter 3", Start: "00:00:30"},
+	}
+	tag := id3v2.NewEmptyTag()
+	if err := AddCHAPAndCTOC(mp3duration.Info{TimeDuration: 30 * time.Second}, tag, chapters); !errors.Is(err, ErrChapterBeyondDuration) {
+		t.Errorf("expected ErrChapterBeyondDuration, got %v", err)
+	}
+	var warnings []error
+	err := AddCHAPAndCTOC(mp3duration.Info{TimeDuration: 30 * time.Second}, tag, chapters,
+		WithClampChapters(),
+		WithWarnings(func(err error) { warnings = append(warnings, err) }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, err := ChapterFrames(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 2 || frames[1].EndMillis != 30000 {
+		t.Errorf("expected 2 chapters, the last ending at 30000, got %v", frames)
+	}
+	expected := []error{
+		ChapterFix{Kind: ChapterFixClamped, Chapter: chapters[1]},
+		ChapterFix{Kind: ChapterFixBeyondDuration, Chapter: chapters[2]},
+	}
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("expected %v, got %v", expected, warnings)
+	}
+}
+
+func TestWithChapterMerge(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var first bytes.Buffer
+	if err := WriteID3v2TagTo(&first, bytes.NewReader(mp3), TrackInfo{Chapters: []Chapter{
+		{Title: "Intro", Start: "00:00:00"},
+		{Title: "Chapter 1", Start: "00:00:01"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	var second bytes.Buffer
+	if err := WriteID3v2TagTo(&second, bytes.NewReader(first.Bytes()), TrackInfo{Chapters: []Chapter{
+		{Title: "Outro", Start: "00:00:02.5"},
+		{Title: "Welcome", Start: "00:00:00"},
+	}}, WithChapterMerge()); err != nil {
+		t.Fatal(err)
+	}
+	tag, err := id3v2.ParseReader(bytes.NewReader(second.Bytes()), id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chapters, err := TagChapters(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Chapter{
+		{Title: "Welcome", Start: "00:00:00.000"},
+		{Title: "Chapter 1", Start: "00:00:01.000"},
+		{Title: "Outro", Start: "00:00:02.500"},
+	}
+	if !reflect.DeepEqual(chapters, expected) {
+		t.Errorf("expected %v, got %v", expected, chapters)
+	}
+}
+
+func TestRenumberChapters(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Chapter 01", Start: "00:00:00"},
+		{Title: "Chapter 02", Start: "00:10:00"},
+		{Title: "Inserted", Start: "00:05:00"},
+		{Title: "Track 9", Start: "00:20:00"},
+		{Title: "Chapter 3 revisited", Start: "00:15:00"},
+	}
+	renumbered, err := RenumberChapters(chapters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Chapter{
+		{Title: "Chapter 01", Start: "00:00:00"},
+		{Title: "Inserted", Start: "00:05:00"},
+		{Title: "Chapter 03", Start: "00:10:00"},
+		{Title: "Chapter 3 revisited", Start: "00:15:00"},
+		{Title: "Track 5", Start: "00:20:00"},
+	}
+	if !reflect.DeepEqual(renumbered, expected) {
+		t.Errorf("expected %v, got %v", expected, renumbered)
+	}
+	if chapters[1].Title != "Chapter 02" {
+		t.Error("input was modified")
+	}
+	if id := ChapterElementID(0); id != "1" {
+		t.Errorf("expected element ID %q, got %q", "1", id)
+	}
+}
+
+func TestGenerateIntervalChapters(t *testing.T) {
+	chapters, err := GenerateIntervalChapters(25*time.Minute, 10*time.Minute, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Chapter{
+		{Title: "Part 1", Start: "00:00:00.000"},
+		{Title: "Part 2", Start: "00:10:00.000"},
+		{Title: "Part 3", Start: "00:20:00.000"},
+	}
+	if !reflect.DeepEqual(chapters, expected) {
+		t.Errorf("expected %v, got %v", expected, chapters)
+	}
+	chapters, err = GenerateIntervalChapters(20*time.Minute, 10*time.Minute, "Lecture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chapters) != 2 || chapters[1].Title != "Lecture 2" {
+		t.Errorf("unexpected chapters %v", chapters)
+	}
+	chapters, err = GenerateIntervalChapters(time.Hour, 30*time.Minute, "Sermon part %02d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chapters[0].Title != "Sermon part 01" {
+		t.Errorf("unexpected title %q", chapters[0].Title)
+	}
+	if _, err := GenerateIntervalChapters(time.Hour, 0, ""); err == nil {
+		t.Error("expected an error for a zero interval")
+	}
+}
+
+func TestChapterTitleLanguages(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chapters := []Chapter{
+		{Title: "Intro", Start: "00:00:00.000", Titles: map[string]string{"swe": "Början", "deu": "Anfang"}},
+		{Title: "The end", Start: "00:00:01.000", Titles: map[string]string{"swe": "Slutet"}},
+	}
+	for _, opts := range [][]Option{nil, {WithTextEncoding(UTF16BE)}, {WithTextEncoding(UTF8)}} {
+		var out bytes.Buffer
+		if err := WriteID3v2TagTo(&out, bytes.NewReader(mp3), TrackInfo{Chapters: chapters}, opts...); err != nil {
+			t.Fatal(err)
+		}
+		tag, err := id3v2.ParseReader(bytes.NewReader(out.Bytes()), id3v2.Options{Parse: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := TagChapters(tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, chapters) {
+			t.Errorf("expected %v, got %v", chapters, got)
+		}
+	}
+	localized := LocalizeChapters(chapters, "deu")
+	if localized[0].Title != "Anfang" || localized[1].Title != "The end" {
+		t.Errorf("unexpected localized chapters %v", localized)
+	}
+	if chapters[0].Title != "Intro" {
+		t.Error("expected chapters to be left as is")
+	}
+}
+
+func TestInsertAdBreaks(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Intro", Start: "00:00:00.000"},
+		{Title: "Interview", Start: "00:05:00.000"},
+		{Title: "Outro", Start: "00:20:00.000"},
+	}
+	ads := []AdInsertion{
+		{At: 10 * time.Minute, Duration: 30 * time.Second},
+		{At: 0, Duration: 15 * time.Second},
+		{At: 10 * time.Minute, Duration: 30 * time.Second},
+		{At: 20 * time.Minute, Duration: time.Minute},
+	}
+	shifted, err := InsertAdBreaks(chapters, ads, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Chapter{
+		{Title: "Intro", Start: "00:00:15.000"},
+		{Title: "Interview", Start: "00:05:15.000"},
+		{Title: "Outro", Start: "00:22:15.000"},
+	}
+	if !reflect.DeepEqual(shifted, expected) {
+		t.Errorf("expected %v, got %v", expected, shifted)
+	}
+	shifted, err = InsertAdBreaks(chapters, ads, "Ad break")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []Chapter{
+		{Title: "Ad break", Start: "00:00:00.000"},
+		{Title: "Intro", Start: "00:00:15.000"},
+		{Title: "Interview", Start: "00:05:15.000"},
+		{Title: "Ad break", Start: "00:10:15.000"},
+		{Title: "Ad break", Start: "00:10:45.000"},
+		{Title: "Interview", Start: "00:11:15.000"},
+		{Title: "Ad break", Start: "00:21:15.000"},
+		{Title: "Outro", Start: "00:22:15.000"},
+	}
+	if !reflect.DeepEqual(shifted, expected) {
+		t.Errorf("expected %v, got %v", expected, shifted)
+	}
+}
+
+func TestParseChapters(t *testing.T) {
+	tag := id3v2.NewEmptyTag()
+	tag.SetVersion(4)
+	chapters := []Chapter{
+		{Title: "One", Start: "00:00:00.000"},
+		{Title: "Two", Start: "00:01:00.000"},
+		{Title: "Three", Start: "00:02:00.000"},
+	}
+	if err := AddCHAPAndCTOC(durationInfo(3*time.Minute), tag, chapters); err != nil {
+		t.Fatal(err)
+	}
+	frames, err := ChapterFrames(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ends := map[string]uint32{}
+	for _, f := range frames {
+		ends[f.ElementID] = f.EndMillis
+	}
+	if expected := map[string]uint32{"1": 60000, "2": 120000, "3": 180000}; !reflect.DeepEqual(ends, expected) {
+		t.Errorf("expected ends %v, got %v", expected, ends)
+	}
+	parsed, err := ParseChapters(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, chapters) {
+		t.Errorf("expected %v, got %v", chapters, parsed)
+	}
+	// A top-level table of contents listing chapter 3 first, then a
+	// nested one with chapter 1, leaving chapter 2 unreferenced.
+	tag.DeleteFrames("CTOC")
+	tag.AddFrame("CTOC", id3v2.UnknownFrame{Body: []byte("toc\x00\x03\x02" + "3\x00" + "part\x00")})
+	tag.AddFrame("CTOC", id3v2.UnknownFrame{Body: []byte("part\x00\x01\x01" + "1\x00")})
+	parsed, err = ParseChapters(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Chapter{chapters[2], chapters[0], chapters[1]}
+	if !reflect.DeepEqual(parsed, expected) {
+		t.Errorf("expected %v, got %v", expected, parsed)
+	}
+	tag.AddFrame("CTOC", id3v2.UnknownFrame{Body: []byte("bad\x00\x03\x02" + "1")})
+	if _, err := ParseChapters(tag); err != ErrBadFrame {
+		t.Errorf("expected %v, got %v", ErrBadFrame, err)
+	}
+}
+
+func TestChapterEnd(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Intro", Start: "00:00:00", End: "00:00:05"},
+		{Title: "Main", Start: "00:00:10"},
+		{Title: "Bonus", Start: "00:00:20", End: "00:00:25"},
+	}
+	output, err := GetFFmpegChapters(30*time.Second, chapters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"START=0\nEND=5000\n", "START=10000\nEND=20000\n", "START=20000\nEND=25000\n"} {
+		if !strings.Contains(string(output), expected) {
+			t.Errorf("expected %q in %q", expected, output)
+		}
+	}
+	tag := id3v2.NewEmptyTag()
+	tag.SetVersion(4)
+	if err := AddCHAPAndCTOC(mp3duration.Info{TimeDuration: 30 * time.Second}, tag, chapters); err != nil {
+		t.Fatal(err)
+	}
+	got, err := TagChapters(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Chapter{
+		{Title: "Intro", Start: "00:00:00.000", End: "00:00:05.000"},
+		{Title: "Main", Start: "00:00:10.000"},
+		{Title: "Bonus", Start: "00:00:20.000"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	chapters[1].End = "00:00:09"
+	if _, err := GetFFmpegChapters(30*time.Second, chapters); !errors.Is(err, ErrBadChapterEndTime) {
+		t.Errorf("expected %v, got %v", ErrBadChapterEndTime, err)
+	}
+	shifted, err := InsertAdBreaks(chapters[:1], []AdInsertion{{At: 2 * time.Second, Duration: 30 * time.Second}}, "Ad break")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []Chapter{
+		{Title: "Intro", Start: "00:00:00.000"},
+		{Title: "Ad break", Start: "00:00:02.000"},
+		{Title: "Intro", Start: "00:00:32.000", End: "00:00:35.000"},
+	}
+	if !reflect.DeepEqual(shifted, expected) {
+		t.Errorf("expected %v, got %v", expected, shifted)
+	}
+}
+
+func TestNestedChapters(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Introduction", Start: "00:00:00"},
+		{Title: "Part One", Children: []Chapter{
+			{Title: "Chapter 1", Start: "00:00:05"},
+			{Title: "Chapter 2", Start: "00:00:10"},
+		}},
+		{Title: "Part Two", Children: []Chapter{
+			{Title: "Chapter 3", Start: "00:00:15"},
+		}},
+	}
+	tag := id3v2.NewEmptyTag()
+	tag.SetVersion(4)
+	if err := AddCHAPAndCTOC(mp3duration.Info{TimeDuration: 30 * time.Second}, tag, chapters); err != nil {
+		t.Fatal(err)
+	}
+	tocs := map[string][]string{}
+	for _, f := range tag.GetFrames("CTOC") {
+		id, topLevel, children, err := parseCTOC(f.(id3v2.UnknownFrame).Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if topLevel != (id == "toc") {
+			t.Errorf("%s: unexpected top-level flag %t", id, topLevel)
+		}
+		tocs[id] = children
+	}
+	expectedTOCs := map[string][]string{
+		"toc":  {"1", "toc1", "toc2"},
+		"toc1": {"2", "3"},
+		"toc2": {"4"},
+	}
+	if !reflect.DeepEqual(tocs, expectedTOCs) {
+		t.Errorf("expected %v, got %v", expectedTOCs, tocs)
+	}
+	for _, f := range tag.GetFrames("CTOC") {
+		if body := f.(id3v2.UnknownFrame).Body; bytes.HasPrefix(body, []byte("toc1\x00")) && !bytes.Contains(body, []byte("TIT2")) {
+			t.Errorf("expected a TIT2 sub-frame in %q", body)
+		}
+	}
+	got, err := ParseChapters(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, ch := range got {
+		titles = append(titles, ch.Title)
+	}
+	expectedTitles := []string{"Introduction", "Chapter 1", "Chapter 2", "Chapter 3"}
+	if !reflect.DeepEqual(titles, expectedTitles) {
+		t.Errorf("expected %v, got %v", expectedTitles, titles)
+	}
+	output, err := GetFFmpegChapters(30*time.Second, chapters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(output), "START=15000\nEND=30000\ntitle=Chapter 3\n") {
+		t.Errorf("unexpected ffmetadata chapters %q", output)
+	}
+}
+
+func TestNestedChaptersPrepared(t *testing.T) {
+	// One child per parent flattens to as many chapters as there are
+	// parents.
+	chapters := []Chapter{
+		{Title: "Part One", Children: []Chapter{{Title: "Chapter 1", Start: "00:00:00"}}},
+		{Title: "Part Two", Children: []Chapter{{Title: "Chapter 2", Start: "00:00:10"}}},
+	}
+	tag := id3v2.NewEmptyTag()
+	tag.SetVersion(4)
+	if err := AddCHAPAndCTOC(mp3duration.Info{TimeDuration: 30 * time.Second}, tag, chapters); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(tag.GetFrames("CTOC")); n != 3 {
+		t.Errorf("expected 3 CTOC frames, got %d", n)
+	}
+
+	chapters = []Chapter{
+		{Title: "Part Two", Children: []Chapter{
+			{Title: "Chapter 4", Start: "00:00:40"},
+			{Title: "Chapter 3", Start: "00:00:15", End: "00:00:35"},
+		}},
+		{Title: "Part One", Children: []Chapter{
+			{Title: "Chapter 2", Start: "00:00:10"},
+			{Title: "Chapter 1", Start: "00:00:05"},
+			{Title: "Chapter 1 again", Start: "00:00:05"},
+		}},
+	}
+	for _, tc := range []struct {
+		opt      Option
+		tocs     map[string][]string
+		titles   []string
+		warnings int
+	}{
+		{
+			opt:      WithChapterAutoFix(),
+			tocs:     map[string][]string{"toc": {"toc1", "toc2"}, "toc1": {"1", "2"}, "toc2": {"3"}},
+			titles:   []string{"Chapter 1@00:00:05.000", "Chapter 2@00:00:10.000", "Chapter 3@00:00:15.000"},
+			warnings: 4, // sorted, duplicate, beyond-duration and clamped
+		},
+		{
+			opt:      WithSortChapters(),
+			tocs:     map[string][]string{"toc": {"toc1", "toc2"}, "toc1": {"1", "2", "3"}, "toc2": {"4"}},
+			titles:   []string{"Chapter 1@00:00:05.000", "Chapter 1 again@00:00:05.000", "Chapter 2@00:00:10.000", "Chapter 3@00:00:15.000"},
+			warnings: 2, // beyond-duration and clamped
+		},
+	} {
+		var warnings []error
+		tag := id3v2.NewEmptyTag()
+		tag.SetVersion(4)
+		opts := []Option{tc.opt, WithClampChapters(), WithWarnings(func(err error) { warnings = append(warnings, err) })}
+		if err := AddCHAPAndCTOC(mp3duration.Info{TimeDuration: 30 * time.Second}, tag, chapters, opts...); err != nil {
+			t.Fatal(err)
+		}
+		tocs := map[string][]string{}
+		for _, f := range tag.GetFrames("CTOC") {
+			id, _, children, err := parseCTOC(f.(id3v2.UnknownFrame).Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tocs[id] = children
+		}
+		if !reflect.DeepEqual(tocs, tc.tocs) {
+			t.Errorf("expected %v, got %v", tc.tocs, tocs)
+		}
+		got, err := ParseChapters(tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var titles []string
+		for _, ch := range got {
+			titles = append(titles, ch.Title+"@"+ch.Start)
+		}
+		if !reflect.DeepEqual(titles, tc.titles) {
+			t.Errorf("expected %v, got %v", tc.titles, titles)
+		}
+		if len(warnings) != tc.warnings {
+			t.Errorf("expected %d warnings, got %v", tc.warnings, warnings)
+		}
+	}
+}
+
+func TestChapterImage(t *testing.T) {
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	imagePath := filepath.Join(t.TempDir(), "chapter.png")
+	if err := os.WriteFile(imagePath, pngData.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	chapters := []Chapter{
+		{Title: "Chapter 1", Start: "00:00:00.000", Image: imagePath},
+		{Title: "Chapter 2", Start: "00:00:01.000", Image: "https://example.com/chapter2.jpg"},
+		{Title: "Chapter 3", Start: "00:00:02.000"},
+	}
+	tag := id3v2.NewEmptyTag()
+	tag.SetVersion(4)
+	if err := AddCHAPAndCTOC(mp3duration.Info{TimeDuration: 3 * time.Second}, tag, chapters, WithChapterArt(TitleCard{Width: 16, Height: 16})); err != nil {
+		t.Fatal(err)
+	}
+	frames, err := ChapterFrames(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(frames, func(i, j int) bool { return frames[i].StartMillis < frames[j].StartMillis })
+	expected := []string{
+		"\x00image/png\x00\x00\x00" + pngData.String(),
+		"\x00-->\x00\x00\x00https://example.com/chapter2.jpg",
+		"\x00image/jpeg\x00\x00\x00\xFF\xD8",
+	}
+	for i, f := range tag.GetFrames("CHAP") {
+		body := f.(id3v2.UnknownFrame).Body
+		id := string(body[:bytes.IndexByte(body, 0x00)])
+		n, _ := strconv.Atoi(id)
+		apic := bytes.Index(body, []byte("APIC"))
+		if apic < 0 {
+			t.Fatalf("chapter %d: expected an APIC sub-frame", i+1)
+		}
+		if !bytes.HasPrefix(body[apic+10:], []byte(expected[n-1])) {
+			t.Errorf("chapter %s: unexpected APIC sub-frame %q", id, body[apic:min(len(body), apic+48)])
+		}
+	}
+	if frames[0].Image != "" || frames[1].Image != chapters[1].Image {
+		t.Errorf("expected only the linked image to be read back, got %q and %q", frames[0].Image, frames[1].Image)
+	}
+}
+
+func TestChapterURL(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Intro", Start: "00:00:00.000"},
+		{Title: "Sponsor", Start: "00:00:01.000", URL: "https://example.com/sponsor"},
+	}
+	tag := id3v2.NewEmptyTag()
+	tag.SetVersion(4)
+	if err := AddCHAPAndCTOC(mp3duration.Info{TimeDuration: 3 * time.Second}, tag, chapters); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range tag.GetFrames("CHAP") {
+		body := f.(id3v2.UnknownFrame).Body
+		i := bytes.Index(body, []byte("WXXX"))
+		if bytes.HasPrefix(body, []byte("1\x00")) {
+			if i >= 0 {
+				t.Errorf("unexpected WXXX sub-frame in %q", body)
+			}
+			continue
+		}
+		if i < 0 || !bytes.HasSuffix(body, []byte("WXXX\x00\x00\x00\x1d\x00\x00\x03\x00https://example.com/sponsor")) {
+			t.Errorf("unexpected WXXX sub-frame in %q", body)
+		}
+	}
+	got, err := TagChapters(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, chapters) {
+		t.Errorf("expected %v, got %v", chapters, got)
+	}
+}
+
+func TestChapterDescription(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Intro", Start: "00:00:00.000", Description: "Where we introduce today's guest, her background and the books she wrote."},
+		{Title: "Outro", Start: "00:00:02.000"},
+	}
+	tag := id3v2.NewEmptyTag()
+	tag.SetVersion(4)
+	if err := AddCHAPAndCTOC(mp3duration.Info{TimeDuration: 3 * time.Second}, tag, chapters); err != nil {
+		t.Fatal(err)
+	}
+	tit3 := 0
+	for _, f := range tag.GetFrames("CHAP") {
+		if bytes.Contains(f.(id3v2.UnknownFrame).Body, []byte("TIT3")) {
+			tit3++
+		}
+	}
+	if tit3 != 1 {
+		t.Errorf("expected 1 TIT3 sub-frame, got %d", tit3)
+	}
+	got, err := TagChapters(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, chapters) {
+		t.Errorf("expected %v, got %v", chapters, got)
+	}
+}
+
+func TestPrepareChapters(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Chapter 2", Start: "00:00:10", End: "00:00:40"},
+		{Title: "Chapter 1", Start: "00:00:00"},
+		{Title: "Chapter 3", Start: "00:00:30"},
+	}
+	prepared, err := PrepareChapters(chapters, 30*time.Second, WithSortChapters(), WithClampChapters())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Chapter{
+		{Title: "Chapter 1", Start: "00:00:00"},
+		{Title: "Chapter 2", Start: "00:00:10", End: "00:00:30.000"},
+	}
+	if !reflect.DeepEqual(prepared, expected) {
+		t.Errorf("expected %v, got %v", expected, prepared)
+	}
+	if chapters[0].End != "00:00:40" {
+		t.Error("input was modified")
+	}
+}
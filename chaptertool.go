@@ -0,0 +1,142 @@
+package id3v24
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// chapterToolXML is the document of Apple ChapterTool, used for
+// chapters in enhanced (AAC) podcasts.
+type chapterToolXML struct {
+	XMLName  xml.Name             `xml:"chapters"`
+	Version  string               `xml:"version,attr,omitempty"`
+	Chapters []chapterToolChapter `xml:"chapter"`
+}
+
+type chapterToolChapter struct {
+	StartTime string           `xml:"starttime,attr"`
+	Title     string           `xml:"title"`
+	Picture   string           `xml:"picture,omitempty"`
+	Link      *chapterToolLink `xml:"link,omitempty"`
+}
+
+type chapterToolLink struct {
+	Href string `xml:"href,attr"`
+	Text string `xml:",chardata"`
+}
+
+// ParseChapterToolXML returns the chapters of the Apple ChapterTool
+// XML document in r. The picture of a chapter is returned as Image,
+// unresolved, and the href of its link (or the text if it has none)
+// as URL.
+func ParseChapterToolXML(r io.Reader) ([]Chapter, error) {
+	var doc chapterToolXML
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	chapters := make([]Chapter, len(doc.Chapters))
+	for i, c := range doc.Chapters {
+		millis, err := clockTimeToMillis(c.StartTime)
+		if err != nil {
+			return nil, fmt.Errorf("chapter %d: %w", i+1, err)
+		}
+		chapters[i] = Chapter{
+			Title: strings.TrimSpace(c.Title),
+			Start: MillisToStringTime(millis),
+			Image: strings.TrimSpace(c.Picture),
+		}
+		if c.Link != nil {
+			chapters[i].URL = strings.TrimSpace(c.Link.Href)
+			if chapters[i].URL == "" {
+				chapters[i].URL = strings.TrimSpace(c.Link.Text)
+			}
+		}
+	}
+	return chapters, nil
+}
+
+// ChapterToolChapterSource returns the chapters of the Apple
+// ChapterTool XML file path, see ParseChapterToolXML. Relative
+// pictures are resolved against the directory of path.
+func ChapterToolChapterSource(path string) ChapterSource {
+	return ChapterSource{Name: "chaptertool", Load: func() ([]Chapter, error) {
+		f, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		chapters, err := ParseChapterToolXML(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for i, ch := range chapters {
+			if ch.Image != "" && !filepath.IsAbs(ch.Image) && !strings.Contains(ch.Image, "://") {
+				chapters[i].Image = filepath.Join(filepath.Dir(path), ch.Image)
+			}
+		}
+		return chapters, nil
+	}}
+}
+
+// clockTimeToMillis parses t as [[HH:]MM:]SS[.fff], the free form
+// times of ChapterTool.
+func clockTimeToMillis(t string) (uint32, error) {
+	bad := fmt.Errorf("bad time %q (expected [[HH:]MM:]SS[.fff])", t)
+	parts := strings.Split(strings.TrimSpace(t), ":")
+	if len(parts) > 3 {
+		return 0, bad
+	}
+	seconds, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil || seconds < 0 || (len(parts) > 1 && seconds >= 60) {
+		return 0, bad
+	}
+	var minutes uint64
+	for i, p := range parts[:len(parts)-1] {
+		n, err := strconv.ParseUint(p, 10, 32)
+		if err != nil || (i > 0 && n >= 60) {
+			return 0, bad
+		}
+		minutes = minutes*60 + n
+	}
+	millis := minutes*60000 + uint64(seconds*1000+0.5)
+	if millis > 1<<32-1 {
+		return 0, bad
+	}
+	return uint32(millis), nil
+}
+
+// ChapterToolXML returns chapters as an Apple ChapterTool XML
+// document, for legacy enhanced podcast pipelines. Image is written
+// as the picture and URL as the link of a chapter. Nested chapters
+// are flattened, see FlattenChapters.
+func ChapterToolXML(chapters []Chapter) ([]byte, error) {
+	chapters = FlattenChapters(chapters)
+	doc := chapterToolXML{Version: "1", Chapters: make([]chapterToolChapter, len(chapters))}
+	for i, ch := range chapters {
+		millis, err := StringTimeToMillis(ch.Start)
+		if err != nil {
+			return nil, fmt.Errorf("chapter %d: %w", i+1, err)
+		}
+		doc.Chapters[i] = chapterToolChapter{
+			StartTime: MillisToStringTime(millis),
+			Title:     ch.Title,
+			Picture:   ch.Image,
+		}
+		if ch.URL != "" {
+			doc.Chapters[i].Link = &chapterToolLink{Href: ch.URL, Text: ch.URL}
+		}
+	}
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
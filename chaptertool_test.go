@@ -0,0 +1,106 @@
+package id3v24
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testChapterToolXML = `<?xml version="1.0" encoding="utf-8"?>
+<chapters version="1">
+  <chapter starttime="0:00">
+    <title>Intro</title>
+    <picture>intro.jpg</picture>
+  </chapter>
+  <chapter starttime="1:30.5">
+    <title>Sponsor &amp; news</title>
+    <link href="https://example.com/sponsor">Our sponsor</link>
+  </chapter>
+  <chapter starttime="01:02:03">
+    <title>Outro</title>
+    <picture>/art/outro.png</picture>
+    <link>https://example.com</link>
+  </chapter>
+</chapters>
+`
+
+func TestParseChapterToolXML(t *testing.T) {
+	chapters, err := ParseChapterToolXML(strings.NewReader(testChapterToolXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Chapter{
+		{Title: "Intro", Start: "00:00:00.000", Image: "intro.jpg"},
+		{Title: "Sponsor & news", Start: "00:01:30.500", URL: "https://example.com/sponsor"},
+		{Title: "Outro", Start: "01:02:03.000", Image: "/art/outro.png", URL: "https://example.com"},
+	}
+	if !reflect.DeepEqual(chapters, expected) {
+		t.Errorf("expected %v, got %v", expected, chapters)
+	}
+	for _, bad := range []string{"1:60", "x", "1:2:3:4", "-1"} {
+		doc := `<chapters><chapter starttime="` + bad + `"><title>T</title></chapter></chapters>`
+		if _, err := ParseChapterToolXML(strings.NewReader(doc)); err == nil {
+			t.Errorf("expected error for starttime %q", bad)
+		}
+	}
+}
+
+func TestChapterToolChapterSource(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "chapters.xml")
+	if chapters, err := ChapterToolChapterSource(path).Load(); err != nil || chapters != nil {
+		t.Fatalf("expected no chapters and no error, got %v, %v", chapters, err)
+	}
+	if err := os.WriteFile(path, []byte(testChapterToolXML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	chapters, err := ChapterToolChapterSource(path).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(dir, "intro.jpg"); chapters[0].Image != expected {
+		t.Errorf("expected %q, got %q", expected, chapters[0].Image)
+	}
+	if expected := "/art/outro.png"; chapters[2].Image != expected {
+		t.Errorf("expected %q, got %q", expected, chapters[2].Image)
+	}
+}
+
+func TestChapterToolXML(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Intro", Start: "00:00:00", Image: "intro.jpg"},
+		{Title: "Sponsor & news", Start: "00:01:30.500", URL: "https://example.com/sponsor"},
+	}
+	data, err := ChapterToolXML(chapters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<chapters version="1">
+  <chapter starttime="00:00:00.000">
+    <title>Intro</title>
+    <picture>intro.jpg</picture>
+  </chapter>
+  <chapter starttime="00:01:30.500">
+    <title>Sponsor &amp; news</title>
+    <link href="https://example.com/sponsor">https://example.com/sponsor</link>
+  </chapter>
+</chapters>
+`
+	if string(data) != expected {
+		t.Errorf("expected %q, got %q", expected, data)
+	}
+	parsed, err := ParseChapterToolXML(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chapters[0].Start = "00:00:00.000"
+	if !reflect.DeepEqual(parsed, chapters) {
+		t.Errorf("expected %v, got %v", chapters, parsed)
+	}
+	if _, err := ChapterToolXML([]Chapter{{Title: "Bad", Start: "1:2"}}); err == nil {
+		t.Error("expected error for bad start time")
+	}
+}
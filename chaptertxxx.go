@@ -0,0 +1,36 @@
+package id3v24
+
+import (
+	"fmt"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// AddChapterTXXX adds chapters to tag as pairs of TXXX frames in the
+// Nero/Vorbis comment style read by some legacy tools,
+// CHAPTERnnn=HH:MM:SS.mmm and CHAPTERnnnNAME=title, numbered from
+// 001. This is not part of the ID3v2 chapter specification and meant
+// as a fallback alongside the CHAP and CTOC frames, see
+// WithChapterTXXXFallback.
+func AddChapterTXXX(tag *id3v2.Tag, chapters []Chapter) error {
+	for i, ch := range chapters {
+		start, err := StringTimeToMillis(ch.Start)
+		if err != nil {
+			return err
+		}
+		key := fmt.Sprintf("CHAPTER%03d", i+1)
+		addUserDefinedText(tag, key, MillisToStringTime(start))
+		addUserDefinedText(tag, key+"NAME", ch.Title)
+	}
+	return nil
+}
+
+// WithChapterTXXXFallback makes WriteID3v2Tag write chapters both as
+// spec compliant CHAP and CTOC frames and as Nero-style TXXX frames
+// (see AddChapterTXXX) for tools that only read the latter. Off by
+// default.
+func WithChapterTXXXFallback() Option {
+	return func(o *options) {
+		o.chapterTXXX = true
+	}
+}
@@ -0,0 +1,66 @@
+//go:build js && wasm
+
+// Command id3v24-wasm exposes the io-only core of package id3v24 to
+// JavaScript, so MP3 files dropped into a browser page can be tagged
+// entirely client-side. Build and serve with:
+//
+//	GOOS=js GOARCH=wasm go build -o id3v24.wasm ./cmd/id3v24-wasm
+//	cp "$(go env GOROOT)/lib/wasm/wasm_exec.js" .
+//	cp cmd/id3v24-wasm/index.html .
+//
+// The module registers a global function
+//
+//	id3v24WriteTag(mp3 Uint8Array, trackInfoJSON string, cover Uint8Array?) Uint8Array|Error
+//
+// returning the tagged MP3, or an Error if tagging failed.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"syscall/js"
+
+	"github.com/sa6mwa/id3v24"
+)
+
+func main() {
+	js.Global().Set("id3v24WriteTag", js.FuncOf(writeTag))
+	select {}
+}
+
+func writeTag(this js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return jsError("expected mp3 and trackInfoJSON arguments")
+	}
+	mp3 := make([]byte, args[0].Get("length").Int())
+	js.CopyBytesToGo(mp3, args[0])
+	var input id3v24.TrackInfo
+	if err := json.Unmarshal([]byte(args[1].String()), &input); err != nil {
+		return jsError(err.Error())
+	}
+	if len(args) > 2 && !args[2].IsUndefined() && !args[2].IsNull() {
+		input.CoverData = make([]byte, args[2].Get("length").Int())
+		js.CopyBytesToGo(input.CoverData, args[2])
+	}
+	// There is no file system to read a cover path from, only data
+	// URIs are accepted in CoverJPEG.
+	var out bytes.Buffer
+	if err := id3v24.WriteID3v2TagTo(&out, bytes.NewReader(mp3), input, id3v24.WithFS(noFS{})); err != nil {
+		return jsError(err.Error())
+	}
+	result := js.Global().Get("Uint8Array").New(out.Len())
+	js.CopyBytesToJS(result, out.Bytes())
+	return result
+}
+
+// noFS is a file system without files.
+type noFS struct{}
+
+func (noFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func jsError(msg string) js.Value {
+	return js.Global().Get("Error").New(msg)
+}
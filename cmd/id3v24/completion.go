@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+func init() {
+	// Registered here as completionCmd refers to commands.
+	commands["completion"] = command{
+		summary:     "print a bash, zsh or fish completion script",
+		run:         completionCmd,
+		subcommands: []string{"bash", "zsh", "fish"},
+	}
+}
+
+// Description is the schema printed by --describe json.
+type Description struct {
+	Name     string               `json:"name"`
+	Commands []CommandDescription `json:"commands"`
+}
+
+type CommandDescription struct {
+	Name        string               `json:"name"`
+	Summary     string               `json:"summary,omitempty"`
+	Flags       []FlagDescription    `json:"flags"`
+	Subcommands []CommandDescription `json:"subcommands,omitempty"`
+}
+
+type FlagDescription struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"` // bool, string, int, int64, duration... or value
+	Default string `json:"default,omitempty"`
+	Usage   string `json:"usage"`
+}
+
+// collecting is non-nil while describe collects the flag sets created
+// by newFlagSet.
+var collecting *[]*flag.FlagSet
+
+// newFlagSet returns a flag set for a command, silenced and recorded
+// while describing the commands.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	if collecting != nil {
+		fs.SetOutput(io.Discard)
+		*collecting = append(*collecting, fs)
+	}
+	return fs
+}
+
+// describe returns the commands and their flags, found by running
+// each command (and subcommand) with -h.
+func describe() Description {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	d := Description{Name: "id3v24"}
+	for _, name := range names {
+		cmd := commands[name]
+		c := CommandDescription{Name: name, Summary: cmd.summary, Flags: describeFlags(cmd, "-h")}
+		for _, sub := range cmd.subcommands {
+			c.Subcommands = append(c.Subcommands, CommandDescription{Name: sub, Flags: describeFlags(cmd, sub, "-h")})
+		}
+		d.Commands = append(d.Commands, c)
+	}
+	return d
+}
+
+// describeFlags returns the flags of the flag set cmd parses args with.
+func describeFlags(cmd command, args ...string) []FlagDescription {
+	var sets []*flag.FlagSet
+	collecting = &sets
+	defer func() { collecting = nil }()
+	stderr := os.Stderr
+	os.Stderr = nil // usage of commands without a flag set
+	cmd.run(args)
+	os.Stderr = stderr
+	flags := []FlagDescription{}
+	if len(sets) == 0 {
+		return flags
+	}
+	sets[len(sets)-1].VisitAll(func(f *flag.Flag) {
+		_, usage := flag.UnquoteUsage(f)
+		typ := strings.TrimSuffix(strings.TrimPrefix(fmt.Sprintf("%T", f.Value), "*flag."), "Value")
+		if strings.Contains(typ, ".") || typ == "func" {
+			typ = "value"
+		}
+		def := f.DefValue
+		if def == "false" || def == "0" || def == "0s" {
+			def = ""
+		}
+		flags = append(flags, FlagDescription{Name: f.Name, Type: typ, Default: def, Usage: usage})
+	})
+	return flags
+}
+
+func describeCmd() error {
+	output, err := json.MarshalIndent(describe(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutput(stdio, append(output, '\n'))
+}
+
+func completionCmd(args []string) error {
+	fs := newFlagSet("completion")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: completion bash|zsh|fish\n\ne.g: source <(id3v24 completion bash)\n")
+	}
+	if err := fs.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return usageError(errors.New("a shell is required"))
+	}
+	var b strings.Builder
+	switch d := describe(); fs.Arg(0) {
+	case "bash":
+		bashCompletion(&b, d)
+	case "zsh":
+		zshCompletion(&b, d)
+	case "fish":
+		fishCompletion(&b, d)
+	default:
+		return fmt.Errorf("unknown shell %q", fs.Arg(0))
+	}
+	return writeOutput(stdio, []byte(b.String()))
+}
+
+func flagNames(flags []FlagDescription) []string {
+	var names []string
+	for _, f := range flags {
+		names = append(names, "--"+f.Name)
+	}
+	return names
+}
+
+func commandNames(cmds []CommandDescription) []string {
+	var names []string
+	for _, c := range cmds {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func bashCompletion(w io.Writer, d Description) {
+	fmt.Fprintf(w, "_%s() {\n", d.Name)
+	fmt.Fprintf(w, "\tlocal cur=${COMP_WORDS[COMP_CWORD]} words\n")
+	fmt.Fprintf(w, "\tif [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(w, "\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n\t\treturn\n\tfi\n", strings.Join(commandNames(d.Commands), " "))
+	fmt.Fprintf(w, "\tcase \"${COMP_WORDS[1]} ${COMP_WORDS[2]}\" in\n")
+	for _, c := range d.Commands {
+		for _, sub := range c.Subcommands {
+			fmt.Fprintf(w, "\t%q) words=%q ;;\n", c.Name+" "+sub.Name, strings.Join(flagNames(sub.Flags), " "))
+		}
+		words := append(commandNames(c.Subcommands), flagNames(c.Flags)...)
+		fmt.Fprintf(w, "\t%s\\ *) words=%q ;;\n", c.Name, strings.Join(words, " "))
+	}
+	fmt.Fprintf(w, "\tesac\n")
+	fmt.Fprintf(w, "\tCOMPREPLY=($(compgen -W \"$words\" -- \"$cur\"))\n")
+	fmt.Fprintf(w, "\t[[ $cur == -* ]] || COMPREPLY+=($(compgen -f -- \"$cur\"))\n")
+	fmt.Fprintf(w, "}\ncomplete -F _%s %s\n", d.Name, d.Name)
+}
+
+// zshQuote escapes s for an _arguments spec in single quotes.
+func zshQuote(s string) string {
+	return strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
+
+func zshArguments(w io.Writer, indent string, flags []FlagDescription) {
+	fmt.Fprintf(w, "%s_arguments", indent)
+	for _, f := range flags {
+		if f.Type == "bool" {
+			fmt.Fprintf(w, " \\\n%s\t'--%s[%s]'", indent, f.Name, zshQuote(f.Usage))
+		} else {
+			fmt.Fprintf(w, " \\\n%s\t'--%s[%s]:%s:_files'", indent, f.Name, zshQuote(f.Usage), f.Type)
+		}
+	}
+	fmt.Fprintf(w, " \\\n%s\t'*:file:_files'\n", indent)
+}
+
+func zshCompletion(w io.Writer, d Description) {
+	fmt.Fprintf(w, "#compdef %s\n\n_%s() {\n\tlocal -a commands\n\tcommands=(\n", d.Name, d.Name)
+	for _, c := range d.Commands {
+		fmt.Fprintf(w, "\t\t'%s:%s'\n", c.Name, zshQuote(c.Summary))
+	}
+	fmt.Fprintf(w, "\t)\n\tif (( CURRENT == 2 )); then\n\t\t_describe command commands\n\t\treturn\n\tfi\n")
+	fmt.Fprintf(w, "\tcase \"$words[2] $words[3]\" in\n")
+	for _, c := range d.Commands {
+		for _, sub := range c.Subcommands {
+			fmt.Fprintf(w, "\t%q)\n\t\tshift 2 words\n\t\t(( CURRENT -= 2 ))\n", c.Name+" "+sub.Name)
+			zshArguments(w, "\t\t", sub.Flags)
+			fmt.Fprintf(w, "\t\t;;\n")
+		}
+		fmt.Fprintf(w, "\t%s\\ *)\n", c.Name)
+		if len(c.Subcommands) > 0 {
+			fmt.Fprintf(w, "\t\t(( CURRENT == 3 )) && compadd -- %s\n", strings.Join(commandNames(c.Subcommands), " "))
+		}
+		fmt.Fprintf(w, "\t\tshift words\n\t\t(( CURRENT-- ))\n")
+		zshArguments(w, "\t\t", c.Flags)
+		fmt.Fprintf(w, "\t\t;;\n")
+	}
+	fmt.Fprintf(w, "\tesac\n}\n\n_%s \"$@\"\n", d.Name)
+}
+
+// fishQuote quotes s for fish in single quotes.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+func fishCompletion(w io.Writer, d Description) {
+	fmt.Fprintf(w, "complete -c %s -f\n", d.Name)
+	for _, c := range d.Commands {
+		fmt.Fprintf(w, "complete -c %s -n __fish_use_subcommand -a %s -d %s\n", d.Name, c.Name, fishQuote(c.Summary))
+		condition := "__fish_seen_subcommand_from " + c.Name
+		if len(c.Subcommands) > 0 {
+			subs := strings.Join(commandNames(c.Subcommands), " ")
+			fmt.Fprintf(w, "complete -c %s -n %s -a %s\n", d.Name, fishQuote(condition+"; and not __fish_seen_subcommand_from "+subs), fishQuote(subs))
+			for _, sub := range c.Subcommands {
+				fishFlags(w, d.Name, condition+"; and __fish_seen_subcommand_from "+sub.Name, sub.Flags)
+			}
+			condition += "; and not __fish_seen_subcommand_from " + subs
+		}
+		fishFlags(w, d.Name, condition, c.Flags)
+	}
+}
+
+func fishFlags(w io.Writer, name, condition string, flags []FlagDescription) {
+	for _, f := range flags {
+		fmt.Fprintf(w, "complete -c %s -n %s -l %s", name, fishQuote(condition), f.Name)
+		if f.Type != "bool" {
+			fmt.Fprintf(w, " -r -F")
+		}
+		fmt.Fprintf(w, " -d %s\n", fishQuote(f.Usage))
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sa6mwa/id3v24"
+)
+
+// Exit codes, see the package documentation.
+const (
+	exitFailure = 1 // any other failure
+	exitUsage   = 2 // bad command line, or -h
+	exitInput   = 3 // bad track info, template or chapters
+	exitAudio   = 4 // the audio is missing or unreadable
+	exitWrite   = 5 // writing the tag or output file failed
+	exitVerify  = 6 // the written tag did not read back as expected
+)
+
+// Error classes of the --json error output, by exit code.
+var errorClasses = map[int]string{
+	exitFailure: "failure",
+	exitUsage:   "usage",
+	exitInput:   "input",
+	exitAudio:   "audio",
+	exitWrite:   "write",
+	exitVerify:  "verify",
+}
+
+// exitError is an error with the exit code of its class.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// classify returns err with exit code code, unless err is nil or
+// already classified.
+func classify(code int, err error) error {
+	var e *exitError
+	if err == nil || errors.As(err, &e) {
+		return err
+	}
+	return &exitError{code: code, err: err}
+}
+
+func usageError(err error) error  { return classify(exitUsage, err) }
+func inputError(err error) error  { return classify(exitInput, err) }
+func audioError(err error) error  { return classify(exitAudio, err) }
+func writeError(err error) error  { return classify(exitWrite, err) }
+func verifyError(err error) error { return classify(exitVerify, err) }
+
+// exitCode returns the exit code of err.
+func exitCode(err error) int {
+	var e *exitError
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return exitUsage
+	case errors.As(err, &e):
+		return e.code
+	}
+	return exitFailure
+}
+
+// exit reports err on stderr, as a JSON object if asJSON is set, and
+// exits with its exit code.
+func exit(err error, asJSON bool) {
+	code := exitCode(err)
+	switch {
+	case asJSON:
+		json.NewEncoder(os.Stderr).Encode(struct {
+			Error string `json:"error"`
+			Class string `json:"class"`
+			Code  int    `json:"code"`
+		}{err.Error(), errorClasses[code], code})
+	case code != exitUsage || !errors.Is(err, flag.ErrHelp):
+		fmt.Fprintf(os.Stderr, "%s: %v\n", filepath.Base(os.Args[0]), err)
+	}
+	os.Exit(code)
+}
+
+// failureKinds implements id3v24.Metrics to classify the errors of the
+// package by the MetricErr kind it reports them as.
+type failureKinds struct {
+	kind string
+}
+
+func (f *failureKinds) FileTagged(int64, time.Duration)              {}
+func (f *failureKinds) DurationScanned(time.Duration, time.Duration) {}
+func (f *failureKinds) Error(kind string)                            { f.kind = kind }
+
+// classify returns err with the exit code of the last reported kind.
+func (f *failureKinds) classify(err error) error {
+	switch f.kind {
+	case id3v24.MetricErrDuration, id3v24.MetricErrOpen:
+		return audioError(err)
+	case id3v24.MetricErrInput, id3v24.MetricErrCover, id3v24.MetricErrChapters:
+		return inputError(err)
+	case id3v24.MetricErrSave:
+		return writeError(err)
+	}
+	return err
+}
@@ -0,0 +1,824 @@
+// Command id3v24 writes ID3v2.4 tags with chapters to MP3 files and
+// produces ffmpeg metadata files from the same track information. All
+// path arguments accept "-" for stdin or stdout so that the tool can
+// sit in Unix pipelines, e.g:
+//
+//	curl -s https://example.com/episode.json | id3v24 write --meta - --audio episode.mp3
+//	id3v24 write --meta episode.json --audio - < in.mp3 > out.mp3
+//	cat episode.json in.mp3 | id3v24 write --meta - --audio - > out.mp3
+//
+// The exit code tells automation what failed: 1 for any other
+// failure, 2 for a bad command line, 3 for bad track info, template or
+// chapters, 4 for missing or unreadable audio, 5 when writing the tag
+// or output file failed and 6 when write --verify did not read back
+// the tag written. With --json before the command, errors are printed
+// to stderr as {"error": "...", "class": "input", "code": 3} where
+// class is one of failure, usage, input, audio, write or verify.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sa6mwa/id3v24"
+)
+
+const stdio = "-"
+
+// stdin is what "-" reads. Once the track info has been decoded from
+// it, it is the rest of os.Stdin, e.g the audio following the JSON.
+var stdin io.Reader = os.Stdin
+
+type command struct {
+	summary     string
+	run         func(args []string) error
+	subcommands []string // first argument selecting a flag set of its own
+}
+
+var commands = map[string]command{
+	"write": {
+		summary: "write an ID3v2.4 tag with chapters to an MP3",
+		run:     writeCmd,
+	},
+	"ffmetadata": {
+		summary: "print an ffmpeg metadata file (;FFMETADATA1)",
+		run:     ffmetadataCmd,
+	},
+	"chapters": {
+		summary:     "print an ffmpeg chapters.txt file, or convert between chapter formats",
+		run:         chaptersCmd,
+		subcommands: []string{"convert"},
+	},
+	"read": {
+		summary: "print the tag of an MP3 as track info JSON",
+		run:     readCmd,
+	},
+	"retitle": {
+		summary: "search and replace chapter titles in MP3 files",
+		run:     retitleCmd,
+	},
+	"stats": {
+		summary: "print the bytes used per frame in the tag of an MP3",
+		run:     statsCmd,
+	},
+	"cover": {
+		summary:     "extract, replace, resize or remove the cover of an MP3",
+		run:         coverCmd,
+		subcommands: []string{"extract", "replace", "resize", "remove"},
+	},
+	"set": {
+		summary: "set a single frame of MP3 files, e.g set TIT2 \"Episode 1\" file.mp3",
+		run:     setCmd,
+	},
+	"undo": {
+		summary: "restore the tag replaced by the last write --undo",
+		run:     undoCmd,
+	},
+}
+
+func main() {
+	args := os.Args[1:]
+	asJSON := len(args) > 0 && args[0] == "--json"
+	if asJSON {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		usage()
+		os.Exit(exitUsage)
+	}
+	if name, ok := strings.CutPrefix(args[0], "--describe"); ok {
+		if name != "=json" && (name != "" || len(args) < 2 || args[1] != "json") {
+			fmt.Fprintf(os.Stderr, "usage: %s --describe json\n", filepath.Base(os.Args[0]))
+			os.Exit(exitUsage)
+		}
+		if err := describeCmd(); err != nil {
+			exit(err, asJSON)
+		}
+		return
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		if args[0] != "-h" && args[0] != "--help" && args[0] != "help" {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n", args[0])
+		}
+		usage()
+		os.Exit(exitUsage)
+	}
+	if err := cmd.run(args[1:]); err != nil {
+		exit(err, asJSON)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s [--json] <command> [flags]\n       %s --describe json\n\ncommands:\n", filepath.Base(os.Args[0]), filepath.Base(os.Args[0]))
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, commands[name].summary)
+	}
+}
+
+func writeCmd(args []string) error {
+	fs := newFlagSet("write")
+	meta := fs.String("meta", "", "track info JSON file, or markdown file with YAML front matter (- for stdin)")
+	template := fs.String("template", "", "merge defaults from the named template in "+templateDir())
+	audio := fs.String("audio", "", "MP3 file to tag (- for stdin)")
+	out := fs.String("out", "", "output file (- for stdout), default is to modify --audio in place or stdout if --audio is -")
+	merge := fs.Bool("merge", false, "keep the frames of the existing tag that the track info does not set")
+	mergeChapters := fs.Bool("merge-chapters", false, "merge chapters with those already in the file instead of replacing them")
+	undo := fs.Bool("undo", false, "save the replaced tag so that the write can be reverted with the undo command (of --out, if given)")
+	generateCover := fs.Bool("generate-cover", false, "render a cover with title and artist when the track info has none")
+	textEncoding := fs.String("text-encoding", "", "encoding of chapter titles and text frames: utf-8, utf-16, utf-16-be-bom or utf-16-be (default utf-16 for chapter titles, utf-8 for the rest)")
+	clean := fs.Bool("clean", false, "write only the audio frames, removing junk and other tags from the audio")
+	exactDuration := fs.Bool("exact-duration", false, "fail if the duration of damaged audio can only be estimated, as chapter ends depend on it")
+	sortChapters := fs.Bool("sort-chapters", false, "sort chapters by start time instead of failing on chapters out of order")
+	clampChapters := fs.Bool("clamp-chapters", false, "drop chapters starting after the end of the audio instead of failing")
+	sidecarChapters := fs.Bool("sidecar-chapters", false, "read chapters from BASENAME.chapters.txt, .json or .cue next to --audio when the track info has none")
+	verify := fs.Bool("verify", false, "read the written tag back and fail if the title or chapters differ from the track info")
+	strict := fs.Bool("strict", false, "fail, listing every lossy conversion, if the track info can not be written as is")
+	writeTLEN := fs.Bool("tlen", false, "write the duration of the audio as a TLEN frame")
+	yearFromDate := fs.Bool("year-from-date", false, "set the year from the date instead of failing when they disagree")
+	taggedBy := fs.Bool("tagged-by", false, "add a TXXX TAGGED_BY frame with the version of id3v24 and the time of writing")
+	if err := fs.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if *meta == "" || *audio == "" {
+		fs.Usage()
+		return usageError(errors.New("--meta and --audio are required"))
+	}
+	input, err := readTrackInfo(*meta, *template)
+	if err != nil {
+		return err
+	}
+	if *audio != stdio {
+		input = id3v24.ApplySeasonEpisode(input, *audio)
+	}
+	if *sidecarChapters && *audio != stdio && len(input.Chapters) == 0 {
+		// Resolved here rather than by id3v24.WithSidecarChapters for
+		// --verify to know the chapters written.
+		resolved, err := id3v24.ResolveChapters(id3v24.SidecarChapterSources(*audio), false)
+		if err != nil {
+			return inputError(err)
+		}
+		input.Chapters = resolved.Chapters
+	}
+	var opts []id3v24.Option
+	if *merge {
+		opts = append(opts, id3v24.WithTagMerge())
+	}
+	if *mergeChapters {
+		opts = append(opts, id3v24.WithChapterMerge())
+	}
+	if *generateCover {
+		opts = append(opts, id3v24.WithCoverArt(id3v24.TitleCard{}))
+	}
+	if *exactDuration {
+		opts = append(opts, id3v24.WithExactDuration())
+	}
+	if *clean {
+		opts = append(opts, id3v24.WithCleanStream())
+	}
+	if *sortChapters {
+		opts = append(opts, id3v24.WithSortChapters())
+	}
+	if *clampChapters {
+		opts = append(opts, id3v24.WithClampChapters())
+	}
+	if *strict {
+		opts = append(opts, id3v24.WithStrict())
+	}
+	if *writeTLEN {
+		opts = append(opts, id3v24.WithWriteTLEN())
+	}
+	if *yearFromDate {
+		opts = append(opts, id3v24.WithYearFromDate())
+	}
+	if *taggedBy {
+		opts = append(opts, id3v24.WithProvenance(id3v24.Provenance{Tool: "id3v24", Version: version()}))
+	}
+	if *textEncoding != "" {
+		e, ok := textEncodings[*textEncoding]
+		if !ok {
+			return usageError(fmt.Errorf("unknown text encoding %q", *textEncoding))
+		}
+		opts = append(opts, id3v24.WithTextEncoding(e))
+	}
+	if *undo {
+		if *audio == stdio || *out == stdio {
+			return usageError(errors.New("--undo does not work with stdin or stdout"))
+		}
+		opts = append(opts, id3v24.WithUndo())
+	}
+	failure := &failureKinds{}
+	opts = append(opts, id3v24.WithMetrics(failure))
+	if *audio != stdio && *out != stdio {
+		// A file --out is written like in place, leaving --audio
+		// untouched, which also works for read-only sources.
+		if *out != "" {
+			opts = append(opts, id3v24.WithOutputPath(*out))
+		} else {
+			*out = *audio
+		}
+		if err := id3v24.WriteID3v2Tag(*audio, input, opts...); err != nil {
+			return failure.classify(err)
+		}
+		return verifyWrite(*verify, *out, input, *mergeChapters, opts)
+	}
+	if *verify && (*out == "" || *out == stdio) {
+		return usageError(errors.New("--verify can not read back stdout"))
+	}
+	var src io.ReadSeeker
+	if *audio == stdio {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return audioError(err)
+		}
+		src = bytes.NewReader(data)
+	} else {
+		f, err := os.Open(*audio)
+		if err != nil {
+			return audioError(err)
+		}
+		defer f.Close()
+		src = f
+	}
+	if *out == "" || *out == stdio {
+		return failure.classify(id3v24.WriteID3v2TagTo(os.Stdout, src, input, opts...))
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return writeError(err)
+	}
+	if err := id3v24.WriteID3v2TagTo(f, src, input, opts...); err != nil {
+		f.Close()
+		os.Remove(*out)
+		return failure.classify(err)
+	}
+	if err := f.Close(); err != nil {
+		return writeError(err)
+	}
+	return verifyWrite(*verify, *out, input, *mergeChapters, opts)
+}
+
+// verifyWrite reads back the tag written to name with opts, if verify
+// is set, and returns an error if its title or, unless chapters were
+// merged, chapter titles differ from those of input as written, see
+// id3v24.PrepareChapters.
+func verifyWrite(verify bool, name string, input id3v24.TrackInfo, mergedChapters bool, opts []id3v24.Option) error {
+	if !verify {
+		return nil
+	}
+	written, err := id3v24.ReadID3v2Tag(name)
+	if err != nil {
+		return verifyError(err)
+	}
+	if input.Title != "" && written.Title != input.Title {
+		return verifyError(fmt.Errorf("%s: title %q reads back as %q", name, input.Title, written.Title))
+	}
+	if mergedChapters {
+		return nil
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return verifyError(err)
+	}
+	defer f.Close()
+	di, err := id3v24.ReadMP3Duration(f)
+	if err != nil {
+		return verifyError(err)
+	}
+	chapters, err := id3v24.PrepareChapters(input.Chapters, di.TimeDuration, opts...)
+	if err != nil {
+		return verifyError(err)
+	}
+	want, got := id3v24.FlattenChapters(chapters), id3v24.FlattenChapters(written.Chapters)
+	if len(got) != len(want) {
+		return verifyError(fmt.Errorf("%s: %d chapters read back as %d", name, len(want), len(got)))
+	}
+	for i := range want {
+		if got[i].Title != want[i].Title {
+			return verifyError(fmt.Errorf("%s: chapter %d title %q reads back as %q", name, i+1, want[i].Title, got[i].Title))
+		}
+	}
+	return nil
+}
+
+// textEncodings are the values of write --text-encoding.
+var textEncodings = map[string]id3v24.TextEncoding{
+	"utf-8":         id3v24.UTF8,
+	"utf-16":        id3v24.UTF16LEBOM,
+	"utf-16-be-bom": id3v24.UTF16BEBOM,
+	"utf-16-be":     id3v24.UTF16BE,
+}
+
+func undoCmd(args []string) error {
+	fs := newFlagSet("undo")
+	audio := fs.String("audio", "", "MP3 file written with write --undo")
+	if err := fs.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if *audio == "" || *audio == stdio {
+		fs.Usage()
+		return usageError(errors.New("--audio is required and can not be stdin"))
+	}
+	failure := &failureKinds{}
+	return failure.classify(id3v24.Undo(*audio, id3v24.WithMetrics(failure)))
+}
+
+// version returns the module version of the binary, or an empty
+// string for a development build.
+func version() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return ""
+}
+
+func setCmd(args []string) error {
+	fs := newFlagSet("set")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: set FRAME VALUE file.mp3...\n\nan empty VALUE removes the frame, e.g: set TRCK 3/12 episode.mp3\n")
+	}
+	if err := fs.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if fs.NArg() < 3 {
+		fs.Usage()
+		return usageError(errors.New("a frame, a value and at least one MP3 file are required"))
+	}
+	for _, name := range fs.Args()[2:] {
+		if name == stdio {
+			return usageError(errors.New("set can not be used on stdin"))
+		}
+		failure := &failureKinds{}
+		if err := id3v24.SetField(name, fs.Arg(0), fs.Arg(1), id3v24.WithMetrics(failure)); err != nil {
+			if errors.Is(err, id3v24.ErrUnsupportedField) || errors.Is(err, id3v24.ErrBadFieldValue) {
+				return inputError(err)
+			}
+			return failure.classify(fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return nil
+}
+
+func readCmd(args []string) error {
+	fs := newFlagSet("read")
+	audio := fs.String("audio", "", "MP3 file to read (- for stdin)")
+	out := fs.String("out", stdio, "output file (- for stdout)")
+	if err := fs.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if *audio == "" {
+		fs.Usage()
+		return usageError(errors.New("--audio is required"))
+	}
+	r, err := openInput(*audio)
+	if err != nil {
+		return audioError(err)
+	}
+	defer r.Close()
+	input, err := id3v24.ReadID3v2TagFrom(r)
+	if err != nil {
+		return audioError(err)
+	}
+	output, err := json.MarshalIndent(input, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, append(output, '\n'))
+}
+
+func retitleCmd(args []string) error {
+	fs := newFlagSet("retitle")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: retitle [flags] file.mp3...\n")
+		fs.PrintDefaults()
+	}
+	var rules []id3v24.TitleReplacement
+	fs.Func("replace", "replace `OLD=NEW` in every chapter title, may be repeated", func(s string) error {
+		old, replacement, ok := strings.Cut(s, "=")
+		if !ok || old == "" {
+			return errors.New("expected OLD=NEW")
+		}
+		rules = append(rules, id3v24.TitleReplacement{Old: old, Replacement: replacement})
+		return nil
+	})
+	fs.Func("regexp", "replace matches of `PATTERN=REPLACEMENT` (with $1 etc) in every chapter title, may be repeated", func(s string) error {
+		pattern, replacement, ok := strings.Cut(s, "=")
+		if !ok {
+			return errors.New("expected PATTERN=REPLACEMENT")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, id3v24.TitleReplacement{Pattern: re, Replacement: replacement})
+		return nil
+	})
+	dryRun := fs.Bool("dry-run", false, "only print the changes")
+	if err := fs.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if len(rules) == 0 || fs.NArg() == 0 {
+		fs.Usage()
+		return usageError(errors.New("at least one --replace or --regexp and one file are required"))
+	}
+	for _, name := range fs.Args() {
+		failure := &failureKinds{}
+		changes, err := id3v24.ReplaceChapterTitlesInFile(name, rules, *dryRun, id3v24.WithMetrics(failure))
+		if err != nil {
+			return failure.classify(fmt.Errorf("%s: %w", name, err))
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		fmt.Printf("--- %s\n", name)
+		for _, change := range changes {
+			fmt.Println(change)
+		}
+	}
+	return nil
+}
+
+func statsCmd(args []string) error {
+	fs := newFlagSet("stats")
+	audio := fs.String("audio", "", "MP3 file")
+	if err := fs.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if *audio == "" || *audio == stdio {
+		fs.Usage()
+		return usageError(errors.New("--audio is required and can not be stdin"))
+	}
+	stats, err := id3v24.TagStats(*audio)
+	if err != nil {
+		return audioError(err)
+	}
+	if stats.Version == 0 {
+		fmt.Printf("no ID3v2 tag, %d bytes of audio\n", stats.Audio)
+		return nil
+	}
+	fmt.Printf("ID3v2.%d tag of %d bytes, %d bytes of audio\n", stats.Version, stats.Size, stats.Audio)
+	for _, f := range stats.Frames {
+		fmt.Printf("  %-4s %4d %10d\n", f.ID, f.Count, f.Size)
+	}
+	fmt.Printf("  %-9s %10d\n", "padding", stats.Padding)
+	return nil
+}
+
+func ffmetadataCmd(args []string) error {
+	fs := newFlagSet("ffmetadata")
+	meta := fs.String("meta", "", "track info JSON file, or markdown file with YAML front matter (- for stdin)")
+	template := fs.String("template", "", "merge defaults from the named template in "+templateDir())
+	audio := fs.String("audio", "", "MP3 file to read the duration from (- for stdin)")
+	duration := fs.Duration("duration", 0, "duration of the audio, instead of --audio")
+	timebase := fs.Int64("timebase", id3v24.TimebaseMillis, "chapter TIMEBASE denominator, e.g 1000, 44100 or 90000")
+	out := fs.String("out", stdio, "output file (- for stdout)")
+	if err := fs.Parse(args); err != nil {
+		return usageError(err)
+	}
+	input, d, err := readInputAndDuration(fs, *meta, *template, *audio, *duration)
+	if err != nil {
+		return err
+	}
+	output, err := id3v24.GetFFmpegMetadata(d, input, id3v24.WithTimebase(*timebase))
+	if err != nil {
+		return inputError(err)
+	}
+	return writeOutput(*out, output)
+}
+
+func chaptersCmd(args []string) error {
+	if len(args) > 0 && args[0] == "convert" {
+		return chaptersConvertCmd(args[1:])
+	}
+	fs := newFlagSet("chapters")
+	meta := fs.String("meta", "", "track info JSON file, or markdown file with YAML front matter (- for stdin)")
+	template := fs.String("template", "", "merge defaults from the named template in "+templateDir())
+	audio := fs.String("audio", "", "MP3 file to read the duration from (- for stdin)")
+	duration := fs.Duration("duration", 0, "duration of the audio, instead of --audio")
+	timebase := fs.Int64("timebase", id3v24.TimebaseMillis, "chapter TIMEBASE denominator, e.g 1000, 44100 or 90000")
+	format := fs.String("format", "ffmetadata", "output format, ffmetadata, chaptertool (Apple ChapterTool XML) or timeline (PNG)")
+	width := fs.Int("width", 1200, "width of the timeline PNG in pixels")
+	lang := fs.String("lang", "", "use the chapter titles in this ISO 639-2 language where available, e.g swe")
+	out := fs.String("out", stdio, "output file (- for stdout)")
+	if err := fs.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if *format == "chaptertool" {
+		if *meta == "" {
+			fs.Usage()
+			return usageError(errors.New("--meta is required"))
+		}
+		input, err := readTrackInfo(*meta, *template)
+		if err != nil {
+			return err
+		}
+		output, err := id3v24.ChapterToolXML(id3v24.LocalizeChapters(input.Chapters, *lang))
+		if err != nil {
+			return inputError(err)
+		}
+		return writeOutput(*out, output)
+	} else if *format != "ffmetadata" && *format != "timeline" {
+		return usageError(fmt.Errorf("unknown format %q", *format))
+	}
+	input, d, err := readInputAndDuration(fs, *meta, *template, *audio, *duration)
+	if err != nil {
+		return err
+	}
+	if *format == "timeline" {
+		output, err := id3v24.Timeline{Width: *width}.Render(id3v24.LocalizeChapters(input.Chapters, *lang), d)
+		if err != nil {
+			return inputError(err)
+		}
+		return writeOutput(*out, output)
+	}
+	output, err := id3v24.GetFFmpegChapters(d, id3v24.LocalizeChapters(input.Chapters, *lang), id3v24.WithTimebase(*timebase))
+	if err != nil {
+		return inputError(err)
+	}
+	return writeOutput(*out, output)
+}
+
+func chaptersConvertCmd(args []string) error {
+	fs := newFlagSet("chapters convert")
+	var names []string
+	for _, f := range id3v24.ChapterFormats() {
+		names = append(names, f.Name)
+	}
+	formats := strings.Join(names, ", ")
+	from := fs.String("from", "", "input format ("+formats+"), default by file name extension")
+	to := fs.String("to", "", "output format ("+formats+"), default by file name extension")
+	audio := fs.String("audio", "", "MP3 file to read the duration from, for formats with chapter ends (default the FILEs of a cue sheet)")
+	duration := fs.Duration("duration", 0, "duration of the audio, instead of --audio")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: chapters convert [flags] [in [out]]\n\nin and out default to - (stdin and stdout)\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return usageError(err)
+	}
+	in, out := stdio, stdio
+	if fs.NArg() > 0 {
+		in = fs.Arg(0)
+	}
+	if fs.NArg() > 1 {
+		out = fs.Arg(1)
+	}
+	if fs.NArg() > 2 {
+		fs.Usage()
+		return usageError(errors.New("too many arguments"))
+	}
+	for _, f := range []struct {
+		name *string
+		path string
+	}{{from, in}, {to, out}} {
+		if *f.name != "" {
+			continue
+		}
+		if f.path == stdio {
+			fs.Usage()
+			return usageError(errors.New("--from and --to are required for stdin and stdout"))
+		}
+		format, err := id3v24.ChapterFormatByExtension(f.path)
+		if err != nil {
+			return usageError(err)
+		}
+		*f.name = format.Name
+	}
+	d := *duration
+	if d == 0 && *audio != "" {
+		r, err := openInput(*audio)
+		if err != nil {
+			return audioError(err)
+		}
+		di, err := id3v24.ReadMP3Duration(r)
+		r.Close()
+		if err != nil {
+			return audioError(err)
+		}
+		d = di.TimeDuration
+	}
+	r, err := openInput(in)
+	if err != nil {
+		return inputError(err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return inputError(err)
+	}
+	if d == 0 && *audio == "" && *from == "cue" && in != stdio {
+		d = cueDuration(in, data)
+	}
+	var b bytes.Buffer
+	if err := id3v24.ConvertChapters(&b, bytes.NewReader(data), *from, *to, d); err != nil {
+		return inputError(err)
+	}
+	return writeOutput(out, b.Bytes())
+}
+
+// cueDuration returns the duration of the MP3 FILEs of the cue sheet
+// data read from name, zero if any of them can not be read.
+func cueDuration(name string, data []byte) time.Duration {
+	files, err := id3v24.CueSheetFiles(bytes.NewReader(data))
+	if err != nil {
+		return 0
+	}
+	var d time.Duration
+	for _, file := range files {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(name), file)
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return 0
+		}
+		di, err := id3v24.ReadMP3Duration(f)
+		f.Close()
+		if err != nil {
+			return 0
+		}
+		d += di.TimeDuration
+	}
+	return d
+}
+
+func coverCmd(args []string) error {
+	if len(args) == 0 || !slices.Contains([]string{"extract", "replace", "resize", "remove"}, args[0]) {
+		fmt.Fprintf(os.Stderr, "usage: cover extract|replace|resize|remove [flags] file.mp3\n")
+		return flag.ErrHelp
+	}
+	fs := newFlagSet("cover " + args[0])
+	out := fs.String("out", stdio, "file to write the cover to (- for stdout)")
+	image := fs.String("image", "", "JPEG or PNG file or http(s) URL of the new cover")
+	maxSize := fs.Int("max-size", 0, "scale the cover down to fit `PIXELS` by PIXELS")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: cover %s [flags] file.mp3\n", args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return usageError(err)
+	}
+	if fs.NArg() != 1 || fs.Arg(0) == stdio {
+		fs.Usage()
+		return usageError(errors.New("one MP3 file is required"))
+	}
+	name := fs.Arg(0)
+	failure := &failureKinds{}
+	switch args[0] {
+	case "extract":
+		_, data, err := id3v24.ReadCover(name)
+		if err != nil {
+			return audioError(err)
+		}
+		return writeOutput(*out, data)
+	case "replace":
+		if *image == "" {
+			fs.Usage()
+			return usageError(errors.New("--image is required"))
+		}
+		data, err := readImage(*image)
+		if err != nil {
+			return inputError(err)
+		}
+		if *maxSize > 0 {
+			if data, err = id3v24.ResizeCover(data, *maxSize); err != nil {
+				return inputError(err)
+			}
+		}
+		return failure.classify(id3v24.SetCover(name, data, id3v24.WithMetrics(failure)))
+	case "resize":
+		if *maxSize <= 0 {
+			fs.Usage()
+			return usageError(errors.New("--max-size is required"))
+		}
+		_, data, err := id3v24.ReadCover(name)
+		if err != nil {
+			return audioError(err)
+		}
+		resized, err := id3v24.ResizeCover(data, *maxSize)
+		if err != nil || bytes.Equal(resized, data) {
+			return audioError(err)
+		}
+		return failure.classify(id3v24.SetCover(name, resized, id3v24.WithMetrics(failure)))
+	case "remove":
+		return failure.classify(id3v24.RemoveCover(name, id3v24.WithMetrics(failure)))
+	}
+	return nil
+}
+
+// readImage reads the file or fetches the http(s) URL name.
+func readImage(name string) ([]byte, error) {
+	if !strings.HasPrefix(name, "http://") && !strings.HasPrefix(name, "https://") {
+		return os.ReadFile(name)
+	}
+	resp, err := http.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", name, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func readInputAndDuration(fs *flag.FlagSet, meta, template, audio string, duration time.Duration) (id3v24.TrackInfo, time.Duration, error) {
+	if meta == "" || (audio == "" && duration == 0) {
+		fs.Usage()
+		return id3v24.TrackInfo{}, 0, usageError(errors.New("--meta and one of --audio or --duration are required"))
+	}
+	input, err := readTrackInfo(meta, template)
+	if err != nil {
+		return input, 0, err
+	}
+	if duration != 0 {
+		return input, duration, nil
+	}
+	r, err := openInput(audio)
+	if err != nil {
+		return input, 0, audioError(err)
+	}
+	defer r.Close()
+	di, err := id3v24.ReadMP3Duration(r)
+	if err != nil {
+		return input, 0, audioError(err)
+	}
+	return input, di.TimeDuration, nil
+}
+
+// readTrackInfo reads the track info JSON file (or the front matter
+// of the markdown file) name and, unless template is empty, merges in
+// the defaults of the named template. Track info JSON read from stdin
+// may be followed by the audio, see stdin.
+func readTrackInfo(name, template string) (input id3v24.TrackInfo, err error) {
+	defer func() { err = inputError(err) }()
+	if ext := strings.ToLower(filepath.Ext(name)); ext == ".md" || ext == ".markdown" {
+		if input, err = id3v24.ReadMarkdownFile(name); err != nil {
+			return input, err
+		}
+	} else {
+		r, err := openInput(name)
+		if err != nil {
+			return input, err
+		}
+		defer r.Close()
+		dec := json.NewDecoder(r)
+		if err := dec.Decode(&input); err != nil {
+			return input, fmt.Errorf("%s: %w", name, err)
+		}
+		if name == stdio {
+			stdin = io.MultiReader(dec.Buffered(), stdin)
+		}
+	}
+	if template == "" {
+		return input, nil
+	}
+	defaults, err := id3v24.LoadTemplate(template)
+	if err != nil {
+		return input, err
+	}
+	return id3v24.ApplyTemplate(input, defaults), nil
+}
+
+func templateDir() string {
+	dir, err := id3v24.TemplateDir()
+	if err != nil {
+		return "the config directory"
+	}
+	return dir
+}
+
+func openInput(name string) (io.ReadCloser, error) {
+	if name == stdio {
+		return io.NopCloser(stdin), nil
+	}
+	return os.Open(name)
+}
+
+// writeOutput writes data to the file name, or stdout, classifying
+// a failure as a write error.
+func writeOutput(name string, data []byte) error {
+	if name == stdio {
+		_, err := os.Stdout.Write(data)
+		return writeError(err)
+	}
+	return writeError(os.WriteFile(name, data, 0644))
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sa6mwa/id3v24"
+)
+
+func TestWriteMetaAndAudioFromStdin(t *testing.T) {
+	mp3, err := os.ReadFile("../../testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(r io.Reader) { stdin = r }(stdin)
+	stdin = bytes.NewReader(append([]byte(`{"title": "Piped"}`+"\n"), mp3...))
+	out := filepath.Join(t.TempDir(), "out.mp3")
+	if err := writeCmd([]string{"--meta", "-", "--audio", "-", "--out", out}); err != nil {
+		t.Fatal(err)
+	}
+	written, err := id3v24.ReadID3v2Tag(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written.Title != "Piped" {
+		t.Errorf("expected title %q, got %q", "Piped", written.Title)
+	}
+}
+
+func TestWriteVerifyPreparedChapters(t *testing.T) {
+	mp3, err := os.ReadFile("../../testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		flag    string
+		meta    string
+		sidecar string
+	}{
+		{flag: "--sort-chapters", meta: `{"title": "Sorted", "chapters": [{"title": "Two", "start": "00:00:01"}, {"title": "One", "start": "00:00:00"}]}`},
+		{flag: "--clamp-chapters", meta: `{"title": "Clamped", "chapters": [{"title": "One", "start": "00:00:00"}, {"title": "Beyond", "start": "00:00:10"}]}`},
+		{flag: "--sidecar-chapters", meta: `{"title": "Sidecar"}`, sidecar: "00:00 One\n00:01 Two\n"},
+	} {
+		dir := t.TempDir()
+		audio := filepath.Join(dir, "episode.mp3")
+		meta := filepath.Join(dir, "episode.json")
+		if err := os.WriteFile(audio, mp3, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(meta, []byte(tc.meta), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if tc.sidecar != "" {
+			if err := os.WriteFile(filepath.Join(dir, "episode.chapters.txt"), []byte(tc.sidecar), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := writeCmd([]string{"--meta", meta, "--audio", audio, "--verify", tc.flag}); err != nil {
+			t.Errorf("%s: %v", tc.flag, err)
+		}
+	}
+}
+
+func TestChaptersConvertCueDuration(t *testing.T) {
+	mp3, err := os.ReadFile("../../testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "in.mp3"), mp3, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cue := filepath.Join(dir, "in.cue")
+	if err := os.WriteFile(cue, []byte("FILE \"in.mp3\" MP3\n  TRACK 01 AUDIO\n    TITLE \"Intro\"\n    INDEX 01 00:00:00\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.txt")
+	if err := chaptersCmd([]string{"convert", "--from", "cue", "--to", "ffmetadata", cue, out}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("START=0\nEND=3056\ntitle=Intro\n")) {
+		t.Errorf("expected the chapter to end at the end of in.mp3, got %q", data)
+	}
+}
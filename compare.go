@@ -0,0 +1,213 @@
+package id3v24
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// TagMismatchError is returned by AssertTagEquals when the tags
+// differ, with one line per difference.
+type TagMismatchError struct {
+	Differences []string
+}
+
+func (e *TagMismatchError) Error() string {
+	return "tags differ:\n  " + strings.Join(e.Differences, "\n  ")
+}
+
+// AssertTagEquals compares the ID3v2 tags of the files got and want
+// semantically, for golden tests of tagged output: frames are
+// compared by their decoded content regardless of order, text
+// encoding, padding and frame header details, and chapters by
+// element ID, times and sub-frames. Frames with an ID in ignoreFrames
+// (e.g "TSSE" or "PRIV") are skipped. Returns a *TagMismatchError
+// listing the differences, or nil if the tags are equal.
+func AssertTagEquals(got, want string, ignoreFrames ...string) error {
+	gotTag, err := openTag(got)
+	if err != nil {
+		return err
+	}
+	wantTag, err := openTag(want)
+	if err != nil {
+		return err
+	}
+	return compareTags(gotTag, wantTag, ignoreFrames)
+}
+
+// openTag parses the ID3v2 tag at the start of path.
+func openTag(path string) (*id3v2.Tag, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tag, err := id3v2.ParseReader(f, id3v2.Options{Parse: true})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return tag, nil
+}
+
+func compareTags(got, want *id3v2.Tag, ignoreFrames []string) error {
+	if diffs := TagFrameSet(got, ignoreFrames...).Diff(TagFrameSet(want, ignoreFrames...)); diffs != nil {
+		return &TagMismatchError{Differences: diffs}
+	}
+	return nil
+}
+
+// FrameSet is the decoded content of the frames of a tag by frame
+// key, the frame ID followed by what distinguishes frames with the
+// same ID (e.g the description of a TXXX frame), see TagFrameSet.
+type FrameSet map[string][]string
+
+// TagFrameSet returns the decoded content of the frames of tag except
+// those with an ID in ignoreFrames. The content is independent of
+// frame order, text encoding and header details; chapters are
+// described by element ID, times, title and sub-frame hashes and
+// pictures by MIME type, description, size and hash.
+func TagFrameSet(tag *id3v2.Tag, ignoreFrames ...string) FrameSet {
+	ignore := map[string]bool{}
+	for _, id := range ignoreFrames {
+		ignore[id] = true
+	}
+	set := FrameSet{}
+	for id, frames := range tag.AllFrames() {
+		if ignore[id] {
+			continue
+		}
+		for _, f := range frames {
+			key := strings.ReplaceAll(frameKey(id, f), "\x00", ":")
+			set[key] = append(set[key], describeFrame(id, f))
+		}
+	}
+	for _, values := range set {
+		sort.Strings(values)
+	}
+	return set
+}
+
+// ReadFrameSet parses the ID3v2 tag at the start of r and returns its
+// TagFrameSet.
+func ReadFrameSet(r io.Reader, ignoreFrames ...string) (FrameSet, error) {
+	tag, err := id3v2.ParseReader(r, id3v2.Options{Parse: true})
+	if err != nil {
+		return nil, err
+	}
+	return TagFrameSet(tag, ignoreFrames...), nil
+}
+
+// Diff returns one line per frame key that differs between s and
+// want, sorted by key, or nil if they are equal.
+func (s FrameSet) Diff(want FrameSet) []string {
+	var keys []string
+	for key := range want {
+		keys = append(keys, key)
+	}
+	for key := range s {
+		if _, ok := want[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var diffs []string
+	for _, key := range keys {
+		g, inGot := s[key]
+		w, inWant := want[key]
+		switch {
+		case !inGot:
+			diffs = append(diffs, fmt.Sprintf("missing %s: %s", key, strings.Join(w, "; ")))
+		case !inWant:
+			diffs = append(diffs, fmt.Sprintf("unexpected %s: %s", key, strings.Join(g, "; ")))
+		case strings.Join(g, "\x00") != strings.Join(w, "\x00"):
+			diffs = append(diffs, fmt.Sprintf("%s: got %s, want %s", key, strings.Join(g, "; "), strings.Join(w, "; ")))
+		}
+	}
+	return diffs
+}
+
+// describeFrame returns the decoded content of frame f with id.
+func describeFrame(id string, f id3v2.Framer) string {
+	switch f := f.(type) {
+	case id3v2.TextFrame:
+		return fmt.Sprintf("%q", f.Text)
+	case id3v2.UserDefinedTextFrame:
+		return fmt.Sprintf("%q", f.Value)
+	case id3v2.CommentFrame:
+		return fmt.Sprintf("%q", f.Text)
+	case id3v2.UnsynchronisedLyricsFrame:
+		return fmt.Sprintf("%q", f.Lyrics)
+	case id3v2.PictureFrame:
+		return fmt.Sprintf("%s %q %d bytes sha256:%x", f.MimeType, f.Description, len(f.Picture), sha256.Sum256(f.Picture))
+	case id3v2.PopularimeterFrame:
+		return fmt.Sprintf("%q rating=%d counter=%v", f.Email, f.Rating, f.Counter)
+	case id3v2.UnknownFrame:
+		switch id {
+		case "CHAP":
+			cf, err := parseCHAP(f.Body)
+			if err != nil {
+				break
+			}
+			return fmt.Sprintf("%s %s-%s %q%s", cf.ElementID, MillisToStringTime(cf.StartMillis), MillisToStringTime(cf.EndMillis), cf.Title, describeSubFrames(f.Body))
+		case "CTOC":
+			tocID, top, children, err := parseCTOC(f.Body)
+			if err != nil {
+				break
+			}
+			return fmt.Sprintf("%s top-level=%t %v", tocID, top, children)
+		case "WXXX":
+			if len(f.Body) > 0 {
+				_, url, _ := cutEncodedString(f.Body[1:], f.Body[0])
+				return fmt.Sprintf("%q", strings.TrimRight(string(url), "\x00"))
+			}
+		}
+		return fmt.Sprintf("%x", f.Body)
+	}
+	return fmt.Sprintf("%+v", f)
+}
+
+// describeSubFrames returns the IDs and decoded values (content
+// hashes for binary sub-frames like APIC) of the sub-frames of a CHAP
+// frame body other than TIT2.
+func describeSubFrames(body []byte) string {
+	i := bytes.IndexByte(body, 0x00)
+	subFrames := body[i+1+16:]
+	var b strings.Builder
+	for len(subFrames) >= id3v2HeaderSize {
+		size, ok := subFrameSize(subFrames[4:8], len(subFrames)-id3v2HeaderSize)
+		if !ok {
+			break
+		}
+		if id := string(subFrames[0:4]); id != "TIT2" {
+			fmt.Fprintf(&b, " %s:%s", id, describeSubFrame(id, subFrames[id3v2HeaderSize:id3v2HeaderSize+size]))
+		}
+		subFrames = subFrames[id3v2HeaderSize+size:]
+	}
+	return b.String()
+}
+
+// describeSubFrame returns the text or URL of a CHAP sub-frame
+// regardless of its text encoding, or a hash of body for other
+// sub-frames.
+func describeSubFrame(id string, body []byte) string {
+	switch {
+	case (id == "TXXX" || id == "WXXX") && len(body) > 0:
+		description, value, _ := cutEncodedString(body[1:], body[0])
+		encoding := body[0]
+		if id == "WXXX" {
+			encoding = id3v2.EncodingISO.Key // the URL is always ISO-8859-1
+		}
+		return fmt.Sprintf("%q=%q", decodeText(description, body[0]), decodeText(value, encoding))
+	case id[0] == 'T' && len(body) > 0:
+		return fmt.Sprintf("%q", decodeText(body[1:], body[0]))
+	case id[0] == 'W':
+		return fmt.Sprintf("%q", decodeText(body, id3v2.EncodingISO.Key))
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(body))
+}
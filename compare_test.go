@@ -0,0 +1,45 @@
+package id3v24
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAssertTagEquals(t *testing.T) {
+	input := TrackInfo{
+		Title:  "Episode 1",
+		Artist: "The Hosts",
+		Chapters: []Chapter{
+			{Start: "00:00:00", Title: "Intro"},
+			{Start: "00:00:01.500", Title: "Main", Description: "The main part, åäö.", URL: "https://example.com/main"},
+		},
+	}
+	want := copyTestMP3(t)
+	if err := WriteID3v2Tag(want, input); err != nil {
+		t.Fatal(err)
+	}
+	got := copyTestMP3(t)
+	if err := WriteID3v2Tag(got, input, WithTextEncoding(UTF8)); err != nil {
+		t.Fatal(err)
+	}
+	if err := AssertTagEquals(got, want); err != nil {
+		t.Errorf("expected equal tags, got %v", err)
+	}
+	input.Artist = "Someone Else"
+	input.Chapters[1].Title = "Interview"
+	if err := WriteID3v2Tag(got, input); err != nil {
+		t.Fatal(err)
+	}
+	err := AssertTagEquals(got, want)
+	var mismatch *TagMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("expected *TagMismatchError, got %v", err)
+	}
+	if len(mismatch.Differences) != 2 || !strings.HasPrefix(mismatch.Differences[0], "CHAP: ") || !strings.HasPrefix(mismatch.Differences[1], "TPE1: ") {
+		t.Errorf("unexpected differences %q", mismatch.Differences)
+	}
+	if err := AssertTagEquals(got, want, "CHAP", "TPE1"); err != nil {
+		t.Errorf("expected equal tags ignoring CHAP and TPE1, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package id3v24
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png" // for ResizeCover
+	"net/http"
+	"os"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+var ErrNoCover error = errors.New("no cover picture")
+
+// ReadCover returns the front cover picture of the tag of mp3file, or
+// the first picture if it has no front cover, and its MIME type.
+// Returns ErrNoCover if the tag has no picture or mp3file no tag.
+func ReadCover(mp3file string) (mimeType string, data []byte, err error) {
+	f, err := os.Open(mp3file)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	tag, err := id3v2.ParseReader(f, id3v2.Options{Parse: true})
+	if err != nil {
+		return "", nil, err
+	}
+	pf, ok := tagCoverFrame(tag)
+	if !ok {
+		return "", nil, ErrNoCover
+	}
+	mimeType = pf.MimeType
+	if mimeType == "" || mimeType == "image/jpg" {
+		mimeType = http.DetectContentType(pf.Picture)
+	}
+	return mimeType, pf.Picture, nil
+}
+
+// SetCover replaces the cover picture (see ReadCover) of the tag of
+// mp3file with the image in data, keeping all other frames. A file
+// without a tag gets a new one.
+func SetCover(mp3file string, data []byte, opts ...Option) error {
+	mimeType := http.DetectContentType(data)
+	if mimeType != "image/jpeg" && mimeType != "image/png" {
+		return errors.New("cover is not a JPEG or PNG image")
+	}
+	return updateLeadingTag(newOptions(opts...), mp3file, func(tag *id3v2.Tag) error {
+		deleteCover(tag)
+		addCover(tag, mimeType, data)
+		return nil
+	})
+}
+
+// RemoveCover removes the cover picture (see ReadCover) from the tag
+// of mp3file, keeping all other frames. Returns ErrNoCover if there is
+// none.
+func RemoveCover(mp3file string, opts ...Option) error {
+	return updateLeadingTag(newOptions(opts...), mp3file, func(tag *id3v2.Tag) error {
+		if !deleteCover(tag) {
+			return ErrNoCover
+		}
+		return nil
+	})
+}
+
+// ResizeCover returns the JPEG or PNG image in data scaled down to fit
+// maxSize by maxSize pixels, keeping the aspect ratio, as a JPEG.
+// Images already fitting are returned as is if they are JPEGs.
+func ResizeCover(data []byte, maxSize int) ([]byte, error) {
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	b := src.Bounds()
+	if b.Dx() <= maxSize && b.Dy() <= maxSize {
+		if format == "jpeg" {
+			return data, nil
+		}
+		maxSize = max(b.Dx(), b.Dy())
+	}
+	width, height := maxSize, b.Dy()*maxSize/b.Dx()
+	if b.Dy() > b.Dx() {
+		width, height = b.Dx()*maxSize/b.Dy(), maxSize
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, max(width, 1), max(height, 1)))
+	scaleDown(dst, src)
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// scaleDown draws src onto the smaller dst, averaging the source
+// pixels covered by each destination pixel.
+func scaleDown(dst *image.RGBA, src image.Image) {
+	sb, db := src.Bounds(), dst.Bounds()
+	for y := 0; y < db.Dy(); y++ {
+		y0, y1 := sb.Min.Y+y*sb.Dy()/db.Dy(), sb.Min.Y+(y+1)*sb.Dy()/db.Dy()
+		for x := 0; x < db.Dx(); x++ {
+			x0, x1 := sb.Min.X+x*sb.Dx()/db.Dx(), sb.Min.X+(x+1)*sb.Dx()/db.Dx()
+			var r, g, b, a, n uint32
+			for sy := y0; sy < max(y1, y0+1); sy++ {
+				for sx := x0; sx < max(x1, x0+1); sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a, n = r+cr, g+cg, b+cb, a+ca, n+1
+				}
+			}
+			dst.Set(db.Min.X+x, db.Min.Y+y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(b / n), uint16(a / n)})
+		}
+	}
+}
+
+// tagCoverFrame returns the front cover picture frame of tag, or the
+// first picture frame if it has no front cover.
+func tagCoverFrame(tag *id3v2.Tag) (cover id3v2.PictureFrame, ok bool) {
+	for _, f := range tag.GetFrames(tag.CommonID("Attached picture")) {
+		pf, isPicture := f.(id3v2.PictureFrame)
+		if !isPicture {
+			continue
+		}
+		if pf.PictureType == id3v2.PTFrontCover {
+			return pf, true
+		}
+		if !ok {
+			cover, ok = pf, true
+		}
+	}
+	return cover, ok
+}
+
+// deleteCover removes the front cover pictures of tag, or the first
+// picture if it has no front cover. Returns false if tag has no
+// picture.
+func deleteCover(tag *id3v2.Tag) bool {
+	cover, ok := tagCoverFrame(tag)
+	if !ok {
+		return false
+	}
+	id := tag.CommonID("Attached picture")
+	frames := tag.GetFrames(id)
+	tag.DeleteFrames(id)
+	deleted := false
+	for _, f := range frames {
+		if pf, isPicture := f.(id3v2.PictureFrame); isPicture && (pf.PictureType == id3v2.PTFrontCover || !deleted && bytes.Equal(pf.Picture, cover.Picture)) {
+			deleted = true
+			continue
+		}
+		tag.AddFrame(id, f)
+	}
+	return true
+}
+
+// updateLeadingTag calls fn with the parsed ID3v2 tag at the start of
+// path, or a new ID3v2.4 tag if there is none, and replaces the tag
+// with the result.
+func updateLeadingTag(o *options, path string, fn func(tag *id3v2.Tag) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return o.fail(MetricErrOpen, err)
+	}
+	previous, err := readLeadingTag(f)
+	f.Close()
+	if err != nil {
+		return o.fail(MetricErrOpen, err)
+	}
+	tag := id3v2.NewEmptyTag()
+	tag.SetVersion(4)
+	if len(previous) > 0 {
+		if tag, err = id3v2.ParseReader(bytes.NewReader(previous), id3v2.Options{Parse: true}); err != nil {
+			return o.fail(MetricErrOpen, err)
+		}
+	}
+	if err := fn(tag); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if _, err := tag.WriteTo(&buf); err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	return replaceLeadingTag(o, path, buf.Bytes())
+}
@@ -0,0 +1,82 @@
+package id3v24
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"testing"
+)
+
+func TestCover(t *testing.T) {
+	name := copyTestMP3(t)
+	if _, _, err := ReadCover(name); !errors.Is(err, ErrNoCover) {
+		t.Errorf("expected ErrNoCover, got %v", err)
+	}
+	if err := WriteID3v2Tag(name, TrackInfo{Title: "Cover", CoverData: []byte("\xFF\xD8\xFF\xE0 not really a JPEG")}); err != nil {
+		t.Fatal(err)
+	}
+	src := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	for x := 0; x < 100; x++ {
+		for y := 0; y < 100; y++ {
+			src.Set(x, y, color.White)
+		}
+	}
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, src); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetCover(name, pngData.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	mimeType, data, err := ReadCover(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mimeType != "image/png" || !bytes.Equal(data, pngData.Bytes()) {
+		t.Errorf("expected the PNG cover, got %s of %d bytes", mimeType, len(data))
+	}
+	input, err := ReadID3v2Tag(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.Title != "Cover" {
+		t.Errorf("expected %q, got %q", "Cover", input.Title)
+	}
+
+	resized, err := ResizeCover(data, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, format, err := image.Decode(bytes.NewReader(resized))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "jpeg" || img.Bounds().Dx() != 50 || img.Bounds().Dy() != 25 {
+		t.Errorf("expected a 50x25 jpeg, got a %dx%d %s", img.Bounds().Dx(), img.Bounds().Dy(), format)
+	}
+	if r, g, b, _ := img.At(12, 12).RGBA(); r>>8 < 0xF0 || g>>8 < 0xF0 || b>>8 < 0xF0 {
+		t.Errorf("expected white at 12,12, got %v", img.At(12, 12))
+	}
+	if same, err := ResizeCover(resized, 100); err != nil || !bytes.Equal(same, resized) {
+		t.Errorf("expected a fitting JPEG to be returned as is, got %v", err)
+	}
+
+	if err := RemoveCover(name); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ReadCover(name); !errors.Is(err, ErrNoCover) {
+		t.Errorf("expected ErrNoCover, got %v", err)
+	}
+	if err := RemoveCover(name); !errors.Is(err, ErrNoCover) {
+		t.Errorf("expected ErrNoCover, got %v", err)
+	}
+	if input, err = ReadID3v2Tag(name); err != nil || input.Title != "Cover" {
+		t.Errorf("expected the title to be kept, got %q, %v", input.Title, err)
+	}
+	if fi, err := os.Stat(name); err != nil || fi.Size() <= 48900 {
+		t.Errorf("expected the tag to be kept, got %v", err)
+	}
+}
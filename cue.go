@@ -0,0 +1,103 @@
+package id3v24
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseCueSheet returns one chapter per TRACK of the cue sheet in r,
+// titled by the track TITLE (or "Track N") and starting at its
+// INDEX 01. Cue sheets referencing several FILEs are read as if the
+// files were concatenated without gaps, which is only correct if
+// every INDEX is relative to the start of the first file.
+func ParseCueSheet(r io.Reader) ([]Chapter, error) {
+	var chapters []Chapter
+	inTrack := false
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "TRACK":
+			chapters = append(chapters, Chapter{Title: fmt.Sprintf("Track %d", len(chapters)+1)})
+			inTrack = true
+		case "TITLE":
+			if inTrack {
+				chapters[len(chapters)-1].Title = cueString(strings.TrimSpace(scanner.Text())[len(fields[0]):])
+			}
+		case "INDEX":
+			if !inTrack || len(fields) < 3 || fields[1] != "01" {
+				continue
+			}
+			millis, err := cueTimeToMillis(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			chapters[len(chapters)-1].Start = MillisToStringTime(millis)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for i, ch := range chapters {
+		if ch.Start == "" {
+			return nil, fmt.Errorf("track %d has no INDEX 01", i+1)
+		}
+	}
+	return chapters, nil
+}
+
+// CueSheetFiles returns the names of the FILEs of the cue sheet in r,
+// relative to the cue sheet unless absolute.
+func CueSheetFiles(r io.Reader) ([]string, error) {
+	var files []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		fields := strings.Fields(line)
+		if len(fields) < 3 || strings.ToUpper(fields[0]) != "FILE" {
+			continue
+		}
+		// FILE "name with spaces" TYPE
+		name := strings.TrimSpace(line[len(fields[0]):])
+		name = strings.TrimSpace(name[:len(name)-len(fields[len(fields)-1])])
+		files = append(files, cueString(name))
+	}
+	return files, scanner.Err()
+}
+
+// cueString returns the possibly quoted string s.
+func cueString(s string) string {
+	s = strings.TrimSpace(s)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		return unquoted
+	}
+	return strings.Trim(s, `"`)
+}
+
+// cueTimeToMillis converts a cue sheet MM:SS:FF time (75 frames per
+// second) to milliseconds.
+func cueTimeToMillis(t string) (uint32, error) {
+	bad := fmt.Errorf("bad cue time %q (expected MM:SS:FF)", t)
+	parts := strings.Split(t, ":")
+	if len(parts) != 3 {
+		return 0, bad
+	}
+	var v [3]uint64
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 10, 32)
+		if err != nil {
+			return 0, bad
+		}
+		v[i] = n
+	}
+	if v[1] >= 60 || v[2] >= 75 {
+		return 0, bad
+	}
+	return uint32(v[0]*60000 + v[1]*1000 + v[2]*1000/75), nil
+}
@@ -0,0 +1,168 @@
+package id3v24
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrBadDate          error = errors.New("bad date (expected e.g 2024-09-17, 17 Sep 2024, RFC3339 or epoch seconds)")
+	ErrYearDateConflict error = errors.New("year does not match date")
+)
+
+// dateLayouts are the layouts ParseDate tries, in order.
+var dateLayouts = []string{
+	"2006-01-02",
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2 Jan 2006",
+	"2 January 2006",
+	"Jan 2, 2006",
+	"January 2, 2006",
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2006/01/02",
+	"20060102",
+}
+
+// ParseDate parses s as a date in any of the common formats of show
+// notes and feeds: "2024-09-17", "17 Sep 2024", "Sep 17, 2024",
+// "20240917", RFC3339 (e.g "2024-09-17T10:00:00Z"), the RFC1123
+// pubDate of RSS or epoch seconds (e.g "1726531200"). Dates without
+// a zone are in UTC and an empty s is the zero time. TrackInfo uses it
+// when decoding the date field from JSON or YAML.
+func ParseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if strings.Trim(s, "0123456789") == "" && len(s) != 8 {
+		secs, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: %q", ErrBadDate, s)
+		}
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %q", ErrBadDate, s)
+}
+
+// formatDate returns the date of t in UTC as written to the tags, e.g
+// 2024-09-17. 2024-09-17T01:00:00+02:00 is 2024-09-16, so that a date
+// is the same whatever the time zone of the machine or feed it came
+// from.
+func formatDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// WithYearFromDate makes the writers replace the Year of a TrackInfo
+// with the year of its Date (in UTC) instead of failing with
+// ErrYearDateConflict when they disagree, see resolveYear.
+func WithYearFromDate() Option {
+	return func(o *options) {
+		o.yearFromDate = true
+	}
+}
+
+// resolveYear applies the precedence of Date over Year to input. A set
+// Date is written (in UTC, see formatDate) as the TDRC frame, the
+// Vorbis DATE or the ffmetadata date and Year is only written when
+// Date is zero. Year is set to the year of Date, but a Year that is
+// not the start of Date (e.g 2023 or 2024-10 for 2024-09-17) is an
+// ErrYearDateConflict unless WithYearFromDate is given.
+func (o *options) resolveYear(input *TrackInfo) error {
+	if input.Date.IsZero() {
+		return nil
+	}
+	date := formatDate(input.Date)
+	if input.Year != "" && !o.yearFromDate && (len(input.Year) < 4 || !strings.HasPrefix(date, input.Year)) {
+		return fmt.Errorf("%w: year %s, date %s", ErrYearDateConflict, input.Year, date)
+	}
+	input.Year = date[:4]
+	return nil
+}
+
+// UnmarshalJSON decodes t like encoding/json, except that the date is
+// parsed with ParseDate and may also be a number of epoch seconds.
+func (t *TrackInfo) UnmarshalJSON(data []byte) error {
+	type plain TrackInfo
+	aux := struct {
+		*plain
+		Date json.RawMessage `json:"date"`
+	}{plain: (*plain)(t)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if len(aux.Date) == 0 || string(aux.Date) == "null" {
+		return nil
+	}
+	s := string(aux.Date)
+	if aux.Date[0] == '"' {
+		if err := json.Unmarshal(aux.Date, &s); err != nil {
+			return err
+		}
+	}
+	date, err := ParseDate(s)
+	if err != nil {
+		return err
+	}
+	t.Date = date
+	return nil
+}
+
+// UnmarshalYAML decodes t like yaml.v3, except that the date is parsed
+// with ParseDate and may also be a number of epoch seconds.
+func (t *TrackInfo) UnmarshalYAML(value *yaml.Node) error {
+	type plain TrackInfo
+	var date *yaml.Node
+	if value.Kind == yaml.MappingNode {
+		content := make([]*yaml.Node, 0, len(value.Content))
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			if value.Content[i].Value == "date" {
+				date = value.Content[i+1]
+				continue
+			}
+			content = append(content, value.Content[i], value.Content[i+1])
+		}
+		stripped := *value
+		stripped.Content = content
+		value = &stripped
+	}
+	if err := value.Decode((*plain)(t)); err != nil {
+		return err
+	}
+	if date == nil || date.Tag == "!!null" {
+		return nil
+	}
+	if date.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: %w", date.Line, ErrBadDate)
+	}
+	if date.Tag == "!!timestamp" {
+		var ts time.Time
+		if err := date.Decode(&ts); err == nil {
+			t.Date = ts
+			return nil
+		}
+	}
+	parsed, err := ParseDate(date.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", date.Line, err)
+	}
+	t.Date = parsed
+	return nil
+}
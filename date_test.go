@@ -0,0 +1,99 @@
+package id3v24
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseDate(t *testing.T) {
+	expected := time.Date(2024, time.September, 17, 0, 0, 0, 0, time.UTC)
+	for _, s := range []string{"2024-09-17", "17 Sep 2024", "17 September 2024", "Sep 17, 2024", "20240917", "2024-09-17T00:00:00Z", "Tue, 17 Sep 2024 00:00:00 +0000", "1726531200"} {
+		got, err := ParseDate(s)
+		if err != nil {
+			t.Errorf("%q: %v", s, err)
+		} else if !got.Equal(expected) {
+			t.Errorf("%q: expected %v, got %v", s, expected, got)
+		}
+	}
+	if got, err := ParseDate(""); err != nil || !got.IsZero() {
+		t.Errorf("expected zero time, got %v (%v)", got, err)
+	}
+	if _, err := ParseDate("next tuesday"); !errors.Is(err, ErrBadDate) {
+		t.Errorf("expected %v, got %v", ErrBadDate, err)
+	}
+}
+
+func TestTrackInfoDate(t *testing.T) {
+	expected := time.Date(2024, time.September, 17, 0, 0, 0, 0, time.UTC)
+	for _, data := range []string{`{"title":"x","date":"2024-09-17"}`, `{"title":"x","date":"17 Sep 2024"}`, `{"title":"x","date":1726531200}`} {
+		var input TrackInfo
+		if err := json.Unmarshal([]byte(data), &input); err != nil {
+			t.Errorf("%s: %v", data, err)
+		} else if input.Title != "x" || !input.Date.Equal(expected) {
+			t.Errorf("%s: expected x and %v, got %q and %v", data, expected, input.Title, input.Date)
+		}
+	}
+	for _, data := range []string{"title: x\ndate: 2024-09-17\n", "title: x\ndate: 17 Sep 2024\n", "title: x\ndate: 1726531200\n"} {
+		var input TrackInfo
+		if err := yaml.Unmarshal([]byte(data), &input); err != nil {
+			t.Errorf("%q: %v", data, err)
+		} else if input.Title != "x" || !input.Date.Equal(expected) {
+			t.Errorf("%q: expected x and %v, got %q and %v", data, expected, input.Title, input.Date)
+		}
+	}
+	data, err := json.Marshal(TrackInfo{Title: "x", Date: expected})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var input TrackInfo
+	if err := json.Unmarshal(data, &input); err != nil || !input.Date.Equal(expected) {
+		t.Errorf("expected round trip of %v, got %v (%v)", expected, input.Date, err)
+	}
+	if err := json.Unmarshal([]byte(`{"date":"someday"}`), &input); !errors.Is(err, ErrBadDate) {
+		t.Errorf("expected %v, got %v", ErrBadDate, err)
+	}
+}
+
+func TestResolveYear(t *testing.T) {
+	stockholm := time.FixedZone("CEST", 2*60*60)
+	date := time.Date(2024, time.January, 1, 1, 0, 0, 0, stockholm)
+	comments, err := VorbisComments(TrackInfo{Year: "2023", Date: date})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 || comments[0] != "DATE=2023-12-31" {
+		t.Errorf("expected [DATE=2023-12-31], got %v", comments)
+	}
+	for _, year := range []string{"", "2023", "2023-12"} {
+		if _, err := VorbisComments(TrackInfo{Year: year, Date: date}); err != nil {
+			t.Errorf("year %q: %v", year, err)
+		}
+	}
+	for _, year := range []string{"2024", "2023-11", "20"} {
+		if _, err := VorbisComments(TrackInfo{Year: year, Date: date}); !errors.Is(err, ErrYearDateConflict) {
+			t.Errorf("year %q: expected %v, got %v", year, ErrYearDateConflict, err)
+		}
+	}
+	if _, err := GetFFmpegMetadata(time.Minute, TrackInfo{Year: "2024", Date: date}, WithYearFromDate()); err != nil {
+		t.Errorf("expected WithYearFromDate to resolve the conflict, got %v", err)
+	}
+
+	name := copyTestMP3(t)
+	if err := WriteID3v2Tag(name, TrackInfo{Title: "x", Year: "2024", Date: date}); !errors.Is(err, ErrYearDateConflict) {
+		t.Errorf("expected %v, got %v", ErrYearDateConflict, err)
+	}
+	if err := WriteID3v2Tag(name, TrackInfo{Title: "x", Year: "2024", Date: date}, WithYearFromDate()); err != nil {
+		t.Fatal(err)
+	}
+	input, err := ReadID3v2Tag(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.Year != "2023" || input.Date.Format("2006-01-02") != "2023-12-31" {
+		t.Errorf("expected 2023 and 2023-12-31, got %s and %v", input.Year, input.Date)
+	}
+}
@@ -0,0 +1,58 @@
+package id3v24
+
+import (
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// DescriptionFrame is a frame that TrackInfo.Description can be
+// written to, see WithDescriptionFrames.
+type DescriptionFrame int
+
+const (
+	DescriptionTDES DescriptionFrame = 1 << iota // TDES, the podcast description read by Apple Podcasts
+	DescriptionCOMM                              // COMM (comment) without content description
+	DescriptionUSLT                              // USLT (unsynchronised lyrics/text), for long show notes
+)
+
+// WithDescriptionFrames selects the frames WriteID3v2Tag writes
+// TrackInfo.Description to, DescriptionTDES by default. Without
+// frames, Description is only used for ffmetadata and Vorbis
+// comments. The COMM and USLT frames are in TrackInfo.Language if it
+// is an ISO 639-2 code (e.g "eng"), otherwise in "XXX" (unknown).
+func WithDescriptionFrames(frames ...DescriptionFrame) Option {
+	return func(o *options) {
+		o.descriptionFrames = 0
+		for _, f := range frames {
+			o.descriptionFrames |= f
+		}
+	}
+}
+
+// addDescription adds input.Description to the frames of tag selected
+// by WithDescriptionFrames.
+func (o *options) addDescription(tag *id3v2.Tag, input TrackInfo) {
+	if len([]rune(input.Description)) == 0 {
+		return
+	}
+	lang := "XXX"
+	if len(input.Language) == 3 {
+		lang = input.Language
+	}
+	if o.descriptionFrames&DescriptionTDES != 0 {
+		tag.AddTextFrame("TDES", tag.DefaultEncoding(), input.Description)
+	}
+	if o.descriptionFrames&DescriptionCOMM != 0 {
+		tag.AddCommentFrame(id3v2.CommentFrame{
+			Encoding: tag.DefaultEncoding(),
+			Language: lang,
+			Text:     input.Description,
+		})
+	}
+	if o.descriptionFrames&DescriptionUSLT != 0 {
+		tag.AddUnsynchronisedLyricsFrame(id3v2.UnsynchronisedLyricsFrame{
+			Encoding: tag.DefaultEncoding(),
+			Language: lang,
+			Lyrics:   input.Description,
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package id3v24
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+func TestWithDescriptionFrames(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := TrackInfo{Title: "Episode 1", Description: "Show notes.", Language: "eng"}
+	for _, tc := range []struct {
+		opts             []Option
+		tdes, comm, uslt int
+	}{
+		{nil, 1, 0, 0},
+		{[]Option{WithDescriptionFrames(DescriptionCOMM, DescriptionUSLT)}, 0, 1, 1},
+		{[]Option{WithDescriptionFrames()}, 0, 0, 0},
+	} {
+		var out bytes.Buffer
+		if err := WriteID3v2TagTo(&out, bytes.NewReader(mp3), input, tc.opts...); err != nil {
+			t.Fatal(err)
+		}
+		tag, err := id3v2.ParseReader(bytes.NewReader(out.Bytes()), id3v2.Options{Parse: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tdes, comments, lyrics := tag.GetFrames("TDES"), TagComments(tag), TagLyrics(tag)
+		if len(tdes) != tc.tdes || len(comments) != tc.comm || len(lyrics) != tc.uslt {
+			t.Errorf("expected %d TDES, %d COMM and %d USLT, got %d, %d and %d", tc.tdes, tc.comm, tc.uslt, len(tdes), len(comments), len(lyrics))
+		}
+		if tc.tdes > 0 && tag.GetTextFrame("TDES").Text != input.Description {
+			t.Errorf("expected %q, got %q", input.Description, tag.GetTextFrame("TDES").Text)
+		}
+		for _, text := range append(comments, lyrics...) {
+			if text.Text != input.Description || text.Language != "eng" {
+				t.Errorf("expected %q in eng, got %q in %s", input.Description, text.Text, text.Language)
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package id3v24
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"time"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+var ErrNotDSF error = errors.New("not a DSF stream")
+
+// A DSF file starts with a 28 byte "DSD " chunk holding the total file
+// size and a pointer to the ID3v2 metadata chunk at the end of the
+// file, followed by a "fmt " chunk and the "data" chunk.
+const (
+	dsfDSDChunkSize  = 28
+	dsfPointerOffset = 20
+)
+
+// dsfLayout is what WriteDSFTagTo and ReadDSFTag need to know about a
+// DSF file.
+type dsfLayout struct {
+	header   []byte // the DSD chunk
+	metadata int64  // offset of the ID3v2 tag, 0 if none
+	audioEnd int64  // end of the data chunk
+	duration time.Duration
+}
+
+func readDSFLayout(r io.ReadSeeker) (*dsfLayout, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	// DSD chunk and the fmt chunk up to and including the sample
+	// count.
+	header := make([]byte, dsfDSDChunkSize+44)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, ErrNotDSF
+	}
+	if string(header[0:4]) != "DSD " || string(header[dsfDSDChunkSize:dsfDSDChunkSize+4]) != "fmt " {
+		return nil, ErrNotDSF
+	}
+	fmtChunk := header[dsfDSDChunkSize:]
+	fmtSize := int64(binary.LittleEndian.Uint64(fmtChunk[4:12]))
+	samplingFrequency := binary.LittleEndian.Uint32(fmtChunk[28:32])
+	sampleCount := binary.LittleEndian.Uint64(fmtChunk[36:44])
+	if samplingFrequency == 0 {
+		return nil, ErrNotDSF
+	}
+	dataOffset := dsfDSDChunkSize + fmtSize
+	if _, err := r.Seek(dataOffset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	dataHeader := make([]byte, 12)
+	if _, err := io.ReadFull(r, dataHeader); err != nil || string(dataHeader[0:4]) != "data" {
+		return nil, ErrNotDSF
+	}
+	return &dsfLayout{
+		header:   header[:dsfDSDChunkSize],
+		metadata: int64(binary.LittleEndian.Uint64(header[dsfPointerOffset:dsfDSDChunkSize])),
+		audioEnd: dataOffset + int64(binary.LittleEndian.Uint64(dataHeader[4:12])),
+		duration: time.Duration(float64(sampleCount) / float64(samplingFrequency) * float64(time.Second)),
+	}, nil
+}
+
+// WriteDSFTag writes input (including chapters) as the ID3v2 metadata
+// chunk of the DSF file dsffile, replacing any existing tag. The file
+// will be modified.
+func WriteDSFTag(dsffile string, input TrackInfo, opts ...Option) error {
+	return rewriteFile(newOptions(opts...), dsffile, func(w io.Writer, r io.ReadSeeker) error {
+		return WriteDSFTagTo(w, r, input, opts...)
+	})
+}
+
+// WriteDSFTagTo is the io-only variant of WriteDSFTag, reading the DSF
+// file from r and writing the tagged file to w. The duration used for
+// chapters is taken from the sample count of the fmt chunk.
+func WriteDSFTagTo(w io.Writer, r io.ReadSeeker, input TrackInfo, opts ...Option) error {
+	o := newOptions(opts...)
+	began := time.Now()
+	layout, err := readDSFLayout(r)
+	if err != nil {
+		return o.fail(MetricErrOpen, err)
+	}
+	var existing *id3v2.Tag
+	if layout.metadata > 0 {
+		if _, err := r.Seek(layout.metadata, io.SeekStart); err != nil {
+			return o.fail(MetricErrOpen, err)
+		}
+		input, err = o.mergeExistingChapters(r, math.MaxInt64, input)
+		if err != nil {
+			return o.fail(MetricErrChapters, err)
+		}
+		if o.tagMerge {
+			if _, err := r.Seek(layout.metadata, io.SeekStart); err != nil {
+				return o.fail(MetricErrOpen, err)
+			}
+			if existing, err = id3v2.ParseReader(r, id3v2.Options{Parse: true}); err != nil {
+				return o.fail(MetricErrOpen, err)
+			}
+		}
+	}
+	tag := id3v2.NewEmptyTag()
+	if err := setFrames(o, tag, existing, durationInfo(layout.duration), input); err != nil {
+		return err
+	}
+	var record *JournalRecord
+	if layout.metadata > 0 {
+		if _, err := r.Seek(layout.metadata, io.SeekStart); err != nil {
+			return o.fail(MetricErrOpen, err)
+		}
+		record, err = o.journalRecord(r, math.MaxInt64, tag)
+	} else {
+		record, err = o.journalRecord(r, 0, tag)
+	}
+	if err != nil {
+		return o.fail(MetricErrOpen, err)
+	}
+	var buf bytes.Buffer
+	if _, err := tag.WriteTo(&buf); err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	fileSize := layout.audioEnd + int64(buf.Len())
+	header := append([]byte(nil), layout.header...)
+	binary.LittleEndian.PutUint64(header[12:dsfPointerOffset], uint64(fileSize))
+	binary.LittleEndian.PutUint64(header[dsfPointerOffset:dsfDSDChunkSize], uint64(layout.audioEnd))
+	if _, err := w.Write(header); err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	if _, err := r.Seek(dsfDSDChunkSize, io.SeekStart); err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	if _, err := io.CopyN(w, r, layout.audioEnd-dsfDSDChunkSize); err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	if _, err := buf.WriteTo(w); err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	if err := o.writeJournal(record); err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	o.fileTagged(fileSize, time.Since(began))
+	return nil
+}
+
+// ReadDSFTag locates and parses the ID3v2 metadata chunk of the DSF
+// file in r. Returns nil and no error if the file has no tag.
+func ReadDSFTag(r io.ReadSeeker) (*id3v2.Tag, error) {
+	layout, err := readDSFLayout(r)
+	if err != nil {
+		return nil, err
+	}
+	if layout.metadata == 0 {
+		return nil, nil
+	}
+	if _, err := r.Seek(layout.metadata, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return id3v2.ParseReader(r, id3v2.Options{Parse: true})
+}
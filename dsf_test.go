@@ -0,0 +1,90 @@
+package id3v24
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// minimalDSF returns a DSF file with 10 seconds of (empty) 1 bit
+// stereo audio at 2822400 Hz.
+func minimalDSF(audio []byte) []byte {
+	dsf := []byte("DSD ")
+	dsf = binary.LittleEndian.AppendUint64(dsf, 28)
+	dsf = binary.LittleEndian.AppendUint64(dsf, uint64(28+52+12+len(audio)))
+	dsf = binary.LittleEndian.AppendUint64(dsf, 0)
+	dsf = append(dsf, "fmt "...)
+	dsf = binary.LittleEndian.AppendUint64(dsf, 52)
+	dsf = binary.LittleEndian.AppendUint32(dsf, 1)       // format version
+	dsf = binary.LittleEndian.AppendUint32(dsf, 0)       // DSD raw
+	dsf = binary.LittleEndian.AppendUint32(dsf, 2)       // stereo
+	dsf = binary.LittleEndian.AppendUint32(dsf, 2)       // channels
+	dsf = binary.LittleEndian.AppendUint32(dsf, 2822400) // sampling frequency
+	dsf = binary.LittleEndian.AppendUint32(dsf, 1)       // bits per sample
+	dsf = binary.LittleEndian.AppendUint64(dsf, 28224000)
+	dsf = binary.LittleEndian.AppendUint32(dsf, 4096) // block size per channel
+	dsf = binary.LittleEndian.AppendUint32(dsf, 0)
+	dsf = append(dsf, "data"...)
+	dsf = binary.LittleEndian.AppendUint64(dsf, uint64(12+len(audio)))
+	return append(dsf, audio...)
+}
+
+func TestWriteDSFTagTo(t *testing.T) {
+	audio := bytes.Repeat([]byte{0x69}, 64)
+	input := TrackInfo{
+		Title: "Hello world",
+		Chapters: []Chapter{
+			{Title: "Chapter 1", Start: "00:00:00"},
+			{Title: "Chapter 2", Start: "00:00:05"},
+		},
+	}
+	format, err := DetectAudioFormat(bytes.NewReader(minimalDSF(audio)))
+	if err != nil || format != FormatDSF {
+		t.Fatalf("expected %s, got %s (%v)", FormatDSF, format, err)
+	}
+	var out bytes.Buffer
+	if err := WriteDSFTagTo(&out, bytes.NewReader(minimalDSF(audio)), input); err != nil {
+		t.Fatal(err)
+	}
+	// Re-tagging must replace, not stack, the tag.
+	var again bytes.Buffer
+	if err := WriteDSFTagTo(&again, bytes.NewReader(out.Bytes()), input); err != nil {
+		t.Fatal(err)
+	}
+	// Frame order in a written tag is not deterministic, compare the
+	// DSF chunks byte for byte and the tags frame by frame.
+	if n := len(minimalDSF(audio)); again.Len() != out.Len() || !bytes.Equal(again.Bytes()[:n], out.Bytes()[:n]) {
+		t.Error("previous tag was not replaced")
+	}
+	first, err := ReadDSFTag(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := ReadDSFTag(bytes.NewReader(again.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := TagFrameSet(second).Diff(TagFrameSet(first)); len(diff) > 0 {
+		t.Errorf("expected the same tag after re-tagging, got %v", diff)
+	}
+	data := out.Bytes()
+	if size := binary.LittleEndian.Uint64(data[12:20]); size != uint64(len(data)) {
+		t.Errorf("expected file size %d, got %d", len(data), size)
+	}
+	tag, err := ReadDSFTag(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag == nil {
+		t.Fatal("expected a tag")
+	}
+	if tag.Title() != input.Title {
+		t.Errorf("expected title %q, got %q", input.Title, tag.Title())
+	}
+	if n := len(tag.GetFrames("CHAP")); n != 2 {
+		t.Errorf("expected 2 CHAP frames, got %d", n)
+	}
+	if tag, err := ReadDSFTag(bytes.NewReader(minimalDSF(audio))); tag != nil || err != nil {
+		t.Errorf("expected no tag, got %v, %v", tag, err)
+	}
+}
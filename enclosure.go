@@ -0,0 +1,30 @@
+package id3v24
+
+import "os"
+
+// Enclosure holds the values a podcast feed needs for an episode
+// file: the url, length and type attributes of the RSS enclosure
+// element and the itunes:duration element.
+type Enclosure struct {
+	Length   int64  // file size in bytes, including the tag
+	Type     string // MIME type, e.g audio/mpeg
+	Duration string // itunes:duration, HH:MM:SS
+}
+
+// GetEnclosure returns the Enclosure of the MP3 file mp3file. Call it
+// after tagging (e.g WriteID3v2Tag) as the tag is part of the length.
+func GetEnclosure(mp3file string) (Enclosure, error) {
+	info, err := Probe(mp3file)
+	if err != nil {
+		return Enclosure{}, err
+	}
+	fi, err := os.Stat(mp3file)
+	if err != nil {
+		return Enclosure{}, err
+	}
+	return Enclosure{
+		Length:   fi.Size(),
+		Type:     info.MIMEType,
+		Duration: info.Duration,
+	}, nil
+}
@@ -0,0 +1,39 @@
+package id3v24
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+var seasonEpisodePattern = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])s(\d{1,3})[ ._-]?e(\d{1,4})(?:[^0-9]|$)`)
+
+// InferSeasonEpisode returns the season and episode numbers (without
+// leading zeros) from a file name containing e.g S02E05, s2e5 or
+// S02.E05. The directory part of filename is ignored. Returns false
+// if no such pattern was found.
+func InferSeasonEpisode(filename string) (season, episode string, ok bool) {
+	m := seasonEpisodePattern.FindStringSubmatch(filepath.Base(filename))
+	if m == nil {
+		return "", "", false
+	}
+	s, _ := strconv.Atoi(m[1])
+	e, _ := strconv.Atoi(m[2])
+	return strconv.Itoa(s), strconv.Itoa(e), true
+}
+
+// ApplySeasonEpisode returns input with Season and Episode inferred
+// from filename (see InferSeasonEpisode) where they are empty.
+func ApplySeasonEpisode(input TrackInfo, filename string) TrackInfo {
+	season, episode, ok := InferSeasonEpisode(filename)
+	if !ok {
+		return input
+	}
+	if input.Season == "" {
+		input.Season = season
+	}
+	if input.Episode == "" {
+		input.Episode = episode
+	}
+	return input
+}
@@ -0,0 +1,402 @@
+package id3v24
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sa6mwa/mp3duration"
+)
+
+// GetFFmpegChaptersTXT returns a chapters.txt file for use with
+// FFmpeg when generating e.g m4b files. Maybe strange to also support
+// ffmpeg and m4b in a package for MP3 ID3 tags, but the functionality
+// is already here and chapters in m4b is much better. Returns a
+// chapters.txt as a byte slice or error if something failed.
+func GetFFmpegChaptersTXT(duration mp3duration.Info, chapters []Chapter, opts ...Option) ([]byte, error) {
+	return GetFFmpegChapters(duration.TimeDuration, chapters, opts...)
+}
+
+// GetFFmpegChapters is GetFFmpegChaptersTXT for callers that know the
+// duration of the audio (the end of the last chapter) without an
+// mp3duration.Info, e.g when building m4b files from FLAC.
+func GetFFmpegChapters(duration time.Duration, chapters []Chapter, opts ...Option) ([]byte, error) {
+	if len(chapters) == 0 {
+		return nil, nil
+	}
+	var b FFMetadataBuilder
+	if err := newOptions(opts...).addFFmetadataChapters(&b, duration, chapters); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// addFFmetadataChapters adds chapters ending at duration to b in the
+// timebase given by the options.
+func (o *options) addFFmetadataChapters(b *FFMetadataBuilder, duration time.Duration, chapters []Chapter) error {
+	if len(chapters) == 0 {
+		return nil
+	}
+	if duration == 0 {
+		return ErrZeroDuration
+	}
+	chapters, err := o.prepareChapters(FlattenChapters(chapters), duration)
+	if err != nil {
+		return err
+	}
+	starts, ends, err := chapterTimes(chapters, uint32(duration/time.Millisecond))
+	if err != nil {
+		return err
+	}
+	timebase := o.timebase
+	if timebase <= 0 {
+		timebase = TimebaseMillis
+	}
+	for i, ch := range chapters {
+		start := int64(starts[i]) * timebase / 1000
+		end := int64(ends[i]) * timebase / 1000
+		b.AddChapter(timebase, start, end, ch.Title)
+	}
+	return nil
+}
+
+// WriteFFmpegChaptersTXT returns a temporary (os.CreateTemp)
+// ffmpeg-compatible chapters.txt file for use if generating e.g an
+// m4b instead of an mp3. Returns full path to tempfile or error if
+// something failed.
+func WriteFFmpegChaptersTXT(duration mp3duration.Info, chapters []Chapter, opts ...Option) (string, error) {
+	var removeTempfile bool
+	chaptersTXT, err := GetFFmpegChaptersTXT(duration, chapters, opts...)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp("", "*-chapters.txt")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		f.Close()
+		if removeTempfile {
+			os.Remove(f.Name())
+		}
+	}()
+	if _, err := f.Write(chaptersTXT); err != nil {
+		removeTempfile = true
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// Common ffmetadata chapter TIMEBASE denominators for WithTimebase.
+const (
+	TimebaseMillis = 1000  // milliseconds, the default
+	Timebase44100  = 44100 // CD audio samples
+	Timebase48000  = 48000 // DAT/video audio samples
+	TimebaseMPEGTS = 90000 // MPEG-TS 90 kHz clock
+)
+
+// WithTimebase sets the chapter TIMEBASE of the ffmetadata writers
+// to 1/denominator and emits START and END in that base. The default
+// is TimebaseMillis (1/1000).
+func WithTimebase(denominator int64) Option {
+	return func(o *options) {
+		o.timebase = denominator
+	}
+}
+
+// DefaultFFmetadataKeys is the order in which GetFFmpegMetadata and
+// WriteFFmpegMetadataFile emit global keys unless WithFFmetadataKeys
+// or WithFFmetadataDateFirst is given. season_number and episode_sort
+// are what the ffmpeg mp4 muxer writes as the iTunes tvsn and tves
+// atoms.
+var DefaultFFmetadataKeys = []string{
+	"title",
+	"album",
+	"artist",
+	"album_artist",
+	"composer",
+	"grouping",
+	"genre",
+	"track",
+	"disc",
+	"season_number",
+	"episode_sort",
+	"comment",
+	"language",
+	"description",
+	"copyright",
+	"date",
+}
+
+// WithFFmetadataKeys sets which global keys (see
+// DefaultFFmetadataKeys) the ffmetadata writers emit and in which
+// order. Keys not listed are left out, unknown keys are ignored.
+func WithFFmetadataKeys(keys ...string) Option {
+	return func(o *options) {
+		o.ffmetadataKeys = keys
+	}
+}
+
+// WithFFmetadataDateFirst moves the date key (if included) first in
+// the ffmetadata output, keeping the order of the remaining keys.
+func WithFFmetadataDateFirst() Option {
+	return func(o *options) {
+		o.ffmetadataDateFirst = true
+	}
+}
+
+// WithSynthesizedCopyright controls whether the ffmetadata writers
+// emit an auto-generated "Copyright YEAR Artist" line when
+// TrackInfo.Copyright is empty, enabled by default.
+func WithSynthesizedCopyright(enabled bool) Option {
+	return func(o *options) {
+		o.noSynthesizedCopyright = !enabled
+	}
+}
+
+// WriteFFmpegMetadataFile returns a temporary (os.CreateTemp)
+// ffmpeg-compatible metadata file for use with illustrative example:
+//
+//	ffmpeg -i input.flac output.m4a
+//	ffmpeg -i output.m4a -i metadata.txt -map_metadata 1 -codec copy final_output.m4a
+//
+// Returns full path to tempfile or error if something failed.
+func WriteFFmpegMetadataFile(duration time.Duration, input TrackInfo, opts ...Option) (string, error) {
+	var removeTempfile bool
+	output, err := GetFFmpegMetadata(duration, input, opts...)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp("", "*-ffmetadata.txt")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		f.Close()
+		if removeTempfile {
+			os.Remove(f.Name())
+		}
+	}()
+	if _, err := f.Write(output); err != nil {
+		removeTempfile = true
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// GetFFmpegMetadata returns the ffmpeg-compatible metadata file
+// written by WriteFFmpegMetadataFile as a byte slice or error if
+// something failed.
+func GetFFmpegMetadata(duration time.Duration, input TrackInfo, opts ...Option) ([]byte, error) {
+	o := newOptions(opts...)
+	if err := o.resolveYear(&input); err != nil {
+		return nil, err
+	}
+	var b FFMetadataBuilder
+	o.addFFmetadataKeys(&b, input, DefaultFFmetadataKeys)
+	if err := o.addFFmetadataChapters(&b, duration, input.Chapters); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// AlbumFFmetadataKeys and TrackFFmetadataKeys split
+// DefaultFFmetadataKeys into the keys describing a whole album (or
+// audiobook) and the keys describing a single track of it.
+var (
+	AlbumFFmetadataKeys = []string{
+		"title",
+		"album",
+		"artist",
+		"album_artist",
+		"composer",
+		"grouping",
+		"genre",
+		"disc",
+		"comment",
+		"language",
+		"description",
+		"copyright",
+		"date",
+	}
+	TrackFFmetadataKeys = []string{
+		"title",
+		"track",
+	}
+)
+
+// AlbumTrack is one input of a multi-file build, see
+// GetFFmpegAlbumMetadata.
+type AlbumTrack struct {
+	Info     TrackInfo
+	Duration time.Duration
+}
+
+// GetFFmpegAlbumMetadata returns an ffmetadata file for a container
+// built by concatenating tracks, e.g an m4b audiobook made from one
+// file per chapter. The global keys (AlbumFFmetadataKeys unless
+// WithFFmetadataKeys is given) are emitted once from album, with the
+// global title defaulting to album.Album, and every track becomes a
+// chapter titled by its own title (or "Track N") spanning its
+// duration. Chapters of the individual tracks are not included.
+func GetFFmpegAlbumMetadata(album TrackInfo, tracks []AlbumTrack, opts ...Option) ([]byte, error) {
+	o := newOptions(opts...)
+	if len([]rune(album.Title)) == 0 {
+		album.Title = album.Album
+	}
+	if err := o.resolveYear(&album); err != nil {
+		return nil, err
+	}
+	var b FFMetadataBuilder
+	o.addFFmetadataKeys(&b, album, AlbumFFmetadataKeys)
+	chapters := make([]Chapter, len(tracks))
+	var total time.Duration
+	for i, track := range tracks {
+		if track.Duration <= 0 {
+			return nil, ErrZeroDuration
+		}
+		title := track.Info.Title
+		if len([]rune(title)) == 0 {
+			title = fmt.Sprintf("Track %d", i+1)
+		}
+		chapters[i] = Chapter{
+			Title: title,
+			Start: MillisToStringTime(uint32(total / time.Millisecond)),
+		}
+		total += track.Duration
+	}
+	if err := o.addFFmetadataChapters(&b, total, chapters); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// GetFFmpegTrackMetadata is GetFFmpegMetadata limited to the
+// TrackFFmetadataKeys (unless WithFFmetadataKeys is given), for the
+// per-input metadata of a multi-file build whose album level keys
+// come from GetFFmpegAlbumMetadata.
+func GetFFmpegTrackMetadata(duration time.Duration, input TrackInfo, opts ...Option) ([]byte, error) {
+	o := newOptions(opts...)
+	if o.ffmetadataKeys == nil {
+		opts = append([]Option{WithFFmetadataKeys(TrackFFmetadataKeys...)}, opts...)
+	}
+	return GetFFmpegMetadata(duration, input, opts...)
+}
+
+// addFFmetadataKeys adds the global keys of input to b in the order
+// given by the options, or defaultKeys.
+func (o *options) addFFmetadataKeys(b *FFMetadataBuilder, input TrackInfo, defaultKeys []string) {
+	values := map[string]string{
+		"title":         input.Title,
+		"album":         input.Album,
+		"artist":        input.Artist,
+		"album_artist":  input.AlbumArtist,
+		"composer":      input.Composer,
+		"grouping":      input.Grouping,
+		"disc":          input.Disc,
+		"genre":         input.Genre,
+		"track":         input.Track,
+		"season_number": input.Season,
+		"episode_sort":  input.Episode,
+		"comment":       input.Comment,
+		"language":      input.Language,
+		"description":   input.Description,
+	}
+	if len([]rune(input.Copyright)) > 0 {
+		values["copyright"] = input.Copyright
+	} else if !o.noSynthesizedCopyright {
+		values["copyright"] = synthesizeCopyright(input)
+	}
+	if !input.Date.IsZero() {
+		values["date"] = formatDate(input.Date)
+	}
+	for _, k := range o.ffmetadataKeyOrder(defaultKeys) {
+		if v := values[k]; len([]rune(v)) > 0 {
+			b.AddKV(k, v)
+		}
+	}
+}
+
+// synthesizeCopyright returns "Copyright YEAR Artist" from the Date
+// (or Year) and Artist of input, or an empty string if the year is
+// unknown.
+func synthesizeCopyright(input TrackInfo) string {
+	year := input.Year
+	if !input.Date.IsZero() {
+		year = formatDate(input.Date)[:4]
+	}
+	if len([]rune(year)) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprintf("Copyright %s %s", year, input.Artist))
+}
+
+// ffmetadataKeyOrder returns the global keys to emit in order.
+func (o *options) ffmetadataKeyOrder(defaultKeys []string) []string {
+	keys := defaultKeys
+	if o.ffmetadataKeys != nil {
+		keys = o.ffmetadataKeys
+	}
+	if !o.ffmetadataDateFirst {
+		return keys
+	}
+	ordered := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if k == "date" {
+			ordered = append([]string{k}, ordered...)
+		} else {
+			ordered = append(ordered, k)
+		}
+	}
+	return ordered
+}
+
+// FFMetadataHeader is the first line of an ffmpeg metadata file.
+const FFMetadataHeader = ";FFMETADATA1\n"
+
+// FFMetadataBuilder composes an ffmpeg metadata file incrementally,
+// the zero value is an empty file with only the FFMetadataHeader.
+// Values have line feeds removed, surrounding white space trimmed and
+// the special characters \, =, ; and # escaped with a backslash, as
+// ffmpeg expects.
+type FFMetadataBuilder struct {
+	buf []byte
+}
+
+// AddKV adds key=value. Keys added before the first AddChapter are
+// global, keys added after belong to the last chapter.
+func (b *FFMetadataBuilder) AddKV(key, value string) {
+	b.init()
+	clean := strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\r' {
+			return -1 // remove linefeeds
+		}
+		return r
+	}, value)
+	b.buf = append(b.buf, []byte(key+"="+ffmetadataEscaper.Replace(strings.TrimSpace(clean))+"\n")...)
+}
+
+// ffmetadataEscaper escapes the characters of ffmetadata values that
+// ffmpeg would otherwise read as syntax, see cutFFmetadataKV.
+var ffmetadataEscaper = strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`)
+
+// AddChapter adds a [CHAPTER] section from start to end in units of
+// 1/timebase seconds titled title.
+func (b *FFMetadataBuilder) AddChapter(timebase, start, end int64, title string) {
+	b.init()
+	b.buf = append(b.buf, []byte(fmt.Sprintf("\n[CHAPTER]\nTIMEBASE=1/%d\nSTART=%d\nEND=%d\n", timebase, start, end))...)
+	b.AddKV("title", title)
+}
+
+// Bytes returns the metadata file built so far.
+func (b *FFMetadataBuilder) Bytes() []byte {
+	b.init()
+	return b.buf
+}
+
+func (b *FFMetadataBuilder) init() {
+	if b.buf == nil {
+		b.buf = []byte(FFMetadataHeader)
+	}
+}
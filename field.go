@@ -0,0 +1,114 @@
+package id3v24
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+var (
+	ErrUnsupportedField error = errors.New("frame can not be set as a single field")
+	ErrBadFieldValue    error = errors.New("bad field value")
+)
+
+var (
+	frameIDPattern   = regexp.MustCompile(`^[A-Z][A-Z0-9]{3}$`)
+	positionPattern  = regexp.MustCompile(`^[0-9]+(/[0-9]+)?$`)
+	timestampPattern = regexp.MustCompile(`^[0-9]{4}(-[0-9]{2}(-[0-9]{2}(T[0-9]{2}(:[0-9]{2}(:[0-9]{2})?)?)?)?)?$`)
+	languagePattern  = regexp.MustCompile(`^[a-zA-Z]{3}$`)
+)
+
+// SetField sets the single frame frameID of mp3file to value, e.g
+// SetField("episode.mp3", "TIT2", "Episode 1"), keeping the rest of
+// the tag and the audio as is. An empty value removes the frame.
+// Supported are the text frames (T*** except TXXX), the URL frames
+// (W*** except WXXX) and COMM, which sets the comment without a
+// description. value is validated for the frame:
+//
+//   - TRCK and TPOS: a number, optionally of a total, e.g 3 or 3/12
+//   - TBPM and TLEN: a number
+//   - TDRC, TDRL, TDOR, TDEN, TDTG and TYER: a timestamp, e.g 2024 or
+//     2024-09-17T10:00
+//   - TLAN: an ISO 639-2 code, e.g eng
+//   - W***: an absolute URL
+//
+// Errors are ErrUnsupportedField or ErrBadFieldValue for frames and
+// values that can not be set. Use WriteID3v2Tag to set several fields
+// at once.
+func SetField(mp3file, frameID, value string, opts ...Option) error {
+	o := newOptions(opts...)
+	if err := validateField(frameID, value); err != nil {
+		return err
+	}
+	return updateLeadingTag(o, mp3file, func(tag *id3v2.Tag) error {
+		if o.textEncoding != nil {
+			tag.SetDefaultEncoding(o.textEncoding.id3v2Encoding())
+		}
+		switch {
+		case frameID == "COMM":
+			var keep []id3v2.CommentFrame
+			for _, f := range tag.GetFrames("COMM") {
+				if cf, ok := f.(id3v2.CommentFrame); ok && cf.Description != "" {
+					keep = append(keep, cf)
+				}
+			}
+			tag.DeleteFrames("COMM")
+			for _, cf := range keep {
+				tag.AddCommentFrame(cf)
+			}
+			if value != "" {
+				AddComment(tag, "", "", value)
+			}
+		case frameID[0] == 'W':
+			tag.DeleteFrames(frameID)
+			if value != "" {
+				tag.AddFrame(frameID, id3v2.UnknownFrame{Body: []byte(value)})
+			}
+		case value == "":
+			tag.DeleteFrames(frameID)
+		default:
+			tag.AddTextFrame(frameID, tag.DefaultEncoding(), value)
+		}
+		return nil
+	})
+}
+
+// validateField returns an error if SetField can not set frameID to
+// value.
+func validateField(frameID, value string) error {
+	if !frameIDPattern.MatchString(frameID) {
+		return fmt.Errorf("%w: %q is not a frame ID", ErrUnsupportedField, frameID)
+	}
+	if frameID == "TXXX" || frameID == "WXXX" || (frameID[0] != 'T' && frameID[0] != 'W' && frameID != "COMM") {
+		return fmt.Errorf("%w: %s", ErrUnsupportedField, frameID)
+	}
+	if value == "" {
+		return nil
+	}
+	var ok bool
+	switch frameID {
+	case "TRCK", "TPOS":
+		ok = positionPattern.MatchString(value)
+	case "TBPM", "TLEN":
+		ok = strings.Trim(value, "0123456789") == ""
+	case "TDRC", "TDRL", "TDOR", "TDEN", "TDTG", "TYER":
+		ok = timestampPattern.MatchString(value)
+	case "TLAN":
+		ok = languagePattern.MatchString(value)
+	default:
+		if frameID[0] == 'W' {
+			u, err := url.Parse(value)
+			ok = err == nil && u.Scheme != "" && (u.Host != "" || u.Opaque != "")
+		} else {
+			ok = true
+		}
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s %q", ErrBadFieldValue, frameID, value)
+	}
+	return nil
+}
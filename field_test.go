@@ -0,0 +1,49 @@
+package id3v24
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSetField(t *testing.T) {
+	name := copyTestMP3(t)
+	if err := WriteID3v2Tag(name, TrackInfo{Title: "Old", Artist: "Artist", Chapters: []Chapter{{Title: "Intro", Start: "0"}}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range [][2]string{
+		{"TIT2", "New"},
+		{"TRCK", "3/12"},
+		{"TDRC", "2024-09-17"},
+		{"WCOP", "https://creativecommons.org/licenses/by/4.0/"},
+		{"COMM", "A comment"},
+		{"TPE1", ""},
+	} {
+		if err := SetField(name, field[0], field[1]); err != nil {
+			t.Fatalf("%s: %v", field[0], err)
+		}
+	}
+	input, err := ReadID3v2Tag(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.Title != "New" || input.Track != "3/12" || input.Date.Format("2006-01-02") != "2024-09-17" || input.Comment != "A comment" || input.Artist != "" {
+		t.Errorf("unexpected title %q, track %q, date %v, comment %q or artist %q", input.Title, input.Track, input.Date, input.Comment, input.Artist)
+	}
+	if input.CopyrightURL != "https://creativecommons.org/licenses/by/4.0/" {
+		t.Errorf("expected the WCOP URL, got %q", input.CopyrightURL)
+	}
+	if len(input.Chapters) != 1 || input.Chapters[0].Title != "Intro" {
+		t.Errorf("expected the chapters to be kept, got %v", input.Chapters)
+	}
+
+	for _, field := range [][2]string{{"TXXX", "x"}, {"APIC", "x"}, {"tit2", "x"}, {"CHAP", ""}} {
+		if err := SetField(name, field[0], field[1]); !errors.Is(err, ErrUnsupportedField) {
+			t.Errorf("%s: expected %v, got %v", field[0], ErrUnsupportedField, err)
+		}
+	}
+	for _, field := range [][2]string{{"TRCK", "three"}, {"TDRC", "17 Sep 2024"}, {"TLAN", "english"}, {"WOAS", "example.com"}, {"TBPM", "12.5"}} {
+		if err := SetField(name, field[0], field[1]); !errors.Is(err, ErrBadFieldValue) {
+			t.Errorf("%s %q: expected %v, got %v", field[0], field[1], ErrBadFieldValue, err)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package id3v24
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var ErrNotFLAC error = errors.New("not a FLAC stream")
+
+// FLAC metadata block types replaced by WriteFLACTagTo.
+const (
+	flacBlockPadding       = 1
+	flacBlockVorbisComment = 4
+	flacBlockPicture       = 6
+)
+
+// WriteFLACTag writes input as Vorbis comments (see VorbisComments)
+// and a PICTURE block to the FLAC file flacfile, replacing existing
+// comments, pictures and padding. The file will be modified.
+func WriteFLACTag(flacfile string, input TrackInfo, opts ...Option) error {
+	return rewriteFile(newOptions(opts...), flacfile, func(w io.Writer, r io.ReadSeeker) error {
+		return WriteFLACTagTo(w, r, input, opts...)
+	})
+}
+
+// WriteFLACTagTo is the io-only variant of WriteFLACTag, reading the
+// FLAC stream from r and writing the tagged stream to w. A leading
+// ID3v2 tag in r is dropped.
+func WriteFLACTagTo(w io.Writer, r io.Reader, input TrackInfo, opts ...Option) error {
+	o := newOptions(opts...)
+	src := r
+	header := make([]byte, id3v2HeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return ErrNotFLAC
+	}
+	if size := id3v2TagSize(header); size > 0 {
+		if _, err := io.CopyN(io.Discard, r, size-id3v2HeaderSize); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(r, header[:4]); err != nil {
+			return ErrNotFLAC
+		}
+	} else {
+		r = io.MultiReader(bytes.NewReader(header[4:]), r)
+	}
+	if string(header[:4]) != "fLaC" {
+		return ErrNotFLAC
+	}
+	type block struct {
+		kind byte
+		data []byte
+	}
+	var blocks []block
+	var oldComments []string
+	var oldPictures [][]byte
+	vendor := "id3v24"
+	for last := false; !last; {
+		h := make([]byte, 4)
+		if _, err := io.ReadFull(r, h); err != nil {
+			return ErrNotFLAC
+		}
+		last = h[0]&0x80 != 0
+		kind := h[0] & 0x7F
+		data := make([]byte, int(h[1])<<16|int(h[2])<<8|int(h[3]))
+		if _, err := io.ReadFull(r, data); err != nil {
+			return ErrNotFLAC
+		}
+		switch kind {
+		case flacBlockVorbisComment:
+			if v, comments, ok := parseVorbisComments(data); ok {
+				vendor, oldComments = v, comments
+			}
+		case flacBlockPicture:
+			oldPictures = append(oldPictures, data)
+		case flacBlockPadding:
+		default:
+			blocks = append(blocks, block{kind: kind, data: data})
+		}
+	}
+	comments, picture, err := vorbisComments(o, input)
+	if err != nil {
+		return o.fail(MetricErrCover, err)
+	}
+	vc := binary.LittleEndian.AppendUint32(nil, uint32(len(vendor)))
+	vc = append(vc, vendor...)
+	vc = binary.LittleEndian.AppendUint32(vc, uint32(len(comments)))
+	for _, c := range comments {
+		vc = binary.LittleEndian.AppendUint32(vc, uint32(len(c)))
+		vc = append(vc, c...)
+	}
+	blocks = append(blocks, block{kind: flacBlockVorbisComment, data: vc})
+	if picture != nil {
+		blocks = append(blocks, block{kind: flacBlockPicture, data: picture})
+	}
+	// Only the blocks written from input count towards the tag size.
+	sizes := []FrameSize{{ID: "VORBIS_COMMENT", Count: 1, Size: 4 + len(vc)}}
+	if picture != nil {
+		sizes = append(sizes, FrameSize{ID: "PICTURE", Count: 1, Size: 4 + len(picture)})
+	}
+	sortFrameSizes(sizes)
+	size := 0
+	for _, fs := range sizes {
+		size += fs.Size
+	}
+	if err := o.checkTagSize(size, func() []FrameSize { return sizes }); err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	var pictures [][]byte
+	if picture != nil {
+		pictures = append(pictures, picture)
+	}
+	record := o.fieldJournalRecord(src, vorbisFields(oldComments, oldPictures...), vorbisFields(comments, pictures...))
+	out := []byte("fLaC")
+	for i, b := range blocks {
+		if len(b.data) >= 1<<24 {
+			return ErrTagTooLarge
+		}
+		kind := b.kind
+		if i == len(blocks)-1 {
+			kind |= 0x80
+		}
+		out = append(out, kind, byte(len(b.data)>>16), byte(len(b.data)>>8), byte(len(b.data)))
+		out = append(out, b.data...)
+	}
+	if _, err := w.Write(out); err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	if err := o.writeJournal(record); err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	return nil
+}
+
+// WriteTag tags path with input using the writer matching its
+// format (see DetectAudioFormat): WriteID3v2Tag for MP3, WriteFLACTag
+// for native FLAC, WriteOggTag for Ogg Vorbis and Opus, WriteMP4Tag
+// for MPEG-4 and WriteDSFTag for DSF. Other formats, including Ogg
+// FLAC, return an error wrapping ErrUnsupportedFormat and the file is
+// left untouched.
+func WriteTag(path string, input TrackInfo, opts ...Option) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	format, err := DetectAudioFormat(f)
+	if err == nil && format == FormatFLAC && isOggFLAC(f) {
+		err = fmt.Errorf("%w: Ogg FLAC", ErrUnsupportedFormat)
+	}
+	f.Close()
+	if err != nil {
+		return err
+	}
+	switch format {
+	case FormatMP3:
+		return WriteID3v2Tag(path, input, opts...)
+	case FormatFLAC:
+		return WriteFLACTag(path, input, opts...)
+	case FormatOgg, FormatOpus:
+		return WriteOggTag(path, input, opts...)
+	case FormatM4A:
+		return WriteMP4Tag(path, input, opts...)
+	case FormatDSF:
+		return WriteDSFTag(path, input, opts...)
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+// WriteTagTo is the io-only variant of WriteTag, e.g for HTTP uploads
+// or object storage. It tags the audio in r with input using the
+// writer matching its format and writes the result to w. Nothing is
+// written to w if the format is not supported.
+func WriteTagTo(w io.Writer, r io.ReadSeeker, input TrackInfo, opts ...Option) error {
+	format, err := DetectAudioFormat(readSeekerAt{r})
+	if err != nil {
+		return err
+	}
+	if format == FormatFLAC && isOggFLAC(readSeekerAt{r}) {
+		return fmt.Errorf("%w: Ogg FLAC", ErrUnsupportedFormat)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	switch format {
+	case FormatMP3:
+		return WriteID3v2TagTo(w, r, input, opts...)
+	case FormatFLAC:
+		return WriteFLACTagTo(w, r, input, opts...)
+	case FormatOgg, FormatOpus:
+		return WriteOggTagTo(w, r, input, opts...)
+	case FormatM4A:
+		return WriteMP4TagTo(w, r, input, opts...)
+	case FormatDSF:
+		return WriteDSFTagTo(w, r, input, opts...)
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+// readSeekerAt implements io.ReaderAt on an io.ReadSeeker by seeking,
+// it must not be used concurrently.
+type readSeekerAt struct {
+	r io.ReadSeeker
+}
+
+func (r readSeekerAt) ReadAt(p []byte, off int64) (int, error) {
+	if _, err := r.r.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(r.r, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,85 @@
+package id3v24
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// minimalFLAC returns a FLAC stream with a STREAMINFO, a padding
+// block and audio.
+func minimalFLAC(audio []byte) []byte {
+	flac := []byte("fLaC")
+	flac = append(flac, 0x00, 0x00, 0x00, 34)
+	flac = append(flac, make([]byte, 34)...)
+	flac = append(flac, 0x80|flacBlockPadding, 0x00, 0x00, 0x08)
+	flac = append(flac, make([]byte, 8)...)
+	return append(flac, audio...)
+}
+
+func TestWriteFLACTagTo(t *testing.T) {
+	audio := []byte("\xFF\xF8 pretend audio frames")
+	input := TrackInfo{
+		Title:     "Hello world",
+		CoverData: []byte("\xFF\xD8\xFF not really a jpeg"),
+	}
+	var out bytes.Buffer
+	if err := WriteFLACTagTo(&out, bytes.NewReader(minimalFLAC(audio)), input); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	if !bytes.HasPrefix(data, []byte("fLaC")) || !bytes.HasSuffix(data, audio) {
+		t.Fatal("unexpected stream layout")
+	}
+	var kinds []byte
+	rest := data[4:]
+	for {
+		kind, size := rest[0], int(rest[1])<<16|int(rest[2])<<8|int(rest[3])
+		kinds = append(kinds, kind&0x7F)
+		if kind&0x7F == flacBlockVorbisComment {
+			vc := rest[4 : 4+size]
+			vendor := binary.LittleEndian.Uint32(vc)
+			vc = vc[4+vendor:]
+			if n := binary.LittleEndian.Uint32(vc); n != 1 {
+				t.Errorf("expected 1 comment, got %d", n)
+			}
+			if c := string(vc[8:]); c != "TITLE=Hello world" {
+				t.Errorf("expected title comment, got %q", c)
+			}
+		}
+		rest = rest[4+size:]
+		if kind&0x80 != 0 {
+			break
+		}
+	}
+	if !bytes.Equal(kinds, []byte{0, flacBlockVorbisComment, flacBlockPicture}) {
+		t.Errorf("unexpected metadata blocks %v", kinds)
+	}
+	if !bytes.Equal(rest, audio) {
+		t.Error("audio was not copied unmodified")
+	}
+}
+
+func TestWriteTag(t *testing.T) {
+	dir := t.TempDir()
+	flacfile := filepath.Join(dir, "test.flac")
+	if err := os.WriteFile(flacfile, minimalFLAC(nil), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTag(flacfile, TrackInfo{Title: "Hello world"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTag(copyTestMP3(t), TrackInfo{Title: "Hello world"}); err != nil {
+		t.Fatal(err)
+	}
+	wavfile := filepath.Join(dir, "test.wav")
+	if err := os.WriteFile(wavfile, []byte("RIFF\x24\x00\x00\x00WAVEfmt "), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTag(wavfile, TrackInfo{Title: "Hello world"}); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
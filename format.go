@@ -0,0 +1,97 @@
+package id3v24
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrUnsupportedFormat error = errors.New("unsupported audio format")
+
+// AudioFormat is the container (or, for Ogg, codec) of an audio file
+// as returned by DetectAudioFormat.
+type AudioFormat string
+
+const (
+	FormatMP3   AudioFormat = "mp3"
+	FormatM4A   AudioFormat = "m4a"   // MPEG-4 audio, including m4b
+	FormatOgg   AudioFormat = "ogg"   // Ogg Vorbis
+	FormatOpus  AudioFormat = "opus"  // Ogg Opus
+	FormatSpeex AudioFormat = "speex" // Ogg Speex
+	FormatFLAC  AudioFormat = "flac"  // native or Ogg FLAC
+	FormatWAV   AudioFormat = "wav"
+	FormatDSF   AudioFormat = "dsf" // Sony DSD stream file
+)
+
+// DetectAudioFormat sniffs the first bytes of r (after a leading
+// ID3v2 tag) and returns the audio format, so callers can dispatch
+// mixed-format directories to the right tag writer. Ogg streams are
+// reported by codec. Returns ErrUnsupportedFormat for anything else,
+// including Ogg streams with codecs not listed above, so they can be
+// passed through untouched.
+func DetectAudioFormat(r io.ReaderAt) (AudioFormat, error) {
+	header, err := audioHeader(r)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case hasPrefixAt(header, 0, "fLaC"):
+		return FormatFLAC, nil
+	case hasPrefixAt(header, 0, "OggS"):
+		// The first packet starts after the 27 byte page header and
+		// the segment table.
+		if len(header) < 27 {
+			return "", ErrUnsupportedFormat
+		}
+		packet := 27 + int(header[26])
+		switch {
+		case hasPrefixAt(header, packet, "\x01vorbis"):
+			return FormatOgg, nil
+		case hasPrefixAt(header, packet, "OpusHead"):
+			return FormatOpus, nil
+		case hasPrefixAt(header, packet, "Speex   "):
+			return FormatSpeex, nil
+		case hasPrefixAt(header, packet, "\x7fFLAC"):
+			return FormatFLAC, nil
+		}
+	case hasPrefixAt(header, 0, "DSD ") && hasPrefixAt(header, 28, "fmt "):
+		return FormatDSF, nil
+	case hasPrefixAt(header, 0, "RIFF") && hasPrefixAt(header, 8, "WAVE"):
+		return FormatWAV, nil
+	case hasPrefixAt(header, 4, "ftyp"):
+		return FormatM4A, nil
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0 && header[1]&0x06 != 0:
+		// MPEG audio frame sync with a layer set (ADTS AAC has layer 0).
+		return FormatMP3, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// audioHeader returns the first bytes of r after any leading ID3v2
+// tag.
+func audioHeader(r io.ReaderAt) ([]byte, error) {
+	header := make([]byte, 64)
+	n, err := r.ReadAt(header, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	header = header[:n]
+	if size := id3v2TagSize(header); size > 0 {
+		n, err = r.ReadAt(header[:cap(header)], size)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		header = header[:n]
+	}
+	return header, nil
+}
+
+// isOggFLAC reports whether r, detected as FormatFLAC, is Ogg FLAC
+// rather than native FLAC.
+func isOggFLAC(r io.ReaderAt) bool {
+	header, err := audioHeader(r)
+	return err == nil && hasPrefixAt(header, 0, "OggS")
+}
+
+func hasPrefixAt(b []byte, offset int, prefix string) bool {
+	return offset >= 0 && len(b) >= offset+len(prefix) && string(b[offset:offset+len(prefix)]) == prefix
+}
@@ -0,0 +1,56 @@
+package id3v24
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestDetectAudioFormat(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tagged bytes.Buffer
+	if err := WriteID3v2TagTo(&tagged, bytes.NewReader(mp3), TrackInfo{Title: "Hello world"}); err != nil {
+		t.Fatal(err)
+	}
+	oggPage := func(packet string) []byte {
+		page := []byte("OggS\x00\x02")
+		page = append(page, make([]byte, 20)...)
+		page = append(page, 0x01, byte(len(packet)))
+		return append(page, packet...)
+	}
+	for _, tc := range []struct {
+		data     []byte
+		expected AudioFormat
+	}{
+		{mp3, FormatMP3},
+		{tagged.Bytes(), FormatMP3},
+		{[]byte("fLaC\x00\x00\x00\x22"), FormatFLAC},
+		{oggPage("\x01vorbis\x00\x00\x00\x00"), FormatOgg},
+		{oggPage("OpusHead\x01\x02"), FormatOpus},
+		{oggPage("Speex   1.2"), FormatSpeex},
+		{[]byte("RIFF\x24\x00\x00\x00WAVEfmt "), FormatWAV},
+		{[]byte("\x00\x00\x00\x20ftypM4B \x00\x00\x00\x00"), FormatM4A},
+	} {
+		format, err := DetectAudioFormat(bytes.NewReader(tc.data))
+		if err != nil {
+			t.Errorf("%s: %v", tc.expected, err)
+			continue
+		}
+		if format != tc.expected {
+			t.Errorf("expected %s, got %s", tc.expected, format)
+		}
+	}
+	for _, data := range [][]byte{
+		oggPage("\x80theora"),
+		[]byte("\xFF\xF1\x50\x80"), // ADTS AAC
+		[]byte("hello"),
+	} {
+		if _, err := DetectAudioFormat(bytes.NewReader(data)); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("%q: expected ErrUnsupportedFormat, got %v", data, err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package id3v24
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// Description of the WXXX frame holding TrackInfo.Funding.
+const FundingDescription = "funding"
+
+// Descriptions of the TXXX frames holding the DJ software fields of
+// TrackInfo, as used by e.g Mixed In Key and rekordbox.
+const (
+	EnergyLevelDescription = "ENERGYLEVEL"
+	ColorDescription       = "COLOR"
+	CuePointsDescription   = "CUEPOINTS"
+)
+
+// Descriptions of the TXXX frames holding TrackInfo.Season and
+// TrackInfo.Episode.
+const (
+	SeasonDescription  = "SEASON"
+	EpisodeDescription = "EPISODE"
+)
+
+// AddUserDefinedURLFrame adds a WXXX (user defined URL link) frame
+// with description and url to tag. The description is encoded as
+// UTF-8 and the url, per the specification, as ISO-8859-1.
+func AddUserDefinedURLFrame(tag *id3v2.Tag, description, url string) {
+	tag.AddFrame("WXXX", id3v2.UnknownFrame{Body: userDefinedURLBody(description, url)})
+}
+
+// AddURLFrame sets the URL link frame id (e.g WOAS) of tag to url,
+// encoded as ISO-8859-1 per the specification, replacing an existing
+// one. Nothing is added if url is empty.
+func AddURLFrame(tag *id3v2.Tag, id, url string) {
+	if url == "" {
+		return
+	}
+	tag.DeleteFrames(id)
+	tag.AddFrame(id, id3v2.UnknownFrame{Body: []byte(url)})
+}
+
+// tagURL returns the URL of the last URL link frame id of tag, or an
+// empty string.
+func tagURL(tag *id3v2.Tag, id string) string {
+	if f, ok := tag.GetLastFrame(id).(id3v2.UnknownFrame); ok {
+		return strings.TrimRight(string(f.Body), "\x00")
+	}
+	return ""
+}
+
+// addLinks adds a WXXX frame to tag for each entry of links, the key
+// as description, in order of key. Entries with an empty URL are
+// skipped, as is the funding entry if funding is set (the Funding
+// field wins).
+func addLinks(tag *id3v2.Tag, links map[string]string, funding bool) {
+	keys := make([]string, 0, len(links))
+	for key := range links {
+		if !(funding && key == FundingDescription) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if links[key] != "" {
+			AddUserDefinedURLFrame(tag, key, links[key])
+		}
+	}
+}
+
+// userDefinedURLBody returns the body of a WXXX frame.
+func userDefinedURLBody(description, url string) []byte {
+	body := []byte{id3v2.EncodingUTF8.Key}
+	body = append(body, []byte(description)...)
+	body = append(body, 0x00)
+	return append(body, []byte(url)...)
+}
+
+// addUserDefinedText adds a TXXX frame with description and value to
+// tag unless value is empty.
+func addUserDefinedText(tag *id3v2.Tag, description, value string) {
+	if len([]rune(value)) == 0 {
+		return
+	}
+	tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+		Encoding:    tag.DefaultEncoding(),
+		Description: description,
+		Value:       value,
+	})
+}
+
+// addCustomText adds a TXXX frame to tag for each entry of custom,
+// the key as description, in order of key. Entries with an empty key
+// or value are skipped.
+func addCustomText(tag *id3v2.Tag, custom map[string]string) {
+	keys := make([]string, 0, len(custom))
+	for key := range custom {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		addUserDefinedText(tag, key, custom[key])
+	}
+}
+
+// isChapterTXXX reports whether description is that of a TXXX frame
+// written by AddChapterTXXX, e.g CHAPTER001 or CHAPTER001NAME.
+func isChapterTXXX(description string) bool {
+	n, ok := strings.CutPrefix(description, "CHAPTER")
+	n = strings.TrimSuffix(n, "NAME")
+	return ok && len(n) == 3 && strings.Trim(n, "0123456789") == ""
+}
+
+// cutEncodedString splits b after the first string terminator of
+// encoding, a single null byte for ISO-8859-1 and UTF-8 and two
+// aligned null bytes for UTF-16. If b has no terminator, text is all
+// of b and ok is false.
+func cutEncodedString(b []byte, encoding byte) (text, rest []byte, ok bool) {
+	if encoding == id3v2.EncodingUTF16.Key || encoding == id3v2.EncodingUTF16BE.Key {
+		for i := 0; i+1 < len(b); i += 2 {
+			if b[i] == 0x00 && b[i+1] == 0x00 {
+				return b[:i], b[i+2:], true
+			}
+		}
+		return b, nil, false
+	}
+	i := bytes.IndexByte(b, 0x00)
+	if i < 0 {
+		return b, nil, false
+	}
+	return b[:i], b[i+1:], true
+}
+
+// decodeText decodes b, encoded as encoding (the ID3v2 text encoding
+// byte), to a string. Trailing null characters are removed.
+func decodeText(b []byte, encoding byte) string {
+	switch encoding {
+	case id3v2.EncodingISO.Key:
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+		return strings.TrimRight(string(runes), "\x00")
+	case id3v2.EncodingUTF16.Key, id3v2.EncodingUTF16BE.Key:
+		var order binary.ByteOrder = binary.BigEndian
+		if len(b) >= 2 && encoding == id3v2.EncodingUTF16.Key {
+			switch {
+			case b[0] == 0xFF && b[1] == 0xFE:
+				order, b = binary.LittleEndian, b[2:]
+			case b[0] == 0xFE && b[1] == 0xFF:
+				b = b[2:]
+			}
+		}
+		units := make([]uint16, len(b)/2)
+		for i := range units {
+			units[i] = order.Uint16(b[2*i:])
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	}
+	return strings.TrimRight(string(b), "\x00")
+}
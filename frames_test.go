@@ -0,0 +1,134 @@
+package id3v24
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+func TestWriteID3v2TagToFunding(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := WriteID3v2TagTo(&out, bytes.NewReader(mp3), TrackInfo{Funding: "https://example.com/donate"}); err != nil {
+		t.Fatal(err)
+	}
+	tag, err := id3v2.ParseReader(bytes.NewReader(out.Bytes()), id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := tag.GetFrames("WXXX")
+	if len(frames) != 1 {
+		t.Fatalf("expected 1 WXXX frame, got %d", len(frames))
+	}
+	expected := []byte("\x03funding\x00https://example.com/donate")
+	if body := frames[0].(id3v2.UnknownFrame).Body; !bytes.Equal(body, expected) {
+		t.Errorf("expected WXXX body %q, got %q", expected, body)
+	}
+}
+
+func TestWriteID3v2TagToDJFields(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := TrackInfo{Mood: "Chill", Energy: "7", Color: "#FF0000"}
+	var out bytes.Buffer
+	if err := WriteID3v2TagTo(&out, bytes.NewReader(mp3), input); err != nil {
+		t.Fatal(err)
+	}
+	tag, err := id3v2.ParseReader(bytes.NewReader(out.Bytes()), id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mood := tag.GetTextFrame("TMOO").Text; mood != input.Mood {
+		t.Errorf("expected TMOO %q, got %q", input.Mood, mood)
+	}
+	txxx := map[string]string{}
+	for _, f := range tag.GetFrames("TXXX") {
+		udtf := f.(id3v2.UserDefinedTextFrame)
+		txxx[udtf.Description] = udtf.Value
+	}
+	if len(txxx) != 2 || txxx[EnergyLevelDescription] != "7" || txxx[ColorDescription] != "#FF0000" {
+		t.Errorf("unexpected TXXX frames %v", txxx)
+	}
+}
+
+func TestAddChapterTXXX(t *testing.T) {
+	tag := id3v2.NewEmptyTag()
+	chapters := []Chapter{
+		{Title: "Chapter 1", Start: "00:00:00"},
+		{Title: "Chapter 2", Start: "00:00:10.5"},
+	}
+	if err := AddChapterTXXX(tag, chapters); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, f := range tag.GetFrames("TXXX") {
+		udtf := f.(id3v2.UserDefinedTextFrame)
+		got[udtf.Description] = udtf.Value
+	}
+	expected := map[string]string{
+		"CHAPTER001":     "00:00:00.000",
+		"CHAPTER001NAME": "Chapter 1",
+		"CHAPTER002":     "00:00:10.500",
+		"CHAPTER002NAME": "Chapter 2",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestSeasonEpisode(t *testing.T) {
+	for _, tc := range []struct {
+		filename, season, episode string
+		ok                        bool
+	}{
+		{"/podcasts/myshow/MyShow.S02E05.mp3", "2", "5", true},
+		{"myshow-s1e12-title.mp3", "1", "12", true},
+		{"My Show S03 E010.mp3", "3", "10", true},
+		{"season2episode5.mp3", "", "", false},
+		{"chess-e4e5.mp3", "", "", false},
+	} {
+		season, episode, ok := InferSeasonEpisode(tc.filename)
+		if season != tc.season || episode != tc.episode || ok != tc.ok {
+			t.Errorf("%s: expected %q %q %v, got %q %q %v", tc.filename, tc.season, tc.episode, tc.ok, season, episode, ok)
+		}
+	}
+	input := ApplySeasonEpisode(TrackInfo{Episode: "6"}, "MyShow.S02E05.mp3")
+	if input.Season != "2" || input.Episode != "6" {
+		t.Errorf("unexpected season %q and episode %q", input.Season, input.Episode)
+	}
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := WriteID3v2TagTo(&out, bytes.NewReader(mp3), input); err != nil {
+		t.Fatal(err)
+	}
+	tag, err := id3v2.ParseReader(bytes.NewReader(out.Bytes()), id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, f := range tag.GetFrames("TXXX") {
+		udtf := f.(id3v2.UserDefinedTextFrame)
+		got[udtf.Description] = udtf.Value
+	}
+	if got[SeasonDescription] != "2" || got[EpisodeDescription] != "6" {
+		t.Errorf("unexpected TXXX frames %v", got)
+	}
+	ffmetadata, err := GetFFmpegMetadata(0, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := ";FFMETADATA1\nseason_number=2\nepisode_sort=6\n"; string(ffmetadata) != expected {
+		t.Errorf("expected %q, got %q", expected, ffmetadata)
+	}
+}
@@ -0,0 +1,93 @@
+package id3v24
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrNoFrontMatter error = errors.New("no YAML front matter (expected a first line of ---)")
+
+// ParseFrontMatter reads the YAML front matter of the markdown
+// document in r, as used by static site generators like Hugo and
+// Jekyll, into a TrackInfo. The front matter is delimited by lines of
+// "---" (the closing line may also be "...") and uses the YAML keys of
+// TrackInfo, e.g:
+//
+//	---
+//	title: Episode 1
+//	date: 2024-05-01
+//	description: The first episode.
+//	chapters:
+//	  - title: Intro
+//	    start: "00:00:00"
+//	---
+//
+// Other keys are ignored. Year is set from Date if empty.
+func ParseFrontMatter(r io.Reader) (TrackInfo, error) {
+	var input TrackInfo
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	if !scanner.Scan() || strings.TrimRight(strings.TrimPrefix(scanner.Text(), "\uFEFF"), " \t\r") != "---" {
+		if err := scanner.Err(); err != nil {
+			return input, err
+		}
+		return input, ErrNoFrontMatter
+	}
+	var buf bytes.Buffer
+	closed := false
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), " \t\r"); line == "---" || line == "..." {
+			closed = true
+			break
+		}
+		buf.WriteString(scanner.Text())
+		buf.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return input, err
+	}
+	if !closed {
+		return input, fmt.Errorf("%w: front matter is not closed by ---", ErrNoFrontMatter)
+	}
+	if err := yaml.Unmarshal(buf.Bytes(), &input); err != nil {
+		return input, err
+	}
+	if input.Year == "" && !input.Date.IsZero() {
+		input.Year = formatDate(input.Date)[:4]
+	}
+	return input, nil
+}
+
+// ReadMarkdownFile reads the YAML front matter of the markdown file
+// path, see ParseFrontMatter, so that an episode can be tagged from
+// the file that renders its page. Relative CoverJPEG and chapter
+// Image paths are resolved against the directory of path.
+func ReadMarkdownFile(path string) (TrackInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return TrackInfo{}, err
+	}
+	defer f.Close()
+	input, err := ParseFrontMatter(f)
+	if err != nil {
+		return input, fmt.Errorf("%s: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	if cover := input.CoverJPEG; cover != "" && !strings.HasPrefix(cover, "data:") && !filepath.IsAbs(cover) {
+		input.CoverJPEG = filepath.Join(dir, cover)
+	}
+	for i, ch := range input.Chapters {
+		if ch.Image != "" && !filepath.IsAbs(ch.Image) && !strings.Contains(ch.Image, "://") {
+			input.Chapters[i].Image = filepath.Join(dir, ch.Image)
+		}
+	}
+	return input, nil
+}
@@ -0,0 +1,73 @@
+package id3v24
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testMarkdown = `---
+title: "Episode 1: The beginning"
+date: 2024-05-01T10:00:00+02:00
+draft: false
+tags: [news]
+description: The first episode.
+coverJPEG: cover.jpg
+chapters:
+  - title: Intro
+    start: "00:00:00"
+  - title: News
+    start: "00:01:30.500"
+    image: images/news.png
+---
+
+# Episode 1
+
+Show notes.
+`
+
+func TestParseFrontMatter(t *testing.T) {
+	input, err := ParseFrontMatter(strings.NewReader(testMarkdown))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.Title != "Episode 1: The beginning" || input.Description != "The first episode." || input.Year != "2024" {
+		t.Errorf("unexpected title %q, description %q or year %q", input.Title, input.Description, input.Year)
+	}
+	expected := []Chapter{
+		{Title: "Intro", Start: "00:00:00"},
+		{Title: "News", Start: "00:01:30.500", Image: "images/news.png"},
+	}
+	if !reflect.DeepEqual(input.Chapters, expected) {
+		t.Errorf("expected %v, got %v", expected, input.Chapters)
+	}
+	for _, doc := range []string{"# No front matter\n", "---\ntitle: Unclosed\n", ""} {
+		if _, err := ParseFrontMatter(strings.NewReader(doc)); !errors.Is(err, ErrNoFrontMatter) {
+			t.Errorf("%q: expected %v, got %v", doc, ErrNoFrontMatter, err)
+		}
+	}
+	if input, err := ParseFrontMatter(strings.NewReader("\uFEFF---\r\ntitle: CRLF\r\n...\r\n")); err != nil || input.Title != "CRLF" {
+		t.Errorf("expected title %q, got %q, %v", "CRLF", input.Title, err)
+	}
+}
+
+func TestReadMarkdownFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "episode-1.md")
+	if err := os.WriteFile(path, []byte(testMarkdown), 0644); err != nil {
+		t.Fatal(err)
+	}
+	input, err := ReadMarkdownFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(dir, "cover.jpg"); input.CoverJPEG != expected {
+		t.Errorf("expected %q, got %q", expected, input.CoverJPEG)
+	}
+	if expected := filepath.Join(dir, "images", "news.png"); input.Chapters[1].Image != expected {
+		t.Errorf("expected %q, got %q", expected, input.Chapters[1].Image)
+	}
+}
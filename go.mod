@@ -6,9 +6,8 @@ require (
 	github.com/bogem/id3v2 v1.2.0
 	github.com/davecgh/go-spew v1.1.1
 	github.com/sa6mwa/mp3duration v0.0.0-20221104103912-0716b1a5de6e
+	github.com/tcolgate/mp3 v0.0.0-20170426193717-e79c5a46d300
+	gopkg.in/yaml.v3 v3.0.1
 )
 
-require (
-	github.com/tcolgate/mp3 v0.0.0-20170426193717-e79c5a46d300 // indirect
-	golang.org/x/text v0.25.0 // indirect
-)
+require golang.org/x/text v0.25.0 // indirect
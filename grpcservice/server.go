@@ -0,0 +1,175 @@
+// Package grpcservice serves the Tagger gRPC service of tagger.proto
+// (TagFile, ReadTag and ConvertChapters) with uploads streamed in
+// chunks, for ingestion pipelines that prefer typed RPC. Clients are
+// generated from tagger.proto as usual, while the server is written
+// against net/http so that neither package id3v24 nor this package
+// depend on the gRPC and protocol buffer modules.
+package grpcservice
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sa6mwa/id3v24"
+)
+
+// DefaultMaxUploadSize is the default of Server.MaxUploadSize.
+const DefaultMaxUploadSize = 1 << 30
+
+// chunkSize is the size of the chunks TagFile streams back.
+const chunkSize = 64 << 10
+
+// Server is an http.Handler serving the Tagger service over HTTP/2,
+// see ListenAndServe.
+type Server struct {
+	Options       []id3v24.Option // passed to the tag writers, e.g id3v24.WithSortChapters()
+	MaxUploadSize int64           // of the audio or chapters of a call, DefaultMaxUploadSize if zero
+}
+
+// ListenAndServe serves the Tagger service of s on the TCP address
+// addr over HTTP/2 without TLS (h2c), as gRPC clients connect with
+// insecure credentials. Use s as the handler of an http.Server for
+// TLS.
+func ListenAndServe(addr string, s *Server) error {
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	srv := &http.Server{Addr: addr, Handler: s, Protocols: &protocols}
+	return srv.ListenAndServe()
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ProtoMajor != 2 || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "gRPC over HTTP/2 only", http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	w.WriteHeader(http.StatusOK)
+	var err error
+	switch r.URL.Path {
+	case "/id3v24.Tagger/TagFile":
+		err = s.tagFile(w, r.Body)
+	case "/id3v24.Tagger/ReadTag":
+		err = s.readTag(w, r.Body)
+	case "/id3v24.Tagger/ConvertChapters":
+		err = s.convertChapters(w, r.Body)
+	default:
+		err = &statusError{code: codeUnimplemented, message: "unknown method " + r.URL.Path}
+	}
+	code := codeOK
+	if err != nil {
+		code = codeUnknown
+		var status *statusError
+		if errors.As(err, &status) {
+			code = status.code
+		}
+		w.Header().Set("Grpc-Message", percentEncode(err.Error()))
+	}
+	w.Header().Set("Grpc-Status", strconv.Itoa(code))
+}
+
+func (s *Server) maxUploadSize() int64 {
+	if s.MaxUploadSize > 0 {
+		return s.MaxUploadSize
+	}
+	return DefaultMaxUploadSize
+}
+
+// upload reads the request messages of r, passing the first to first
+// (if not nil), and returns field number of them all concatenated.
+func (s *Server) upload(r io.Reader, number int, first func(fields) error) ([]byte, error) {
+	var data []byte
+	for n := 0; ; n++ {
+		msg, err := readMessage(r, s.maxUploadSize()-int64(len(data))+16)
+		if err == io.EOF {
+			return data, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		f, err := parseMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 && first != nil {
+			if err := first(f); err != nil {
+				return nil, err
+			}
+		}
+		data = append(data, f.bytes[number]...)
+		if int64(len(data)) > s.maxUploadSize() {
+			return nil, &statusError{code: codeResourceExhausted, message: "upload too large"}
+		}
+	}
+}
+
+func (s *Server) tagFile(w http.ResponseWriter, r io.Reader) error {
+	var input id3v24.TrackInfo
+	audio, err := s.upload(r, 2, func(f fields) error {
+		if len(f.bytes[1]) == 0 {
+			return invalidArgument("the first TagFileRequest has no track_info_json")
+		}
+		if err := json.Unmarshal(f.bytes[1], &input); err != nil {
+			return invalidArgument("track_info_json: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	var tagged bytes.Buffer
+	if err := id3v24.WriteTagTo(&tagged, bytes.NewReader(audio), input, s.Options...); err != nil {
+		return invalidArgument("%v", err)
+	}
+	for b := tagged.Bytes(); len(b) > 0; {
+		n := min(len(b), chunkSize)
+		if err := writeMessage(w, appendBytes(nil, 1, b[:n])); err != nil {
+			return err
+		}
+		w.(http.Flusher).Flush()
+		b = b[n:]
+	}
+	return nil
+}
+
+func (s *Server) readTag(w http.ResponseWriter, r io.Reader) error {
+	audio, err := s.upload(r, 1, nil)
+	if err != nil {
+		return err
+	}
+	info, err := id3v24.ReadID3v2TagFrom(bytes.NewReader(audio))
+	if err != nil {
+		return invalidArgument("%v", err)
+	}
+	output, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return writeMessage(w, appendBytes(nil, 1, output))
+}
+
+func (s *Server) convertChapters(w http.ResponseWriter, r io.Reader) error {
+	msg, err := readMessage(r, s.maxUploadSize())
+	if err == io.EOF {
+		return invalidArgument("no ConvertChaptersRequest")
+	}
+	if err != nil {
+		return err
+	}
+	f, err := parseMessage(msg)
+	if err != nil {
+		return err
+	}
+	var output bytes.Buffer
+	duration := time.Duration(int64(f.varints[4])) * time.Millisecond
+	if err := id3v24.ConvertChapters(&output, bytes.NewReader(f.bytes[3]), string(f.bytes[1]), string(f.bytes[2]), duration); err != nil {
+		return invalidArgument("%v", err)
+	}
+	return writeMessage(w, appendBytes(nil, 1, output.Bytes()))
+}
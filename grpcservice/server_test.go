@@ -0,0 +1,119 @@
+package grpcservice
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sa6mwa/id3v24"
+)
+
+// call sends the messages to method of the Tagger service at url and
+// returns the response messages, grpc-status and grpc-message.
+func call(t *testing.T, client *http.Client, url, method string, messages ...[]byte) ([]fields, string, string) {
+	t.Helper()
+	var body bytes.Buffer
+	for _, msg := range messages {
+		if err := writeMessage(&body, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(http.MethodPost, url+"/id3v24.Tagger/"+method, &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var responses []fields
+	for {
+		msg, err := readMessage(resp.Body, 1<<30)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := parseMessage(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, f)
+	}
+	return responses, resp.Trailer.Get("Grpc-Status"), resp.Trailer.Get("Grpc-Message")
+}
+
+func TestServer(t *testing.T) {
+	ts := httptest.NewUnstartedServer(&Server{})
+	ts.Config.Protocols = new(http.Protocols)
+	ts.Config.Protocols.SetUnencryptedHTTP2(true)
+	ts.Start()
+	defer ts.Close()
+	client := &http.Client{Transport: &http.Transport{Protocols: new(http.Protocols)}}
+	client.Transport.(*http.Transport).Protocols.SetUnencryptedHTTP2(true)
+
+	audio, err := os.ReadFile("../testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := appendBytes(nil, 1, []byte(`{"title":"Hello world"}`))
+	first = appendBytes(first, 2, audio[:len(audio)/2])
+	responses, status, message := call(t, client, ts.URL, "TagFile", first, appendBytes(nil, 2, audio[len(audio)/2:]))
+	if status != "0" {
+		t.Fatalf("TagFile: expected status 0, got %q: %s", status, message)
+	}
+	var tagged []byte
+	for _, f := range responses {
+		tagged = append(tagged, f.bytes[1]...)
+	}
+	info, err := id3v24.ReadID3v2TagFrom(bytes.NewReader(tagged))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Title != "Hello world" {
+		t.Errorf("expected title %q, got %q", "Hello world", info.Title)
+	}
+
+	responses, status, message = call(t, client, ts.URL, "ReadTag", appendBytes(nil, 1, tagged))
+	if status != "0" || len(responses) != 1 {
+		t.Fatalf("ReadTag: expected status 0 and one response, got %q and %d: %s", status, len(responses), message)
+	}
+	var read id3v24.TrackInfo
+	if err := json.Unmarshal(responses[0].bytes[1], &read); err != nil {
+		t.Fatal(err)
+	}
+	if read.Title != "Hello world" {
+		t.Errorf("expected title %q, got %q", "Hello world", read.Title)
+	}
+
+	_, status, _ = call(t, client, ts.URL, "TagFile", appendBytes(nil, 2, audio))
+	if status != "3" {
+		t.Errorf("expected status 3 without track_info_json, got %q", status)
+	}
+
+	cue := "FILE \"a.mp3\" MP3\n  TRACK 01 AUDIO\n    TITLE \"Intro\"\n    INDEX 01 00:00:00\n  TRACK 02 AUDIO\n    TITLE \"Outro\"\n    INDEX 01 01:00:00\n"
+	req := appendBytes(nil, 1, []byte("cue"))
+	req = appendBytes(req, 2, []byte("json"))
+	req = appendBytes(req, 3, []byte(cue))
+	req = appendVarint(req, 4, 120000)
+	responses, status, message = call(t, client, ts.URL, "ConvertChapters", req)
+	if status != "0" || len(responses) != 1 {
+		t.Fatalf("ConvertChapters: expected status 0 and one response, got %q and %d: %s", status, len(responses), message)
+	}
+	if out := string(responses[0].bytes[1]); !strings.Contains(out, "Intro") || !strings.Contains(out, "Outro") {
+		t.Errorf("unexpected chapters %s", out)
+	}
+
+	if _, status, _ = call(t, client, ts.URL, "Unknown"); status != "12" {
+		t.Errorf("expected status 12 for an unknown method, got %q", status)
+	}
+}
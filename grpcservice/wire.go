@@ -0,0 +1,152 @@
+package grpcservice
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Protocol buffer wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// fields is a decoded protocol buffer message, the last value of each
+// field by number.
+type fields struct {
+	bytes   map[int][]byte
+	varints map[int]uint64
+}
+
+// parseMessage decodes the protocol buffer message b. Fields of
+// unknown wire types fail, fixed size ones are skipped.
+func parseMessage(b []byte) (fields, error) {
+	f := fields{bytes: map[int][]byte{}, varints: map[int]uint64{}}
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return f, invalidArgument("malformed message")
+		}
+		b = b[n:]
+		number := int(key >> 3)
+		switch key & 7 {
+		case wireVarint:
+			v, n := binary.Uvarint(b)
+			if n <= 0 {
+				return f, invalidArgument("malformed message")
+			}
+			f.varints[number], b = v, b[n:]
+		case wireBytes:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || size > uint64(len(b)-n) {
+				return f, invalidArgument("malformed message")
+			}
+			f.bytes[number], b = b[n:n+int(size)], b[n+int(size):]
+		case wireFixed64:
+			if len(b) < 8 {
+				return f, invalidArgument("malformed message")
+			}
+			b = b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return f, invalidArgument("malformed message")
+			}
+			b = b[4:]
+		default:
+			return f, invalidArgument("malformed message")
+		}
+	}
+	return f, nil
+}
+
+// appendBytes appends field number with v to the message b, unless v
+// is empty (the default).
+func appendBytes(b []byte, number int, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = binary.AppendUvarint(b, uint64(number)<<3|wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// appendVarint appends field number with v to the message b, unless v
+// is zero (the default).
+func appendVarint(b []byte, number int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = binary.AppendUvarint(b, uint64(number)<<3|wireVarint)
+	return binary.AppendUvarint(b, v)
+}
+
+// readMessage reads the next length-prefixed gRPC message of at most
+// max bytes from r. Returns io.EOF at the end of r.
+func readMessage(r io.Reader, max int64) ([]byte, error) {
+	header := make([]byte, 5)
+	if n, err := io.ReadFull(r, header); err != nil {
+		if n == 0 && err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, invalidArgument("truncated message")
+	}
+	if header[0] != 0 {
+		return nil, &statusError{code: codeUnimplemented, message: "compressed messages are not supported"}
+	}
+	size := int64(binary.BigEndian.Uint32(header[1:]))
+	if size > max {
+		return nil, &statusError{code: codeResourceExhausted, message: fmt.Sprintf("message of %d bytes, the limit is %d", size, max)}
+	}
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, invalidArgument("truncated message")
+	}
+	return msg, nil
+}
+
+// writeMessage writes msg to w as a length-prefixed gRPC message.
+func writeMessage(w io.Writer, msg []byte) error {
+	header := []byte{0}
+	header = binary.BigEndian.AppendUint32(header, uint32(len(msg)))
+	_, err := w.Write(append(header, msg...))
+	return err
+}
+
+// gRPC status codes.
+const (
+	codeOK                = 0
+	codeUnknown           = 2
+	codeInvalidArgument   = 3
+	codeResourceExhausted = 8
+	codeUnimplemented     = 12
+)
+
+// statusError is an error with a gRPC status code.
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+func invalidArgument(format string, a ...any) error {
+	return &statusError{code: codeInvalidArgument, message: fmt.Sprintf(format, a...)}
+}
+
+// percentEncode encodes s as the value of a grpc-message trailer.
+func percentEncode(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c >= ' ' && c <= '~' && c != '%' {
+			b = append(b, c)
+		} else {
+			b = fmt.Appendf(b, "%%%02X", c)
+		}
+	}
+	return string(b)
+}
@@ -1,10 +1,13 @@
 package id3v24
 
 import (
-	"bytes"
+	"encoding/base64"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
+	"math"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
@@ -15,63 +18,171 @@ import (
 )
 
 var (
-	ErrBadChapterStartTime error = errors.New("bad chapter start time format (expected HH:MM:SS.mmm)")
-	ErrZeroDuration        error = errors.New("duration can not be zero")
+	ErrBadChapterStartTime   error = errors.New("bad chapter start time format (expected HH:MM:SS.mmm, MM:SS, seconds or a duration like 1h5m30s)")
+	ErrBadChapterEndTime     error = errors.New("bad chapter end time (expected HH:MM:SS.mmm after start)")
+	ErrZeroDuration          error = errors.New("duration can not be zero")
+	ErrChapterBeyondDuration error = errors.New("chapter starts at or after the end of the audio")
+	ErrBadDataURI            error = errors.New("bad data URI (expected data:<mime type>;base64,<data>)")
+	ErrBadFrame              error = errors.New("malformed frame")
+	ErrBadSeratoMarkers      error = errors.New("malformed Serato Markers2 data")
+	ErrBadTraktorCues        error = errors.New("malformed Traktor cue data")
+	ErrTagTooLarge           error = errors.New("tag too large")
 )
 
 type TrackInfo struct {
-	Title       string    `json:"title" yaml:"title,omitempty"`
-	Album       string    `json:"album" yaml:"album,omitempty"`
-	Artist      string    `json:"artist" yaml:"artist,omitempty"`
-	Genre       string    `json:"genre" yaml:"genre,omitempty"`
-	Year        string    `json:"year" yaml:"year,omitempty"`
-	Date        time.Time `json:"date" yaml:"date,omitempty"` // yyyy-mm-dd
-	Track       string    `json:"track" yaml:"track,omitempty"`
-	Comment     string    `json:"comment" yaml:"comment,omitempty"`
-	Description string    `json:"description" yaml:"description,omitempty"`
-	Language    string    `json:"language" yaml:"language,omitempty"`
-	Copyright   string    `json:"copyright" yaml:"copyright,omitempty"`
-	CoverJPEG   string    `json:"coverJPEG" yaml:"coverJPEG,omitempty"`
-	Chapters    []Chapter `json:"chapters" yaml:"chapters,omitempty"`
+	Title         string            `json:"title" yaml:"title,omitempty"`
+	Album         string            `json:"album" yaml:"album,omitempty"`
+	Artist        string            `json:"artist" yaml:"artist,omitempty"`
+	AlbumArtist   string            `json:"albumArtist" yaml:"albumArtist,omitempty"` // TPE2, e.g the narrator of every part of an audiobook
+	Composer      string            `json:"composer" yaml:"composer,omitempty"`       // TCOM
+	Grouping      string            `json:"grouping" yaml:"grouping,omitempty"`       // TIT1 and, for iTunes, GRP1
+	Genre         string            `json:"genre" yaml:"genre,omitempty"`
+	Year          string            `json:"year" yaml:"year,omitempty"` // must match Date, if set, see WithYearFromDate
+	Date          time.Time         `json:"date" yaml:"date,omitempty"` // e.g 2024-09-17, see ParseDate; written in UTC, takes precedence over Year
+	Track         string            `json:"track" yaml:"track,omitempty"`
+	Disc          string            `json:"disc" yaml:"disc,omitempty"`       // TPOS, e.g 1/3
+	Season        string            `json:"season" yaml:"season,omitempty"`   // TXXX "SEASON"
+	Episode       string            `json:"episode" yaml:"episode,omitempty"` // TXXX "EPISODE"
+	Comment       string            `json:"comment" yaml:"comment,omitempty"`
+	Comments      []Comment         `json:"comments" yaml:"comments,omitempty"` // more COMM frames, e.g in other languages
+	Description   string            `json:"description" yaml:"description,omitempty"`
+	Language      string            `json:"language" yaml:"language,omitempty"`
+	Copyright     string            `json:"copyright" yaml:"copyright,omitempty"`
+	CopyrightURL  string            `json:"copyrightURL" yaml:"copyrightURL,omitempty"`   // WCOP, e.g a Creative Commons license URL
+	ArtistURL     string            `json:"artistURL" yaml:"artistURL,omitempty"`         // WOAR, e.g the show website
+	SourceURL     string            `json:"sourceURL" yaml:"sourceURL,omitempty"`         // WOAS, e.g the episode page
+	CommercialURL string            `json:"commercialURL" yaml:"commercialURL,omitempty"` // WCOM, e.g a store link
+	PublisherURL  string            `json:"publisherURL" yaml:"publisherURL,omitempty"`   // WPUB
+	Links         map[string]string `json:"links" yaml:"links,omitempty"`                 // more WXXX frames by description
+	License       string            `json:"license" yaml:"license,omitempty"`             // SPDX identifier, e.g CC-BY-4.0, see ApplyLicense
+	Funding       string            `json:"funding" yaml:"funding,omitempty"`             // donation URL, WXXX "funding"
+	Mood          string            `json:"mood" yaml:"mood,omitempty"`                   // TMOO
+	Energy        string            `json:"energy" yaml:"energy,omitempty"`               // TXXX "ENERGYLEVEL", e.g 1-10
+	Color         string            `json:"color" yaml:"color,omitempty"`                 // TXXX "COLOR", e.g #FF0000
+	CuePoints     string            `json:"cuePoints" yaml:"cuePoints,omitempty"`         // TXXX "CUEPOINTS", passed through as is
+	Custom        map[string]string `json:"custom" yaml:"custom,omitempty"`               // more TXXX frames by description, e.g EPISODE_GUID; the fields above win
+	Loudness      *Loudness         `json:"loudness" yaml:"loudness,omitempty"`           // written as RVA2
+	Rating        *Rating           `json:"rating" yaml:"rating,omitempty"`               // POPM, see TagRatings for more than one
+	PlayCount     uint64            `json:"playCount" yaml:"playCount,omitempty"`         // PCNT
+	CoverJPEG     string            `json:"coverJPEG" yaml:"coverJPEG,omitempty"`         // path or data:image/jpeg;base64,...
+	CoverData     []byte            `json:"coverData" yaml:"coverData,omitempty"`         // base64 in JSON, takes precedence over CoverJPEG
+	Chapters      []Chapter         `json:"chapters" yaml:"chapters,omitempty"`
 }
 
 type Chapter struct {
 	Title string `json:"title" yaml:"title,omitempty"`
-	Start string `json:"start" yaml:"start,omitempty"` // e.g. "00:05:00.500"
+	Start string `json:"start" yaml:"start,omitempty"` // e.g. "00:05:00.500", "5:00.5", "300.5" or "5m0.5s"
+	// Description is a longer text about the chapter, written as a
+	// TIT3 sub-frame.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// End is optional, by default a chapter ends where the next one
+	// starts (or at the end of the audio). Set it for gaps between
+	// chapters or overlapping chapters.
+	End string `json:"end,omitempty" yaml:"end,omitempty"`
+	// Image is the path, data URI or URL of a picture for the
+	// chapter, embedded (or, for a URL, linked) as an APIC sub-frame,
+	// and URL a link for it, e.g to show notes or a sponsor, written
+	// as a WXXX sub-frame.
+	Image string `json:"image,omitempty" yaml:"image,omitempty"`
+	URL   string `json:"url,omitempty" yaml:"url,omitempty"`
+	// Titles holds the title in other languages by ISO 639-2 code,
+	// e.g "swe", see ChapterTitleDescriptionPrefix.
+	Titles map[string]string `json:"titles,omitempty" yaml:"titles,omitempty"`
+	// Children are the chapters of a part, e.g of an audiobook. A
+	// chapter with children is a table of contents rather than a
+	// chapter of its own, its Start and End are not used. See
+	// FlattenChapters.
+	Children []Chapter `json:"children,omitempty" yaml:"children,omitempty"`
 }
 
 func StringTimeToMillis(t string) (uint32, error) {
-	d, err := StringTimeToTime(t)
+	d, err := StringTimeToDuration(t)
 	if err != nil {
 		return 0, err
 	}
-	return uint32((time.Duration(d.Hour())*time.Hour +
-		time.Duration(d.Minute())*time.Minute +
-		time.Duration(d.Second())*time.Second +
-		time.Duration(d.Nanosecond())) / time.Millisecond), nil
+	if d/time.Millisecond > math.MaxUint32 {
+		return 0, ErrBadChapterStartTime
+	}
+	return uint32(d / time.Millisecond), nil
 }
 
-func StringTimeToTime(t string) (time.Time, error) {
-	d, err := time.Parse("15:04:05.000", t)
-	if err != nil {
-		d, err = time.Parse("15:04:05.0", t)
-		if err != nil {
-			d, err = time.Parse("15:04:05", t)
-			if err != nil {
-				return time.Time{}, ErrBadChapterStartTime
-			}
+// MillisToStringTime formats milliseconds as HH:MM:SS.mmm, the
+// format of Chapter.Start.
+func MillisToStringTime(millis uint32) string {
+	return fmt.Sprintf("%02d:%02d:%02d.%03d",
+		millis/3600000, millis/60000%60, millis/1000%60, millis%1000)
+}
+
+// StringTimeToDuration parses a chapter time, the format of
+// Chapter.Start and End, into a time.Duration. Accepted are
+// HH:MM:SS, MM:SS and SS (e.g "1:05:30", "5:30" and "330"), each with
+// an optional fraction of a second after a period or, as in SRT
+// subtitles, a comma (e.g "00:05:30.500", "00:05:30,500" or "330.5"),
+// and Go durations like "1h5m30s". Hours and, without hours, minutes
+// and seconds may have any number of digits, e.g "26:15:00.000" in a
+// long audiobook. Returns ErrBadChapterStartTime if t is in neither
+// format.
+func StringTimeToDuration(t string) (time.Duration, error) {
+	if strings.ContainsAny(t, "hmsuµn") {
+		d, err := time.ParseDuration(t)
+		if err != nil || d < 0 || strings.HasPrefix(t, "+") {
+			return 0, ErrBadChapterStartTime
+		}
+		return d, nil
+	}
+	t, frac, hasFrac := strings.Cut(strings.Replace(t, ",", ".", 1), ".") // SRT uses a comma
+	if hasFrac && (len(frac) == 0 || len(frac) > 9) {
+		return 0, ErrBadChapterStartTime
+	}
+	fields := strings.Split(t, ":")
+	if len(fields) > 3 {
+		return 0, ErrBadChapterStartTime
+	}
+	var d time.Duration
+	for i, field := range fields {
+		if field == "" || strings.TrimLeft(field, "0123456789") != "" || (i > 0 && len(field) != 2) {
+			return 0, ErrBadChapterStartTime
+		}
+		n, err := strconv.ParseInt(field, 10, 64)
+		if err != nil || (i > 0 && n > 59) || d > math.MaxInt64/time.Second/60 || n > math.MaxInt64/int64(time.Hour) {
+			return 0, ErrBadChapterStartTime
 		}
+		d = d*60 + time.Duration(n)
+	}
+	if d > math.MaxInt64/time.Second-1 {
+		return 0, ErrBadChapterStartTime
+	}
+	d *= time.Second
+	if hasFrac {
+		if strings.TrimLeft(frac, "0123456789") != "" {
+			return 0, ErrBadChapterStartTime
+		}
+		n, _ := strconv.ParseInt((frac + "00000000")[:9], 10, 64)
+		d += time.Duration(n)
 	}
 	return d, nil
 }
 
+// StringTimeToTime parses t like StringTimeToDuration and returns it
+// as a time on January 1 of year 0, like time.Parse without a date.
+// Times of 24 hours or more fall on the following days.
+//
+// Deprecated: use StringTimeToDuration.
+func StringTimeToTime(t string) (time.Time, error) {
+	d, err := StringTimeToDuration(t)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(0, time.January, 1, 0, 0, 0, 0, time.UTC).Add(d), nil
+}
+
 func GetMP3Duration(mp3path string) (time.Duration, error) {
 	f, err := os.Open(mp3path)
 	if err != nil {
 		return 0, err
 	}
 	defer f.Close()
-	d, err := mp3duration.Read(f)
+	d, err := ReadMP3Duration(f)
 	if err != nil {
 		return 0, err
 	}
@@ -79,52 +190,52 @@ func GetMP3Duration(mp3path string) (time.Duration, error) {
 }
 
 // TextFrame returns an UTF-16 ID3v2.4 Text Frame from title string.
+// The chapter writers use it for chapter titles unless
+// WithTextEncoding is given, e.g WithTextEncoding(UTF8) for smaller
+// tags.
 func TextFrame(title string) []byte {
-	frame := []byte{0x01}             // UTF-16 with BOM (0x01)
-	frame = append(frame, 0xFF, 0xFE) // BOM (byte order mark)
-	for _, r := range title {
-		frame = append(frame, byte(r), 0x00) // UTF-16LE encoding
-	}
-	return frame
+	return EncodeText(title, UTF16LEBOM) // UTF-16LE with BOM, surrogate pairs outside the BMP
 }
 
 // AddCHAPAndCTOC adds each CHAP and a final CTOC frame to tag from a
-// slice of Chapter structs. duration is an Info struct returned by
+// slice of Chapter structs. Chapters with Children are written as
+// nested CTOC frames, titled by a TIT2 sub-frame, listing the CHAP
+// frames of their children. duration is an Info struct returned by
 // mp3duration.Read or ReadFile as AddCHAPAndCTOC need to know the
 // duration of the underlying MP3 in order to calculate end of last
 // chapter. If chapters is an empty slice, no frames will be
 // added. Returns error if something failed, in which case tag is to
-// be considered corrupt (should not be saved via tag.Save).
-func AddCHAPAndCTOC(duration mp3duration.Info, tag *id3v2.Tag, chapters []Chapter) error {
+// be considered corrupt (should not be saved via tag.Save). With
+// WithTLEN, an existing TLEN frame in tag is used for the duration
+// instead, duration.TimeDuration may then be zero.
+func AddCHAPAndCTOC(duration mp3duration.Info, tag *id3v2.Tag, chapters []Chapter, opts ...Option) error {
+	return addCHAPAndCTOC(newOptions(opts...), duration, tag, chapters)
+}
+
+func addCHAPAndCTOC(o *options, duration mp3duration.Info, tag *id3v2.Tag, chapters []Chapter) error {
 	if len(chapters) == 0 {
 		return nil
 	}
-	if duration.TimeDuration == 0 {
+	total := o.tlenDuration(tag, duration.TimeDuration)
+	if total == 0 {
 		return ErrZeroDuration
 	}
-	millis := uint32(duration.TimeDuration / time.Millisecond)
-
-	starts := make([]uint32, len(chapters))
-	chapterIDs := []string{}
+	millis := uint32(total / time.Millisecond)
+	chapters, err := o.prepareChapterTree(chapters, total)
+	if err != nil {
+		return err
+	}
+	leaves := FlattenChapters(chapters)
 
-	for i, ch := range chapters {
-		m, err := StringTimeToMillis(ch.Start)
-		if err != nil {
-			return err
-		}
-		starts[i] = m
+	starts, ends, err := chapterTimes(leaves, millis)
+	if err != nil {
+		return err
 	}
 
 	// CHAP encoding loop
-	for i, ch := range chapters {
-		start := starts[i]
-		var end uint32
-		if i < len(chapters)-1 {
-			end = starts[i+1]
-		} else {
-			end = millis
-		}
-		chapterID := strconv.Itoa(i + 1)
+	for i, ch := range leaves {
+		start, end := starts[i], ends[i]
+		chapterID := ChapterElementID(i)
 		body := []byte{}
 		body = append(body, []byte(chapterID)...)
 		body = append(body, 0x00)
@@ -136,31 +247,118 @@ func AddCHAPAndCTOC(duration mp3duration.Info, tag *id3v2.Tag, chapters []Chapte
 		body = append(body, []byte{0xFF, 0xFF, 0xFF, 0xFF}...) // start offset
 		body = append(body, []byte{0xFF, 0xFF, 0xFF, 0xFF}...) // end offset
 
-		titleFrame := TextFrame(ch.Title)
-		titleHeader := []byte("TIT2")
-		lengthBuf := make([]byte, 4)
-		binary.BigEndian.PutUint32(lengthBuf, uint32(len(titleFrame)))
-		titleHeader = append(titleHeader, lengthBuf...)
-		titleHeader = append(titleHeader, []byte{0x00, 0x00}...)
-		body = append(body, titleHeader...)
-		body = append(body, titleFrame...)
+		body = append(body, subFrame("TIT2", o.chapterTitleFrame(ch.Title))...)
+		if ch.Description != "" {
+			body = append(body, subFrame("TIT3", o.chapterTitleFrame(ch.Description))...)
+		}
+		body = append(body, o.chapterTitleTXXX(ch.Titles)...)
+		if ch.URL != "" {
+			body = append(body, subFrame("WXXX", userDefinedURLBody("", ch.URL))...)
+		}
+		mimeType, img, err := o.chapterImage(ch)
+		if err != nil {
+			return err
+		}
+		if img != nil {
+			body = append(body, subFrame("APIC", pictureBody(mimeType, id3v2.PTOther, img))...)
+		}
 
 		tag.AddFrame("CHAP", id3v2.UnknownFrame{Body: body})
-		chapterIDs = append(chapterIDs, chapterID)
 	}
 
-	// Add CTOC frame
-	ctocBody := []byte("toc\x00")
-	ctocBody = append(ctocBody, []byte{0x01, 0x00}...)
-	ctocBody = append(ctocBody, byte(len(chapterIDs)))
-	for _, id := range chapterIDs {
-		ctocBody = append(ctocBody, []byte(id)...)
-		ctocBody = append(ctocBody, 0x00)
+	// Add the top-level CTOC frame and one per chapter with children
+	next, tocs := 0, 0
+	var addCTOC func(tocID, title string, flags byte, chapters []Chapter)
+	addCTOC = func(tocID, title string, flags byte, chapters []Chapter) {
+		childIDs := []string{}
+		for _, ch := range chapters {
+			if len(ch.Children) > 0 {
+				tocs++
+				childID := "toc" + strconv.Itoa(tocs)
+				childIDs = append(childIDs, childID)
+				addCTOC(childID, ch.Title, ctocOrdered, ch.Children)
+				continue
+			}
+			childIDs = append(childIDs, ChapterElementID(next))
+			next++
+		}
+		ctocBody := []byte(tocID + "\x00")
+		ctocBody = append(ctocBody, flags, byte(len(childIDs)))
+		for _, id := range childIDs {
+			ctocBody = append(ctocBody, []byte(id)...)
+			ctocBody = append(ctocBody, 0x00)
+		}
+		if title != "" {
+			ctocBody = append(ctocBody, subFrame("TIT2", o.chapterTitleFrame(title))...)
+		}
+		tag.AddFrame("CTOC", id3v2.UnknownFrame{Body: ctocBody})
 	}
-	tag.AddFrame("CTOC", id3v2.UnknownFrame{Body: ctocBody})
+	addCTOC("toc", "", ctocTopLevel|ctocOrdered, chapters)
 	return nil
 }
 
+// Flags of a CTOC frame.
+const (
+	ctocOrdered  = 0x01
+	ctocTopLevel = 0x02
+)
+
+// subFrame returns a frame with id and body for embedding in a CHAP
+// or CTOC frame, with the ID3v2.4 synchsafe frame size.
+func subFrame(id string, body []byte) []byte {
+	size := len(body)
+	frame := append([]byte(id), byte(size>>21&0x7F), byte(size>>14&0x7F), byte(size>>7&0x7F), byte(size&0x7F))
+	frame = append(frame, 0x00, 0x00) // flags
+	return append(frame, body...)
+}
+
+// pictureBody returns the body of an APIC frame with an empty
+// description.
+func pictureBody(mimeType string, pictureType byte, data []byte) []byte {
+	body := []byte{id3v2.EncodingISO.Key}
+	body = append(body, []byte(mimeType)...)
+	body = append(body, 0x00, pictureType, 0x00)
+	return append(body, data...)
+}
+
+// chapterTimes returns the start and end of each chapter in
+// milliseconds. The end of a chapter is its End if set, otherwise the
+// start of the next one, or total for the last chapter. Returns a
+// ChapterOrderError if a chapter starts before the previous one and
+// ErrChapterBeyondDuration if one starts at or after a non-zero total.
+func chapterTimes(chapters []Chapter, total uint32) (starts, ends []uint32, err error) {
+	starts = make([]uint32, len(chapters))
+	ends = make([]uint32, len(chapters))
+	for i, ch := range chapters {
+		m, err := StringTimeToMillis(ch.Start)
+		if err != nil {
+			return nil, nil, err
+		}
+		starts[i] = m
+		if i > 0 && m < starts[i-1] {
+			return nil, nil, &ChapterOrderError{Index: i, Chapter: ch, Previous: chapters[i-1]}
+		}
+		if total > 0 && m >= total {
+			return nil, nil, fmt.Errorf("%w: chapter %d %q starts at %s, the audio ends at %s",
+				ErrChapterBeyondDuration, i+1, ch.Title, MillisToStringTime(m), MillisToStringTime(total))
+		}
+	}
+	for i, ch := range chapters {
+		if ch.End != "" {
+			m, err := StringTimeToMillis(ch.End)
+			if err != nil || m <= starts[i] {
+				return nil, nil, ErrBadChapterEndTime
+			}
+			ends[i] = m
+		} else if i < len(chapters)-1 {
+			ends[i] = starts[i+1]
+		} else {
+			ends[i] = total
+		}
+	}
+	return starts, ends, nil
+}
+
 // AddCoverJPEG adds a cover picture (jpegPath) to tag or return
 // error.
 func AddCoverJPEG(tag *id3v2.Tag, jpegPath string) error {
@@ -168,196 +366,215 @@ func AddCoverJPEG(tag *id3v2.Tag, jpegPath string) error {
 	if err != nil {
 		return err
 	}
+	AddCoverJPEGData(tag, imgData)
+	return nil
+}
+
+// AddCoverJPEGFrom adds a cover picture from the JPEG image read from
+// r to tag or returns error.
+func AddCoverJPEGFrom(tag *id3v2.Tag, r io.Reader) error {
+	imgData, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	AddCoverJPEGData(tag, imgData)
+	return nil
+}
+
+// AddCoverJPEGData adds a cover picture from the JPEG image in
+// imgData to tag.
+func AddCoverJPEGData(tag *id3v2.Tag, imgData []byte) {
+	addCover(tag, "image/jpeg", imgData)
+}
+
+func addCover(tag *id3v2.Tag, mimeType string, imgData []byte) {
 	picFrame := id3v2.PictureFrame{
 		Encoding:    id3v2.EncodingISO,
-		MimeType:    "image/jpeg",
+		MimeType:    mimeType,
 		PictureType: id3v2.PTFrontCover,
 		Description: "Cover",
 		Picture:     imgData,
 	}
 	tag.AddAttachedPicture(picFrame)
-	return nil
 }
 
-// WriteID3v2Tag writes everything this package is designed for;
-// title, album, arist, genre, year, cover picture (jpeg), and
-// chapters. If any field is empty (zero length or empty slice, etc),
-// it will not be added to the tag. The output mp3 will be modified.
-func WriteID3v2Tag(mp3file string, input TrackInfo) error {
-	di, err := mp3duration.ReadFile(mp3file)
+// DecodeDataURI decodes a base64 data URI (e.g
+// data:image/jpeg;base64,/9j/4AAQ...) into its MIME type and data.
+// Returns ErrBadDataURI if uri is not a base64 data URI.
+func DecodeDataURI(uri string) (mimeType string, data []byte, err error) {
+	rest, ok := strings.CutPrefix(uri, "data:")
+	if !ok {
+		return "", nil, ErrBadDataURI
+	}
+	meta, payload, ok := strings.Cut(rest, ",")
+	if !ok {
+		return "", nil, ErrBadDataURI
+	}
+	mimeType, ok = strings.CutSuffix(meta, ";base64")
+	if !ok {
+		return "", nil, ErrBadDataURI
+	}
+	data, err = base64.StdEncoding.DecodeString(payload)
 	if err != nil {
-		return err
-	}
-	tag, err := id3v2.Open(mp3file, id3v2.Options{Parse: false})
-	if err != nil {
-		return err
-	}
-	defer tag.Close()
-	// Important
-	tag.SetVersion(4)
-	// Set frames unless empty...
-	if len([]rune(input.Title)) > 0 {
-		tag.SetTitle(input.Title)
-	}
-	if len([]rune(input.Album)) > 0 {
-		tag.SetAlbum(input.Album)
+		return "", nil, fmt.Errorf("%w: %w", ErrBadDataURI, err)
 	}
-	if len([]rune(input.Artist)) > 0 {
-		tag.SetArtist(input.Artist)
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
 	}
-	if len([]rune(input.Genre)) > 0 {
-		tag.SetGenre(input.Genre)
-	}
-	if len([]rune(input.Year)) > 0 {
-		tag.SetYear(input.Year)
-	}
-	if len([]rune(input.CoverJPEG)) > 0 {
-		if err := AddCoverJPEG(tag, input.CoverJPEG); err != nil {
-			return err
+	return mimeType, data, nil
+}
+
+// coverImage returns the cover of input from CoverData, or from
+// CoverJPEG as a data URI or path. Returns nil data if input has no
+// cover.
+func coverImage(o *options, input TrackInfo) (mimeType string, data []byte, err error) {
+	switch {
+	case len(input.CoverData) > 0:
+		mimeType = http.DetectContentType(input.CoverData)
+		if !strings.HasPrefix(mimeType, "image/") {
+			mimeType = "image/jpeg"
 		}
-	}
-	if len(input.Chapters) > 0 {
-		if err := AddCHAPAndCTOC(di, tag, input.Chapters); err != nil {
-			return err
+		return mimeType, input.CoverData, nil
+	case strings.HasPrefix(input.CoverJPEG, "data:"):
+		return DecodeDataURI(input.CoverJPEG)
+	case len([]rune(input.CoverJPEG)) > 0:
+		data, err = o.readFile(input.CoverJPEG)
+		return "image/jpeg", data, err
+	case o.coverArt != nil:
+		data, err = o.coverArt.Render(coverText(input))
+		return "image/jpeg", data, err
+	}
+	return "", nil, nil
+}
+
+// chapterImageLink is the MIME type of an APIC frame linking to the
+// picture by URL instead of embedding it.
+const chapterImageLink = "-->"
+
+// chapterImage returns the picture of ch for its APIC sub-frame, from
+// Image or rendered by WithChapterArt, or nil if there is none. An
+// Image URL is linked rather than embedded.
+func (o *options) chapterImage(ch Chapter) (mimeType string, data []byte, err error) {
+	switch {
+	case strings.HasPrefix(ch.Image, "data:"):
+		return DecodeDataURI(ch.Image)
+	case strings.Contains(ch.Image, "://"):
+		return chapterImageLink, []byte(ch.Image), nil
+	case ch.Image != "":
+		data, err = o.readFile(ch.Image)
+		if err != nil {
+			return "", nil, err
 		}
+		mimeType = http.DetectContentType(data)
+		if !strings.HasPrefix(mimeType, "image/") {
+			mimeType = "image/jpeg"
+		}
+		return mimeType, data, nil
+	case o.chapterArt != nil:
+		data, err = o.chapterArt.Render(ch.Title)
+		return "image/jpeg", data, err
 	}
-	// Save tag information
-	if err := tag.Save(); err != nil {
-		return err
-	}
-	return nil
+	return "", nil, nil
 }
 
-// GetFFmpegChaptersTXT returns a chapters.txt file for use with
-// FFmpeg when generating e.g m4b files. Maybe strange to also support
-// ffmpeg and m4b in a package for MP3 ID3 tags, but the functionality
-// is already here and chapters in m4b is much better. Returns a
-// chapters.txt as a byte slice or error if something failed.
-func GetFFmpegChaptersTXT(duration mp3duration.Info, chapters []Chapter) ([]byte, error) {
-	var output []byte = []byte(";FFMETADATA1\n")
-	if len(chapters) == 0 {
-		return nil, nil
-	}
-	if duration.TimeDuration == 0 {
-		return nil, ErrZeroDuration
-	}
-	millis := uint32(duration.TimeDuration / time.Millisecond)
-	starts := make([]uint32, len(chapters))
-	for i, ch := range chapters {
-		m, err := StringTimeToMillis(ch.Start)
+// WriteID3v2Tag writes everything this package is designed for;
+// title, album, artist, album artist, composer, grouping, genre, year
+// or date, track, disc, language, comment, description, copyright,
+// funding and other URLs, custom TXXX frames, cover picture (jpeg),
+// and chapters. If any field is empty (zero length or empty slice,
+// etc), it will not be added to the tag. The output mp3 will be
+// modified, unless WithOutputPath is given.
+func WriteID3v2Tag(mp3file string, input TrackInfo, opts ...Option) error {
+	o := newOptions(opts...)
+	if o.sidecarChapters && len(input.Chapters) == 0 {
+		resolved, err := ResolveChapters(SidecarChapterSources(mp3file), false)
 		if err != nil {
-			return nil, err
+			return o.fail(MetricErrChapters, err)
 		}
-		starts[i] = m
-	}
-	for i, ch := range chapters {
-		start := starts[i]
-		var end uint32
-		if i < len(chapters)-1 {
-			end = starts[i+1]
-		} else {
-			end = millis
+		input.Chapters = resolved.Chapters
+	}
+	var previous []byte
+	err := rewriteFile(o, mp3file, func(w io.Writer, r io.ReadSeeker) error {
+		if o.undo {
+			var err error
+			if previous, err = readLeadingTag(r); err != nil {
+				return o.fail(MetricErrOpen, err)
+			}
+			if _, err := r.Seek(0, io.SeekStart); err != nil {
+				return o.fail(MetricErrOpen, err)
+			}
 		}
-		output = append(output, []byte(fmt.Sprintf("\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
-			start, end, ch.Title,
-		))...)
+		return WriteID3v2TagTo(w, r, input, opts...)
+	})
+	if err != nil || !o.undo {
+		return err
+	}
+	if err := os.WriteFile(o.outputPath(mp3file)+UndoSuffix, previous, 0644); err != nil {
+		return o.fail(MetricErrSave, err)
 	}
-	return output, nil
+	return nil
 }
 
-// WriteFFmpegChaptersTXT returns a temporary (os.CreateTemp)
-// ffmpeg-compatible chapters.txt file for use if generating e.g an
-// m4b instead of an mp3. Returns full path to tempfile or error if
-// something failed.
-func WriteFFmpegChaptersTXT(duration mp3duration.Info, chapters []Chapter) (string, error) {
-	var removeTempfile bool
-	chaptersTXT, err := GetFFmpegChaptersTXT(duration, chapters)
-	if err != nil {
-		return "", err
+// WithOutputPath makes the functions that modify an MP3 file in place
+// (WriteID3v2Tag, SetCover, ReplaceChapterTitlesInFile, Undo...)
+// leave it untouched and write the result to path instead, e.g when
+// the file is on a read-only share or in a content-addressed store.
+// The file is only opened for reading and path is replaced
+// atomically. WithUndo saves the previous tag next to path.
+func WithOutputPath(path string) Option {
+	return func(o *options) {
+		o.output = path
 	}
-	f, err := os.CreateTemp("", "*-chapters.txt")
-	if err != nil {
-		return "", err
-	}
-	defer func() {
-		f.Close()
-		if removeTempfile {
-			os.Remove(f.Name())
-		}
-	}()
-	if _, err := f.Write(chaptersTXT); err != nil {
-		removeTempfile = true
-		return "", err
+}
+
+// outputPath returns the path the result of modifying path is written
+// to, see WithOutputPath.
+func (o *options) outputPath(path string) string {
+	if o.output != "" {
+		return o.output
 	}
-	return f.Name(), nil
+	return path
 }
 
-// WriteFFmpegMetadataFile returns a temporary (os.CreateTemp)
-// ffmpeg-compatible metadata file for use with illustrative example:
-//
-//	ffmpeg -i input.flac output.m4a
-//	ffmpeg -i output.m4a -i metadata.txt -map_metadata 1 -codec copy final_output.m4a
-//
-// Returns full path to tempfile or error if something failed.
-func WriteFFmpegMetadataFile(duration time.Duration, input TrackInfo) (string, error) {
-	var removeTempfile bool
-	var output []byte = []byte(";FFMETADATA1\n")
-	chaptersTXT, err := GetFFmpegChaptersTXT(mp3duration.Info{TimeDuration: duration}, input.Chapters)
+// rewriteFile calls fn with path opened for reading and a temporary
+// file next to the output path (path unless WithOutputPath is given),
+// which replaces the output if fn succeeds (like id3v2.Tag.Save
+// does). Failures outside fn are reported to the metrics hook of o.
+func rewriteFile(o *options, path string, fn func(w io.Writer, r io.ReadSeeker) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return o.fail(MetricErrOpen, err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
 	if err != nil {
-		return "", err
+		return o.fail(MetricErrOpen, err)
 	}
-	if chaptersTXT == nil {
-		chaptersTXT = make([]byte, 0)
-	} else {
-		// Remove ";FFMETADATA" line from chaptersTXT
-		chaptersTXT = bytes.Replace(chaptersTXT, output, nil, 1)
+	mode, output := fi.Mode(), o.outputPath(path)
+	if output != path {
+		mode |= 0200 // a copy of a read-only file should be writable
 	}
-	f, err := os.CreateTemp("", "*-ffmetadata.txt")
+	tmp, err := os.OpenFile(output+"-id3v2", os.O_RDWR|os.O_CREATE|os.O_TRUNC, mode)
 	if err != nil {
-		return "", err
+		return o.fail(MetricErrSave, err)
 	}
+	removeTempfile := true
 	defer func() {
-		f.Close()
+		tmp.Close()
 		if removeTempfile {
-			os.Remove(f.Name())
+			os.Remove(tmp.Name())
 		}
 	}()
-	kvpairs := []map[string]string{
-		{"title": input.Title},
-		{"album": input.Album},
-		{"artist": input.Artist},
-		{"genre": input.Genre},
-		{"track": input.Track},
-		{"comment": input.Comment},
-		{"language": input.Language},
-		{"description": input.Description},
-		{"copyright": fmt.Sprintf("Copyright %s %s", input.Date.Format("2006"), input.Artist)},
-	}
-	if !input.Date.IsZero() {
-		kvpairs = append(kvpairs, map[string]string{"date": input.Date.Format("2006-01-02")})
-	}
-	for i := range kvpairs {
-		for k, v := range kvpairs[i] {
-			if len([]rune(v)) > 0 {
-				appendKVPair(&output, k, v)
-			}
-		}
+	if err := fn(tmp, f); err != nil {
+		return err
 	}
-	// Append chapters
-	output = append(output, chaptersTXT...)
-	if _, err := f.Write(output); err != nil {
-		removeTempfile = true
-		return "", err
+	if err := tmp.Close(); err != nil {
+		return o.fail(MetricErrSave, err)
 	}
-	return f.Name(), nil
-}
-
-func appendKVPair(output *[]byte, key, value string) {
-	clean := strings.Map(func(r rune) rune {
-		if r == '\n' || r == '\r' {
-			return -1 // remove linefeeds
-		}
-		return r
-	}, value)
-	*output = append(*output, []byte(key+"="+strings.TrimSpace(clean)+"\n")...)
+	f.Close()
+	if err := os.Rename(tmp.Name(), output); err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	removeTempfile = false
+	return nil
 }
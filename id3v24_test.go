@@ -3,6 +3,8 @@ package id3v24
 import (
 	"bytes"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -104,6 +106,14 @@ func TestGetFFmpegChaptersTXT(t *testing.T) {
 		t.Errorf("generated chapters.txt and %s does not match", testdataFile)
 	}
 
+	chaptersTXT, err = GetFFmpegChapters(duration.TimeDuration, chapters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(chaptersTXT, testdata) != 0 {
+		t.Errorf("GetFFmpegChapters output and %s does not match", testdataFile)
+	}
+
 	// if err := os.WriteFile(testdataFile, chaptersTXT, 0644); err != nil {
 	// 	t.Fatal(err)
 	// }
@@ -167,3 +177,276 @@ func TestWriteFFmpegMetadataFile(t *testing.T) {
 	// 	t.Fatal(err)
 	// }
 }
+
+// copyTestMP3 copies testdata/test.mp3 to a temporary directory and
+// returns the path to the copy.
+func copyTestMP3(t *testing.T) string {
+	t.Helper()
+	data, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mp3file := filepath.Join(t.TempDir(), "test.mp3")
+	if err := os.WriteFile(mp3file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return mp3file
+}
+
+type countingMetrics struct {
+	files, bytes, scans int64
+	errors              []string
+}
+
+func (c *countingMetrics) FileTagged(n int64, _ time.Duration) {
+	c.files++
+	c.bytes += n
+}
+
+func (c *countingMetrics) DurationScanned(_, _ time.Duration) {
+	c.scans++
+}
+
+func (c *countingMetrics) Error(kind string) {
+	c.errors = append(c.errors, kind)
+}
+
+func TestWriteID3v2TagMetrics(t *testing.T) {
+	mp3file := copyTestMP3(t)
+	m := &countingMetrics{}
+	if err := WriteID3v2Tag(mp3file, TrackInfo{Title: "Hello world"}, WithMetrics(m)); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(mp3file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.files != 1 || m.scans != 1 || m.bytes != fi.Size() || len(m.errors) != 0 {
+		t.Errorf("unexpected metrics %+v", m)
+	}
+	err = WriteID3v2Tag(mp3file, TrackInfo{CoverJPEG: "testdata/does-not-exist.jpg"}, WithMetrics(m))
+	if err == nil {
+		t.Fatal("expected error for missing cover")
+	}
+	if len(m.errors) != 1 || m.errors[0] != MetricErrCover {
+		t.Errorf("expected one %q error, got %v", MetricErrCover, m.errors)
+	}
+}
+
+func TestGetFFmpegMetadataKeyOrder(t *testing.T) {
+	trackInfo := TrackInfo{
+		Title:       "Hello world",
+		Artist:      "Universe",
+		Track:       "5",
+		Description: "An episode",
+		Date:        time.Date(2024, 9, 17, 0, 0, 0, 0, time.UTC),
+	}
+	output, err := GetFFmpegMetadata(30*time.Second, trackInfo,
+		WithFFmetadataKeys("description", "track", "title", "date"),
+		WithFFmetadataDateFirst(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ";FFMETADATA1\ndate=2024-09-17\ndescription=An episode\ntrack=5\ntitle=Hello world\n"
+	if string(output) != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+	output, err = GetFFmpegMetadata(30*time.Second, trackInfo, WithSynthesizedCopyright(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(output, []byte("copyright=")) {
+		t.Errorf("expected no copyright line, got %q", output)
+	}
+}
+
+func TestGetFFmpegMetadataCopyright(t *testing.T) {
+	trackInfo := TrackInfo{
+		Artist:    "Universe",
+		Date:      time.Date(2024, 9, 17, 0, 0, 0, 0, time.UTC),
+		Copyright: "CC BY 4.0 Universe",
+	}
+	for _, tc := range []struct {
+		input    TrackInfo
+		opts     []Option
+		expected string
+	}{
+		{trackInfo, nil, "copyright=CC BY 4.0 Universe\n"},
+		{TrackInfo{Artist: "Universe", Date: trackInfo.Date}, nil, "copyright=Copyright 2024 Universe\n"},
+		{TrackInfo{Artist: "Universe", Year: "2023"}, nil, "copyright=Copyright 2023 Universe\n"},
+		{TrackInfo{Artist: "Universe"}, nil, ""},
+		{TrackInfo{Artist: "Universe", Date: trackInfo.Date}, []Option{WithSynthesizedCopyright(false)}, ""},
+	} {
+		opts := append([]Option{WithFFmetadataKeys("copyright")}, tc.opts...)
+		output, err := GetFFmpegMetadata(0, tc.input, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := ";FFMETADATA1\n" + tc.expected; string(output) != expected {
+			t.Errorf("expected %q, got %q", expected, output)
+		}
+	}
+}
+
+func TestGetFFmpegChaptersTimebase(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Chapter 1", Start: "00:00:00"},
+		{Title: "Chapter 2", Start: "00:00:10.5"},
+	}
+	output, err := GetFFmpegChapters(30*time.Second, chapters, WithTimebase(TimebaseMPEGTS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ";FFMETADATA1\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/90000\nSTART=0\nEND=945000\ntitle=Chapter 1\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/90000\nSTART=945000\nEND=2700000\ntitle=Chapter 2\n"
+	if string(output) != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestGetFFmpegAlbumMetadata(t *testing.T) {
+	album := TrackInfo{Album: "Galaxy", Artist: "Universe", Track: "1", Copyright: "CC0"}
+	tracks := []AlbumTrack{
+		{Info: TrackInfo{Title: "Part one", Album: "Galaxy", Track: "1"}, Duration: 10 * time.Second},
+		{Info: TrackInfo{Album: "Galaxy", Track: "2"}, Duration: 20500 * time.Millisecond},
+	}
+	output, err := GetFFmpegAlbumMetadata(album, tracks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ";FFMETADATA1\ntitle=Galaxy\nalbum=Galaxy\nartist=Universe\ncopyright=CC0\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=0\nEND=10000\ntitle=Part one\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=10000\nEND=30500\ntitle=Track 2\n"
+	if string(output) != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+	output, err = GetFFmpegTrackMetadata(10*time.Second, tracks[0].Info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := ";FFMETADATA1\ntitle=Part one\ntrack=1\n"; string(output) != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestFFMetadataBuilder(t *testing.T) {
+	var b FFMetadataBuilder
+	if string(b.Bytes()) != FFMetadataHeader {
+		t.Errorf("expected %q, got %q", FFMetadataHeader, b.Bytes())
+	}
+	b.AddKV("title", " Hello\nworld ")
+	b.AddChapter(Timebase44100, 0, 44100, "Intro")
+	b.AddKV("artist", "Guest")
+	expected := FFMetadataHeader + "title=Helloworld\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/44100\nSTART=0\nEND=44100\ntitle=Intro\nartist=Guest\n"
+	if string(b.Bytes()) != expected {
+		t.Errorf("expected %q, got %q", expected, b.Bytes())
+	}
+}
+
+func TestTextFrame(t *testing.T) {
+	for _, tc := range []struct {
+		title    string
+		expected []byte
+	}{
+		{"Å", []byte{0x01, 0xFF, 0xFE, 0xC5, 0x00}},
+		{"第二章", []byte{0x01, 0xFF, 0xFE, 0x2C, 0x7B, 0x8C, 0x4E, 0xE0, 0x7A}},
+		{"🎧", []byte{0x01, 0xFF, 0xFE, 0x3C, 0xD8, 0xA7, 0xDF}},
+	} {
+		got := TextFrame(tc.title)
+		if !bytes.Equal(got, tc.expected) {
+			t.Errorf("%s: expected % x, got % x", tc.title, tc.expected, got)
+		}
+		if decoded := decodeText(got[1:], got[0]); decoded != tc.title {
+			t.Errorf("expected %q, got %q", tc.title, decoded)
+		}
+	}
+	chapters := []Chapter{
+		{Title: "Intro 🎙️", Start: "00:00:00.000"},
+		{Title: "第二章 – Ωmega", Start: "00:00:10.000"},
+	}
+	tag := id3v2.NewEmptyTag()
+	tag.SetVersion(4)
+	if err := AddCHAPAndCTOC(mp3duration.Info{TimeDuration: 30 * time.Second}, tag, chapters); err != nil {
+		t.Fatal(err)
+	}
+	got, err := TagChapters(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, chapters) {
+		t.Errorf("expected %v, got %v", chapters, got)
+	}
+}
+
+func TestStringTimeToDuration(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"00:00:00":      0,
+		"1:02:03":       time.Hour + 2*time.Minute + 3*time.Second,
+		"00:00:01.5":    1500 * time.Millisecond,
+		"00:00:01.250":  1250 * time.Millisecond,
+		"26:15:00.000":  26*time.Hour + 15*time.Minute,
+		"100:00:00.001": 100*time.Hour + time.Millisecond,
+		"5:30":          5*time.Minute + 30*time.Second,
+		"90:00.25":      90*time.Minute + 250*time.Millisecond,
+		"330":           330 * time.Second,
+		"330.5":         330500 * time.Millisecond,
+		"1h5m30s":       time.Hour + 5*time.Minute + 30*time.Second,
+		"1500ms":        1500 * time.Millisecond,
+		"00:05:00,500":  5*time.Minute + 500*time.Millisecond,
+	} {
+		got, err := StringTimeToDuration(s)
+		if err != nil || got != want {
+			t.Errorf("%s: expected %v, got %v (%v)", s, want, got, err)
+		}
+	}
+	for _, s := range []string{"", "1:2:3", "00:60:00", "00:00:60", "00:00:00.", "-1:00:00", "aa:00:00", "00:00:00.1234567890", "5:3", "1:00:00:00", ".5", "-5m", "5x", "1.2.3", "00:00:01,2.3", "00:00:01,"} {
+		if _, err := StringTimeToDuration(s); err != ErrBadChapterStartTime {
+			t.Errorf("%q: expected ErrBadChapterStartTime, got %v", s, err)
+		}
+	}
+	millis, err := StringTimeToMillis("26:15:00.000")
+	if err != nil || MillisToStringTime(millis) != "26:15:00.000" {
+		t.Errorf("expected 26:15:00.000, got %s (%v)", MillisToStringTime(millis), err)
+	}
+}
+
+func TestWithOutputPath(t *testing.T) {
+	mp3file := copyTestMP3(t)
+	if err := os.Chmod(mp3file, 0444); err != nil {
+		t.Fatal(err)
+	}
+	original, err := os.ReadFile(mp3file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "tagged.mp3")
+	if err := WriteID3v2Tag(mp3file, TrackInfo{Title: "Copy"}, WithOutputPath(output), WithUndo()); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(mp3file); err != nil || !bytes.Equal(data, original) {
+		t.Errorf("expected the source to be untouched, got %v", err)
+	}
+	input, err := ReadID3v2Tag(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.Title != "Copy" {
+		t.Errorf("expected %q, got %q", "Copy", input.Title)
+	}
+	fi, err := os.Stat(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode()&0200 == 0 {
+		t.Errorf("expected a writable copy, got %v", fi.Mode())
+	}
+	if err := Undo(output); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(output); err != nil || !bytes.Equal(data, original) {
+		t.Errorf("expected the undone copy to equal the source, got %v", err)
+	}
+}
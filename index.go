@@ -0,0 +1,105 @@
+package id3v24
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// LibraryEntry is the exported form of a FileReport, as written by
+// WriteLibraryJSONL and WriteLibrarySQL.
+type LibraryEntry struct {
+	Path       string `json:"path"`
+	Format     string `json:"format"`
+	TagVersion int    `json:"tagVersion,omitempty"`
+	TagSize    int    `json:"tagSize,omitempty"`
+	DurationMS int64  `json:"durationMs,omitempty"`
+	Chapters   int    `json:"chapters,omitempty"`
+	HasCover   bool   `json:"hasCover,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// NewLibraryEntry returns the LibraryEntry of r.
+func NewLibraryEntry(r FileReport) LibraryEntry {
+	e := LibraryEntry{
+		Path:       r.Path,
+		Format:     string(r.Format),
+		TagVersion: int(r.TagVersion),
+		TagSize:    r.TagSize,
+		DurationMS: int64(r.Duration / time.Millisecond),
+		Chapters:   r.Chapters,
+		HasCover:   r.HasCover,
+	}
+	if r.Err != nil {
+		e.Error = r.Err.Error()
+	}
+	return e
+}
+
+// WriteLibraryJSONL writes every report received from reports (e.g
+// from ScanLibrary) as a LibraryEntry in JSON Lines format to w and
+// returns the number of entries written. On error the remaining
+// reports are not received, cancel the context of the scan to stop
+// it.
+func WriteLibraryJSONL(w io.Writer, reports <-chan FileReport) (int, error) {
+	enc := json.NewEncoder(w)
+	n := 0
+	for r := range reports {
+		if err := enc.Encode(NewLibraryEntry(r)); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// LibrarySchema creates the table written by WriteLibrarySQL.
+const LibrarySchema = `CREATE TABLE IF NOT EXISTS library (
+	path        TEXT PRIMARY KEY,
+	format      TEXT NOT NULL,
+	tag_version INTEGER NOT NULL,
+	tag_size    INTEGER NOT NULL,
+	duration_ms INTEGER NOT NULL,
+	chapters    INTEGER NOT NULL,
+	has_cover   INTEGER NOT NULL,
+	error       TEXT NOT NULL,
+	scanned_at  TEXT NOT NULL
+)`
+
+// WriteLibrarySQL stores every report received from reports (e.g
+// from ScanLibrary) as a row of the library table (see LibrarySchema,
+// created if missing) in db in a single transaction, replacing rows
+// of files scanned before. Returns the number of rows written. The
+// statements use SQLite syntax; open db with the SQLite driver of
+// your choice, e.g modernc.org/sqlite or github.com/mattn/go-sqlite3.
+// On error the remaining reports are not received, cancel the context
+// of the scan to stop it.
+func WriteLibrarySQL(ctx context.Context, db *sql.DB, reports <-chan FileReport) (int, error) {
+	if _, err := db.ExecContext(ctx, LibrarySchema); err != nil {
+		return 0, err
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, `INSERT OR REPLACE INTO library
+	(path, format, tag_version, tag_size, duration_ms, chapters, has_cover, error, scanned_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	scannedAt := time.Now().UTC().Format(time.RFC3339)
+	n := 0
+	for r := range reports {
+		e := NewLibraryEntry(r)
+		if _, err := stmt.ExecContext(ctx, e.Path, e.Format, e.TagVersion, e.TagSize, e.DurationMS, e.Chapters, e.HasCover, e.Error, scannedAt); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, tx.Commit()
+}
@@ -0,0 +1,168 @@
+package id3v24
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"runtime/debug"
+	"sort"
+	"time"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// JournalRecord describes one tag write, see WithJournal.
+type JournalRecord struct {
+	Time    time.Time `json:"time"`
+	File    string    `json:"file,omitempty"` // empty if not writing to a file
+	Changed []string  `json:"changed"`        // IDs of frames added, changed or removed
+	Tool    string    `json:"tool"`           // module path and version of this package
+}
+
+// WithJournal calls fn with a JournalRecord whenever WriteID3v2Tag,
+// WriteDSFTag, WriteFLACTag, WriteOggTag, WriteMP4Tag or their io
+// variants have written a new tag, for teams that need an audit trail
+// of metadata edits. Changes to tags other than ID3v2 are recorded by
+// Vorbis comment key (PICTURE for FLAC picture blocks) or MPEG-4 item
+// (chpl for chapters). If fn returns an error the write fails and,
+// for the file variants, the file is left untouched. See JournalFile.
+func WithJournal(fn func(JournalRecord) error) Option {
+	return func(o *options) {
+		o.journal = fn
+	}
+}
+
+// JournalFile returns a WithJournal function appending each record
+// as a line of JSON to the file path, which is created if needed.
+func JournalFile(path string) func(JournalRecord) error {
+	return func(record JournalRecord) error {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+}
+
+// journalRecord returns the JournalRecord of the change from the
+// tagSize bytes long tag at the current position of r (none if
+// tagSize is 0) to tag, or nil if WithJournal was not given.
+func (o *options) journalRecord(r io.Reader, tagSize int64, tag *id3v2.Tag) (*JournalRecord, error) {
+	if o.journal == nil {
+		return nil, nil
+	}
+	old := id3v2.NewEmptyTag()
+	if tagSize > 0 {
+		var err error
+		if old, err = id3v2.ParseReader(io.LimitReader(r, tagSize), id3v2.Options{Parse: true}); err != nil {
+			return nil, err
+		}
+	}
+	return &JournalRecord{
+		File:    readerName(r),
+		Changed: changedFrames(old, tag),
+		Tool:    toolVersion(),
+	}, nil
+}
+
+// fieldJournalRecord returns the JournalRecord of the change from the
+// fields old to new, by name, of a tag other than ID3v2 read from r,
+// or nil if WithJournal was not given.
+func (o *options) fieldJournalRecord(r io.Reader, old, new map[string]string) *JournalRecord {
+	if o.journal == nil {
+		return nil
+	}
+	return &JournalRecord{
+		File:    readerName(r),
+		Changed: changedKeys(old, new),
+		Tool:    toolVersion(),
+	}
+}
+
+// readerName returns the name of the file r, empty if r is not a file.
+func readerName(r io.Reader) string {
+	if f, ok := r.(interface{ Name() string }); ok {
+		return f.Name()
+	}
+	return ""
+}
+
+// writeJournal passes record, if any, to the WithJournal function.
+func (o *options) writeJournal(record *JournalRecord) error {
+	if record == nil {
+		return nil
+	}
+	record.Time = time.Now().UTC()
+	return o.journal(*record)
+}
+
+// changedFrames returns the sorted IDs of the frames that differ
+// between old and new.
+func changedFrames(old, new *id3v2.Tag) []string {
+	encode := func(tag *id3v2.Tag) map[string]string {
+		m := map[string]string{}
+		for id, frames := range tag.AllFrames() {
+			bodies := make([]string, len(frames))
+			for i, f := range frames {
+				var buf bytes.Buffer
+				f.WriteTo(&buf)
+				bodies[i] = buf.String()
+			}
+			sort.Strings(bodies)
+			var all bytes.Buffer
+			for _, b := range bodies {
+				all.WriteString(b)
+				all.WriteByte(0)
+			}
+			m[id] = all.String()
+		}
+		return m
+	}
+	return changedKeys(encode(old), encode(new))
+}
+
+// changedKeys returns the sorted keys with values that differ between
+// before and after.
+func changedKeys(before, after map[string]string) []string {
+	changed := []string{}
+	for id, body := range after {
+		if before[id] != body {
+			changed = append(changed, id)
+		}
+	}
+	for id := range before {
+		if _, ok := after[id]; !ok {
+			changed = append(changed, id)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// toolVersion returns the module path and version of this package as
+// recorded in the build info of the running program.
+func toolVersion() string {
+	const path = "github.com/sa6mwa/id3v24"
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return path
+	}
+	if info.Main.Path == path {
+		return path + "@" + info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == path {
+			return path + "@" + dep.Version
+		}
+	}
+	return path
+}
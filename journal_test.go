@@ -0,0 +1,114 @@
+package id3v24
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithJournal(t *testing.T) {
+	mp3file := copyTestMP3(t)
+	journal := filepath.Join(t.TempDir(), "journal.jsonl")
+	if err := WriteID3v2Tag(mp3file, TrackInfo{Title: "Hello", Artist: "Universe"}, WithJournal(JournalFile(journal))); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteID3v2Tag(mp3file, TrackInfo{Title: "Hello world", Artist: "Universe"}, WithJournal(JournalFile(journal))); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var records []JournalRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record JournalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if !reflect.DeepEqual(records[0].Changed, []string{"TIT2", "TPE1"}) || !reflect.DeepEqual(records[1].Changed, []string{"TIT2"}) {
+		t.Errorf("unexpected changes %v and %v", records[0].Changed, records[1].Changed)
+	}
+	if records[1].File != mp3file || records[1].Time.IsZero() || !strings.HasPrefix(records[1].Tool, "github.com/sa6mwa/id3v24") {
+		t.Errorf("unexpected record %+v", records[1])
+	}
+
+	// A failing journal leaves the file untouched.
+	before, err := os.ReadFile(mp3file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := errors.New("journal is read-only")
+	err = WriteID3v2Tag(mp3file, TrackInfo{Title: "Changed"}, WithJournal(func(JournalRecord) error { return failed }))
+	if !errors.Is(err, failed) {
+		t.Errorf("expected %v, got %v", failed, err)
+	}
+	after, err := os.ReadFile(mp3file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Error("file was modified")
+	}
+}
+
+func TestWithJournalVorbisAndMP4(t *testing.T) {
+	var records []JournalRecord
+	journal := WithJournal(func(record JournalRecord) error {
+		records = append(records, record)
+		return nil
+	})
+	input := TrackInfo{Title: "Hello", Artist: "Universe", Chapters: []Chapter{{Title: "Intro", Start: "00:00:00"}}}
+	var out bytes.Buffer
+	if err := WriteFLACTagTo(&out, bytes.NewReader(minimalFLAC(nil)), input, journal); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFLACTagTo(io.Discard, bytes.NewReader(out.Bytes()), TrackInfo{Title: "Hello", Artist: "World"}, journal); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := WriteOggTagTo(&out, bytes.NewReader(minimalOgg("opus", []byte{0x69})), input, journal); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := WriteMP4TagTo(&out, bytes.NewReader(minimalMP4([]byte{0x69}, true)), input, journal); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteMP4TagTo(io.Discard, bytes.NewReader(out.Bytes()), TrackInfo{Title: "Hello", Artist: "World"}, journal); err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{
+		{"ARTIST", "CHAPTER001", "CHAPTER001NAME", "TITLE"},
+		{"ARTIST", "CHAPTER001", "CHAPTER001NAME"},
+		{"ARTIST", "CHAPTER001", "CHAPTER001NAME", "TITLE"}, // TITLE=Old before
+		{"chpl", "©ART", "©nam"},
+		{"chpl", "©ART"},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("expected %d records, got %d", len(expected), len(records))
+	}
+	for i, record := range records {
+		if !reflect.DeepEqual(record.Changed, expected[i]) || record.Time.IsZero() {
+			t.Errorf("record %d: expected changes %v, got %+v", i, expected[i], record)
+		}
+	}
+
+	// A failing journal fails the write.
+	failed := errors.New("journal is read-only")
+	if err := WriteMP4TagTo(io.Discard, bytes.NewReader(out.Bytes()), input, WithJournal(func(JournalRecord) error { return failed })); !errors.Is(err, failed) {
+		t.Errorf("expected %v, got %v", failed, err)
+	}
+}
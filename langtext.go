@@ -0,0 +1,104 @@
+package id3v24
+
+import (
+	"strings"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// LanguageText is the text of a language tagged frame, COMM (comment)
+// or USLT (unsynchronised lyrics). Language is the ISO 639-2 code of
+// the frame, e.g "eng", or "XXX" if unknown.
+type LanguageText struct {
+	Language    string
+	Description string
+	Text        string
+}
+
+// Comment is a comment of TrackInfo.Comments, written as a COMM
+// frame. Language is an ISO 639-2 code, e.g "eng", "XXX" (unknown) if
+// empty, and Description tells comments of the same language apart.
+type Comment struct {
+	Language    string `json:"language,omitempty" yaml:"language,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Text        string `json:"text" yaml:"text"`
+}
+
+// AddComment adds a COMM frame with text to tag, replacing one with
+// the same language and description. language is an ISO 639-2 code,
+// e.g "eng", or "XXX" (unknown) if not three letters.
+func AddComment(tag *id3v2.Tag, language, description, text string) {
+	if len(language) != 3 {
+		language = "XXX"
+	}
+	tag.AddCommentFrame(id3v2.CommentFrame{
+		Encoding:    tag.DefaultEncoding(),
+		Language:    language,
+		Description: description,
+		Text:        text,
+	})
+}
+
+// TagComments returns the COMM frames of tag in the order they appear.
+func TagComments(tag *id3v2.Tag) []LanguageText {
+	var texts []LanguageText
+	for _, f := range tag.GetFrames(tag.CommonID("Comments")) {
+		if cf, ok := f.(id3v2.CommentFrame); ok {
+			texts = append(texts, LanguageText{Language: cf.Language, Description: cf.Description, Text: cf.Text})
+		}
+	}
+	return texts
+}
+
+// TagLyrics returns the USLT frames of tag in the order they appear.
+func TagLyrics(tag *id3v2.Tag) []LanguageText {
+	var texts []LanguageText
+	for _, f := range tag.GetFrames(tag.CommonID("Unsynchronised lyrics/text transcription")) {
+		if uf, ok := f.(id3v2.UnsynchronisedLyricsFrame); ok {
+			texts = append(texts, LanguageText{Language: uf.Language, Description: uf.ContentDescriptor, Text: uf.Lyrics})
+		}
+	}
+	return texts
+}
+
+// SelectLanguage returns the text in the first language of preferred
+// that texts has one in, compared case-insensitively. Without a
+// match, a text of unknown language ("XXX", "und" or empty) is
+// returned, then the first text. Among texts of the same language one
+// without description wins. ok is false if texts is empty.
+func SelectLanguage(texts []LanguageText, preferred ...string) (text LanguageText, ok bool) {
+	if len(texts) == 0 {
+		return LanguageText{}, false
+	}
+	for _, lang := range preferred {
+		if i := indexLanguage(texts, func(l string) bool { return strings.EqualFold(l, lang) }); i >= 0 {
+			return texts[i], true
+		}
+	}
+	if i := indexLanguage(texts, unknownLanguage); i >= 0 {
+		return texts[i], true
+	}
+	return texts[0], true
+}
+
+// indexLanguage returns the index of the first text whose language
+// matches, preferring one without description, or -1.
+func indexLanguage(texts []LanguageText, match func(string) bool) int {
+	found := -1
+	for i, t := range texts {
+		if !match(t.Language) {
+			continue
+		}
+		if t.Description == "" {
+			return i
+		}
+		if found < 0 {
+			found = i
+		}
+	}
+	return found
+}
+
+func unknownLanguage(lang string) bool {
+	return lang == "" || strings.EqualFold(lang, "XXX") || strings.EqualFold(lang, "und")
+}
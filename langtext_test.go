@@ -0,0 +1,92 @@
+package id3v24
+
+import (
+	"bytes"
+	"testing"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+func TestSelectLanguage(t *testing.T) {
+	tag := id3v2.NewEmptyTag()
+	tag.SetVersion(4)
+	for _, c := range []id3v2.CommentFrame{
+		{Language: "deu", Description: "", Text: "Hallo"},
+		{Language: "swe", Description: "notes", Text: "Anteckningar"},
+		{Language: "swe", Description: "", Text: "Hej"},
+		{Language: "XXX", Description: "", Text: "Hi"},
+	} {
+		c.Encoding = id3v2.EncodingUTF8
+		tag.AddCommentFrame(c)
+	}
+	tag.AddUnsynchronisedLyricsFrame(id3v2.UnsynchronisedLyricsFrame{
+		Encoding: id3v2.EncodingUTF8, Language: "eng", ContentDescriptor: "", Lyrics: "La la la",
+	})
+	var buf bytes.Buffer
+	if _, err := tag.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	tag, err := id3v2.ParseReader(&buf, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments := TagComments(tag)
+	if len(comments) != 4 {
+		t.Fatalf("expected 4 comments, got %d", len(comments))
+	}
+	for _, tc := range []struct {
+		preferred []string
+		expected  string
+	}{
+		{[]string{"SWE", "deu"}, "Hej"},
+		{[]string{"fin", "deu"}, "Hallo"},
+		{[]string{"fin"}, "Hi"},
+		{nil, "Hi"},
+	} {
+		if got, ok := SelectLanguage(comments, tc.preferred...); !ok || got.Text != tc.expected {
+			t.Errorf("%v: expected %q, got %q", tc.preferred, tc.expected, got.Text)
+		}
+	}
+	if got, ok := SelectLanguage(TagLyrics(tag), "swe"); !ok || got.Text != "La la la" {
+		t.Errorf("expected %q, got %q", "La la la", got.Text)
+	}
+	if _, ok := SelectLanguage(nil, "eng"); ok {
+		t.Error("expected no text")
+	}
+}
+
+func TestComments(t *testing.T) {
+	mp3file := copyTestMP3(t)
+	input := TrackInfo{
+		Language: "eng",
+		Comment:  "Hello",
+		Comments: []Comment{
+			{Language: "swe", Text: "Hej"},
+			{Language: "eng", Description: "credits", Text: "Music by someone"},
+			{Text: "Unknown language"},
+		},
+	}
+	if err := WriteID3v2Tag(mp3file, input); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadID3v2Tag(mp3file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Comment != "Hello" {
+		t.Errorf("expected %q, got %q", "Hello", got.Comment)
+	}
+	expected := map[Comment]bool{
+		{Language: "swe", Text: "Hej"}:                                      true,
+		{Language: "eng", Description: "credits", Text: "Music by someone"}: true,
+		{Language: "XXX", Text: "Unknown language"}:                         true,
+	}
+	if len(got.Comments) != len(expected) {
+		t.Errorf("expected %d comments, got %v", len(expected), got.Comments)
+	}
+	for _, c := range got.Comments {
+		if !expected[c] {
+			t.Errorf("unexpected comment %+v", c)
+		}
+	}
+}
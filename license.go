@@ -0,0 +1,73 @@
+package id3v24
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrUnknownLicense error = errors.New("unknown license identifier")
+
+// LicenseDescription is the description of the TXXX frame holding the
+// SPDX identifier of TrackInfo.License.
+const LicenseDescription = "LICENSE"
+
+// License is a license known to TrackInfo.License.
+type License struct {
+	ID   string // SPDX identifier, e.g CC-BY-4.0
+	Name string // e.g CC BY 4.0
+	URL  string
+}
+
+var licenses = []License{
+	{"CC0-1.0", "CC0 1.0", "https://creativecommons.org/publicdomain/zero/1.0/"},
+	{"CC-BY-4.0", "CC BY 4.0", "https://creativecommons.org/licenses/by/4.0/"},
+	{"CC-BY-SA-4.0", "CC BY-SA 4.0", "https://creativecommons.org/licenses/by-sa/4.0/"},
+	{"CC-BY-ND-4.0", "CC BY-ND 4.0", "https://creativecommons.org/licenses/by-nd/4.0/"},
+	{"CC-BY-NC-4.0", "CC BY-NC 4.0", "https://creativecommons.org/licenses/by-nc/4.0/"},
+	{"CC-BY-NC-SA-4.0", "CC BY-NC-SA 4.0", "https://creativecommons.org/licenses/by-nc-sa/4.0/"},
+	{"CC-BY-NC-ND-4.0", "CC BY-NC-ND 4.0", "https://creativecommons.org/licenses/by-nc-nd/4.0/"},
+	{"CC-BY-3.0", "CC BY 3.0", "https://creativecommons.org/licenses/by/3.0/"},
+	{"CC-BY-SA-3.0", "CC BY-SA 3.0", "https://creativecommons.org/licenses/by-sa/3.0/"},
+	{"CC-BY-NC-SA-3.0", "CC BY-NC-SA 3.0", "https://creativecommons.org/licenses/by-nc-sa/3.0/"},
+	{"CC-PDM-1.0", "Public Domain Mark 1.0", "https://creativecommons.org/publicdomain/mark/1.0/"},
+}
+
+// LookupLicense returns the known license with the SPDX identifier
+// id, compared case-insensitively.
+func LookupLicense(id string) (License, bool) {
+	for _, l := range licenses {
+		if strings.EqualFold(l.ID, id) {
+			return l, true
+		}
+	}
+	return License{}, false
+}
+
+// ApplyLicense returns input with TrackInfo.License expanded, so that
+// open-licensed audio is tagged consistently: an empty Copyright (TCOP)
+// becomes "YEAR Artist, licensed under NAME" and an empty
+// CopyrightURL (WCOP) the URL of the license. The writers also add
+// the SPDX identifier in a TXXX frame, see LicenseDescription.
+// Returns an error wrapping ErrUnknownLicense if License is not one of
+// the identifiers known to LookupLicense.
+func ApplyLicense(input TrackInfo) (TrackInfo, error) {
+	if input.License == "" {
+		return input, nil
+	}
+	l, ok := LookupLicense(input.License)
+	if !ok {
+		return input, fmt.Errorf("%w: %q", ErrUnknownLicense, input.License)
+	}
+	input.License = l.ID
+	if input.Copyright == "" {
+		input.Copyright = "Licensed under " + l.Name
+		if holder := synthesizeCopyright(input); holder != "" {
+			input.Copyright = strings.TrimPrefix(holder, "Copyright ") + ", licensed under " + l.Name
+		}
+	}
+	if input.CopyrightURL == "" {
+		input.CopyrightURL = l.URL
+	}
+	return input, nil
+}
@@ -0,0 +1,61 @@
+package id3v24
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+func TestApplyLicense(t *testing.T) {
+	for _, tc := range []struct {
+		input     TrackInfo
+		copyright string
+		url       string
+	}{
+		{TrackInfo{License: "cc-by-4.0", Year: "2024", Artist: "The Hosts"}, "2024 The Hosts, licensed under CC BY 4.0", "https://creativecommons.org/licenses/by/4.0/"},
+		{TrackInfo{License: "CC0-1.0"}, "Licensed under CC0 1.0", "https://creativecommons.org/publicdomain/zero/1.0/"},
+		{TrackInfo{License: "CC-BY-SA-4.0", Copyright: "2024 Someone", CopyrightURL: "https://example.com/license"}, "2024 Someone", "https://example.com/license"},
+	} {
+		got, err := ApplyLicense(tc.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Copyright != tc.copyright || got.CopyrightURL != tc.url {
+			t.Errorf("%s: expected %q and %q, got %q and %q", tc.input.License, tc.copyright, tc.url, got.Copyright, got.CopyrightURL)
+		}
+	}
+	if _, err := ApplyLicense(TrackInfo{License: "CC-BY-5.0"}); !errors.Is(err, ErrUnknownLicense) {
+		t.Errorf("expected %v, got %v", ErrUnknownLicense, err)
+	}
+}
+
+func TestLicenseFrames(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := WriteID3v2TagTo(&out, bytes.NewReader(mp3), TrackInfo{Title: "Episode 1", License: "cc-by-nc-4.0"}); err != nil {
+		t.Fatal(err)
+	}
+	tag, err := id3v2.ParseReader(bytes.NewReader(out.Bytes()), id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := tag.GetTextFrame("TCOP").Text; text != "Licensed under CC BY-NC 4.0" {
+		t.Errorf("expected %q, got %q", "Licensed under CC BY-NC 4.0", text)
+	}
+	if wcop := tag.GetLastFrame("WCOP").(id3v2.UnknownFrame); string(wcop.Body) != "https://creativecommons.org/licenses/by-nc/4.0/" {
+		t.Errorf("unexpected WCOP %q", wcop.Body)
+	}
+	frame := tag.GetLastFrame("TXXX").(id3v2.UserDefinedTextFrame)
+	if frame.Description != LicenseDescription || frame.Value != "CC-BY-NC-4.0" {
+		t.Errorf("unexpected TXXX %q=%q", frame.Description, frame.Value)
+	}
+	if err := WriteID3v2TagTo(&out, bytes.NewReader(mp3), TrackInfo{License: "GPL"}); !errors.Is(err, ErrUnknownLicense) {
+		t.Errorf("expected %v, got %v", ErrUnknownLicense, err)
+	}
+}
@@ -0,0 +1,70 @@
+package id3v24
+
+import (
+	"encoding/binary"
+	"math"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// Loudness is the measured loudness of a track, e.g the input_i and
+// input_tp values printed by a first ffmpeg loudnorm pass:
+//
+//	ffmpeg -i episode.mp3 -af loudnorm=print_format=json -f null -
+type Loudness struct {
+	Integrated float64 `json:"integrated" yaml:"integrated"` // integrated loudness in LUFS
+	TruePeak   float64 `json:"truePeak" yaml:"truePeak"`     // true peak in dBTP
+}
+
+const (
+	// DefaultLoudnessTarget is the integrated loudness in LUFS that
+	// the RVA2 gain aims for unless WithLoudnessTarget is given, the
+	// common recommendation for podcasts.
+	DefaultLoudnessTarget = -16.0
+	// TruePeakCeiling is the highest true peak in dBTP the RVA2 gain
+	// is allowed to raise a track to.
+	TruePeakCeiling = -1.0
+	// RVA2Identification is the identification of the RVA2 frame
+	// written for TrackInfo.Loudness.
+	RVA2Identification = "track"
+)
+
+// WithLoudnessTarget sets the integrated loudness in LUFS that the
+// RVA2 gain written for TrackInfo.Loudness aims for, the default is
+// DefaultLoudnessTarget.
+func WithLoudnessTarget(lufs float64) Option {
+	return func(o *options) {
+		o.loudnessTarget = lufs
+	}
+}
+
+// Gain returns the gain in dB that brings l to target LUFS, reduced
+// if needed to keep the true peak at or below TruePeakCeiling.
+func (l Loudness) Gain(target float64) float64 {
+	gain := target - l.Integrated
+	if l.TruePeak+gain > TruePeakCeiling {
+		gain = TruePeakCeiling - l.TruePeak
+	}
+	return gain
+}
+
+// AddRVA2 adds an RVA2 (relative volume adjustment) frame for the
+// master volume to tag with the gain that brings l to target LUFS,
+// see Loudness.Gain.
+func AddRVA2(tag *id3v2.Tag, l Loudness, target float64) {
+	tag.AddFrame("RVA2", id3v2.UnknownFrame{Body: rva2Body(RVA2Identification, l.Gain(target), l.TruePeak)})
+}
+
+// rva2Body returns the body of an RVA2 frame adjusting the master
+// volume by gain dB, with the peak given in dBTP stored as a 16 bit
+// fraction of full scale.
+func rva2Body(identification string, gain, peak float64) []byte {
+	body := append([]byte(identification), 0x00)
+	body = append(body, 0x01) // master volume
+	adjustment := math.Round(gain * 512)
+	adjustment = math.Max(math.Min(adjustment, math.MaxInt16), math.MinInt16)
+	body = binary.BigEndian.AppendUint16(body, uint16(int16(adjustment)))
+	body = append(body, 16)
+	linear := math.Round(math.Pow(10, peak/20) * 32768)
+	return binary.BigEndian.AppendUint16(body, uint16(math.Min(linear, math.MaxUint16)))
+}
@@ -0,0 +1,58 @@
+package id3v24
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+func TestLoudnessGain(t *testing.T) {
+	for _, tc := range []struct {
+		loudness Loudness
+		target   float64
+		gain     float64
+	}{
+		{Loudness{Integrated: -20, TruePeak: -8}, -16, 4},
+		{Loudness{Integrated: -20, TruePeak: -3}, -16, 2}, // limited by TruePeakCeiling
+		{Loudness{Integrated: -12, TruePeak: -0.5}, -16, -4},
+		{Loudness{Integrated: -23, TruePeak: -10}, -23, 0},
+	} {
+		if gain := tc.loudness.Gain(tc.target); gain != tc.gain {
+			t.Errorf("%+v: expected gain %v, got %v", tc.loudness, tc.gain, gain)
+		}
+	}
+}
+
+func TestWriteID3v2TagToRVA2(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := TrackInfo{Loudness: &Loudness{Integrated: -20, TruePeak: -8}}
+	for _, tc := range []struct {
+		opts     []Option
+		expected []byte
+	}{
+		// +4 dB is 0x0800, -8 dBTP is 0.398 of full scale
+		{nil, []byte("track\x00\x01\x08\x00\x10\x32\xf5")},
+		{[]Option{WithLoudnessTarget(-23)}, []byte("track\x00\x01\xfa\x00\x10\x32\xf5")},
+	} {
+		var out bytes.Buffer
+		if err := WriteID3v2TagTo(&out, bytes.NewReader(mp3), input, tc.opts...); err != nil {
+			t.Fatal(err)
+		}
+		tag, err := id3v2.ParseReader(bytes.NewReader(out.Bytes()), id3v2.Options{Parse: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		frames := tag.GetFrames("RVA2")
+		if len(frames) != 1 {
+			t.Fatalf("expected 1 RVA2 frame, got %d", len(frames))
+		}
+		if body := frames[0].(id3v2.UnknownFrame).Body; !bytes.Equal(body, tc.expected) {
+			t.Errorf("expected RVA2 body %q, got %q", tc.expected, body)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package id3v24
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+var ErrBadLRC error = errors.New("malformed LRC")
+
+// SYLT content types, see the ID3v2.4 specification.
+const (
+	SyncedLyricsOther         byte = 0x00
+	SyncedLyricsLyrics        byte = 0x01
+	SyncedLyricsTranscription byte = 0x02
+)
+
+// SyncedLyrics are the lines of a SYLT (synchronised lyrics/text)
+// frame, timed in milliseconds from the start of the audio.
+type SyncedLyrics struct {
+	Language    string // ISO 639-2 code, e.g "eng"
+	Description string
+	ContentType byte // e.g SyncedLyricsLyrics
+	Lines       []LyricLine
+}
+
+type LyricLine struct {
+	Start time.Duration
+	Text  string
+}
+
+var (
+	lrcTagPattern  = regexp.MustCompile(`^\[([^\]]*)\]`)
+	lrcWordPattern = regexp.MustCompile(`<[0-9:.]+>`)
+)
+
+// ParseLRC parses the LRC file in r, one or more [mm:ss.xx]
+// timestamps followed by the text of a line:
+//
+//	[ti:Episode 1]
+//	[la:eng]
+//	[00:12.00]First line
+//	[00:17.20][01:02.50]Chorus
+//
+// The [la:] tag sets the language (if an ISO 639-2 code) and the
+// [offset:] tag, in milliseconds, moves every line earlier (or, if
+// negative, later). Other tags, lines without a timestamp and the word
+// timestamps <mm:ss.xx> of enhanced LRC are ignored. Lines are
+// returned in order of time with ContentType SyncedLyricsLyrics.
+func ParseLRC(r io.Reader) (SyncedLyrics, error) {
+	lyrics := SyncedLyrics{ContentType: SyncedLyricsLyrics}
+	var offset time.Duration
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		var starts []time.Duration
+		for {
+			m := lrcTagPattern.FindStringSubmatch(line)
+			if m == nil {
+				break
+			}
+			line = strings.TrimSpace(line[len(m[0]):])
+			key, value, _ := strings.Cut(m[1], ":")
+			if key != "" && strings.Trim(key, "0123456789") == "" {
+				start, err := StringTimeToDuration(m[1])
+				if err != nil {
+					return lyrics, fmt.Errorf("%w: line %d: bad timestamp [%s]", ErrBadLRC, n, m[1])
+				}
+				starts = append(starts, start)
+				continue
+			}
+			switch value = strings.TrimSpace(value); strings.ToLower(key) {
+			case "la":
+				if len(value) == 3 {
+					lyrics.Language = strings.ToLower(value)
+				}
+			case "offset":
+				ms, err := strconv.ParseInt(strings.TrimPrefix(value, "+"), 10, 64)
+				if err != nil {
+					return lyrics, fmt.Errorf("%w: line %d: bad offset %q", ErrBadLRC, n, value)
+				}
+				offset = time.Duration(ms) * time.Millisecond
+			}
+		}
+		text := strings.TrimSpace(lrcWordPattern.ReplaceAllString(line, ""))
+		for _, start := range starts {
+			lyrics.Lines = append(lyrics.Lines, LyricLine{Start: start, Text: text})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return lyrics, err
+	}
+	for i := range lyrics.Lines {
+		lyrics.Lines[i].Start = max(lyrics.Lines[i].Start-offset, 0)
+	}
+	sort.SliceStable(lyrics.Lines, func(i, j int) bool {
+		return lyrics.Lines[i].Start < lyrics.Lines[j].Start
+	})
+	return lyrics, nil
+}
+
+// AddSyncedLyricsFromLRC adds the lyrics of the LRC file lrcPath (see
+// ParseLRC) to tag as a SYLT frame, see AddSyncedLyrics.
+func AddSyncedLyricsFromLRC(tag *id3v2.Tag, lrcPath string) error {
+	f, err := os.Open(lrcPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	lyrics, err := ParseLRC(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", lrcPath, err)
+	}
+	AddSyncedLyrics(tag, lyrics)
+	return nil
+}
+
+// AddSyncedLyrics adds lyrics to tag as a SYLT frame with millisecond
+// timestamps and UTF-8 text, replacing one with the same language and
+// description. The language is "XXX" (unknown) if not three letters.
+func AddSyncedLyrics(tag *id3v2.Tag, lyrics SyncedLyrics) {
+	if len(lyrics.Language) != 3 {
+		lyrics.Language = "XXX"
+	}
+	existing := tag.GetFrames("SYLT")
+	tag.DeleteFrames("SYLT")
+	for _, f := range existing {
+		if uf, ok := f.(id3v2.UnknownFrame); ok {
+			if s, err := parseSYLT(uf.Body); err == nil && strings.EqualFold(s.Language, lyrics.Language) && s.Description == lyrics.Description {
+				continue
+			}
+		}
+		tag.AddFrame("SYLT", f)
+	}
+	body := []byte{id3v2.EncodingUTF8.Key}
+	body = append(body, lyrics.Language...)
+	body = append(body, 0x02, lyrics.ContentType) // timestamps in milliseconds
+	body = append(body, lyrics.Description...)
+	body = append(body, 0x00)
+	for _, line := range lyrics.Lines {
+		body = append(body, line.Text...)
+		body = append(body, 0x00)
+		body = binary.BigEndian.AppendUint32(body, uint32(line.Start.Milliseconds()))
+	}
+	tag.AddFrame("SYLT", id3v2.UnknownFrame{Body: body})
+}
+
+// TagSyncedLyrics returns the SYLT frames of tag with millisecond
+// timestamps, in the order they appear.
+func TagSyncedLyrics(tag *id3v2.Tag) ([]SyncedLyrics, error) {
+	var lyrics []SyncedLyrics
+	for _, f := range tag.GetFrames("SYLT") {
+		uf, ok := f.(id3v2.UnknownFrame)
+		if !ok {
+			continue
+		}
+		s, err := parseSYLT(uf.Body)
+		if err != nil {
+			return lyrics, err
+		}
+		lyrics = append(lyrics, s)
+	}
+	return lyrics, nil
+}
+
+// parseSYLT parses the body of a SYLT frame.
+func parseSYLT(body []byte) (SyncedLyrics, error) {
+	var s SyncedLyrics
+	if len(body) < 6 {
+		return s, fmt.Errorf("%w: SYLT too short", ErrBadFrame)
+	}
+	encoding := body[0]
+	s.Language = string(body[1:4])
+	if body[4] != 0x02 {
+		return s, fmt.Errorf("%w: SYLT timestamps are not in milliseconds", ErrBadFrame)
+	}
+	s.ContentType = body[5]
+	description, rest, ok := cutEncodedString(body[6:], encoding)
+	if !ok {
+		return s, fmt.Errorf("%w: SYLT content descriptor not terminated", ErrBadFrame)
+	}
+	s.Description = decodeText(description, encoding)
+	for len(rest) > 0 {
+		text, tail, ok := cutEncodedString(rest, encoding)
+		if !ok || len(tail) < 4 {
+			return s, fmt.Errorf("%w: SYLT line %d truncated", ErrBadFrame, len(s.Lines)+1)
+		}
+		s.Lines = append(s.Lines, LyricLine{
+			Start: time.Duration(binary.BigEndian.Uint32(tail)) * time.Millisecond,
+			Text:  decodeText(text, encoding),
+		})
+		rest = tail[4:]
+	}
+	return s, nil
+}
@@ -0,0 +1,53 @@
+package id3v24
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+const testLRC = `[ti:Episode 1]
+[la:swe]
+[offset:+500]
+[00:12.00]Första raden
+[00:17.20][01:02.50]<00:17.20>Refräng
+not a lyric line
+[00:14.75]Andra raden
+`
+
+func TestAddSyncedLyricsFromLRC(t *testing.T) {
+	lrcPath := filepath.Join(t.TempDir(), "episode.lrc")
+	if err := os.WriteFile(lrcPath, []byte(testLRC), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tag := id3v2.NewEmptyTag()
+	if err := AddSyncedLyricsFromLRC(tag, lrcPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddSyncedLyricsFromLRC(tag, lrcPath); err != nil {
+		t.Fatal(err)
+	}
+	lyrics, err := TagSyncedLyrics(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []SyncedLyrics{{Language: "swe", ContentType: SyncedLyricsLyrics, Lines: []LyricLine{
+		{Start: 11500 * time.Millisecond, Text: "Första raden"},
+		{Start: 14250 * time.Millisecond, Text: "Andra raden"},
+		{Start: 16700 * time.Millisecond, Text: "Refräng"},
+		{Start: 62 * time.Second, Text: "Refräng"},
+	}}}
+	if !reflect.DeepEqual(lyrics, expected) {
+		t.Errorf("expected %v, got %v", expected, lyrics)
+	}
+
+	if _, err := ParseLRC(strings.NewReader("[00:1x.00]Bad\n")); !errors.Is(err, ErrBadLRC) {
+		t.Errorf("expected %v, got %v", ErrBadLRC, err)
+	}
+}
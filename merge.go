@@ -0,0 +1,177 @@
+package id3v24
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"strings"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// WithTagMerge makes WriteID3v2Tag, WriteID3v2TagTo and the DSF
+// writers update the existing tag instead of replacing it: frames
+// (lyrics, replay gain, old chapters, etc) are kept unless the new tag
+// sets the same field, i.e the field is non-empty in TrackInfo.
+// Without it, the default, the existing tag is replaced entirely.
+//
+// User defined frames (TXXX, WXXX), private frames (PRIV), comments,
+// lyrics and pictures are matched by description, owner, language or
+// picture type, all other frames by ID. Chapters are replaced as a
+// whole when TrackInfo has any, see WithChapterMerge to merge them.
+// Duplicate frames are collapsed, see WithFrameDedup.
+func WithTagMerge() Option {
+	return func(o *options) {
+		o.tagMerge = true
+	}
+}
+
+// DedupRule is a rule by which WithTagMerge collapses duplicate
+// frames, see WithFrameDedup. Of duplicates, the frame of the new tag
+// wins, then the first of the existing tag.
+type DedupRule int
+
+const (
+	DedupFrontCover  DedupRule = 1 << iota // one APIC front cover, whatever its description
+	DedupUserDefined                       // one TXXX or WXXX per description, ignoring case
+	DedupIdentical                         // no identical frames, e.g repeated PRIV or WXXX frames
+
+	DedupAll = DedupFrontCover | DedupUserDefined | DedupIdentical
+)
+
+// WithFrameDedup sets the rules by which WithTagMerge collapses
+// duplicate frames of the existing tag, so that tags do not
+// accumulate cruft like a second front cover or a "Season" next to
+// the "SEASON" this package writes. The default is DedupAll,
+// WithFrameDedup() without rules keeps all frames the merge keeps.
+func WithFrameDedup(rules ...DedupRule) Option {
+	return func(o *options) {
+		o.dedup = 0
+		for _, r := range rules {
+			o.dedup |= r
+		}
+	}
+}
+
+// dedupKey returns the key of f under rules, frames with the same key
+// are duplicates, or "" if no rule applies to f.
+func dedupKey(id string, f id3v2.Framer, rules DedupRule) string {
+	switch f := f.(type) {
+	case id3v2.PictureFrame:
+		if rules&DedupFrontCover != 0 && f.PictureType == id3v2.PTFrontCover {
+			return "front cover"
+		}
+	case id3v2.UserDefinedTextFrame:
+		if rules&DedupUserDefined != 0 {
+			return "TXXX\x00" + strings.ToUpper(f.Description)
+		}
+	case id3v2.UnknownFrame:
+		if rules&DedupUserDefined != 0 && id == "WXXX" && len(f.Body) > 0 {
+			description, _, _ := cutEncodedString(f.Body[1:], f.Body[0])
+			return "WXXX\x00" + strings.ToUpper(decodeText(description, f.Body[0]))
+		}
+	}
+	if rules&DedupIdentical != 0 {
+		return fmt.Sprintf("%s\x00%x", id, sha256.Sum256([]byte(fmt.Sprintf("%#v", f))))
+	}
+	return ""
+}
+
+// existingTag returns the tag at the start of r, or appended to r if
+// there is none, if WithTagMerge was given and r has one, otherwise
+// nil.
+func (o *options) existingTag(r io.ReadSeeker) (*id3v2.Tag, error) {
+	if !o.tagMerge {
+		return nil, nil
+	}
+	previous, err := readLeadingTag(r)
+	if err == nil && len(previous) == 0 {
+		previous, err = readAppendedTag(r)
+	}
+	if err != nil || len(previous) == 0 {
+		return nil, err
+	}
+	return id3v2.ParseReader(bytes.NewReader(previous), id3v2.Options{Parse: true})
+}
+
+// mergeFrames adds the frames of existing that tag has no frame with
+// the same key of (see frameKey) to tag, leaving out duplicates by
+// the rules of WithFrameDedup.
+func mergeFrames(tag, existing *id3v2.Tag, rules DedupRule) {
+	if existing == nil {
+		return
+	}
+	keys := map[string]bool{}
+	duplicates := map[string]bool{}
+	for id, frames := range tag.AllFrames() {
+		keys[id] = true
+		for _, f := range frames {
+			keys[frameKey(id, f)] = true
+			if dk := dedupKey(id, f, rules); dk != "" {
+				duplicates[dk] = true
+			}
+		}
+	}
+	for id, frames := range existing.AllFrames() {
+		if v4, ok := v3DateFrames[id]; ok {
+			if keys[v4] {
+				continue
+			}
+		}
+		for _, f := range frames {
+			key := frameKey(id, f)
+			if key == id && keys[id] || keys[key] {
+				continue
+			}
+			if dk := dedupKey(id, f, rules); dk != "" {
+				if duplicates[dk] {
+					continue
+				}
+				duplicates[dk] = true
+			}
+			tag.AddFrame(id, f)
+		}
+	}
+}
+
+// v3DateFrames maps the ID3v2.3 date frames to the ID3v2.4 frame
+// replacing them.
+var v3DateFrames = map[string]string{
+	"TYER": "TDRC",
+	"TDAT": "TDRC",
+	"TIME": "TDRC",
+	"TRDA": "TDRC",
+	"TORY": "TDOR",
+}
+
+// frameKey returns id, or for frames that may occur more than once
+// with different descriptions (etc), id followed by what tells them
+// apart.
+func frameKey(id string, f id3v2.Framer) string {
+	switch f := f.(type) {
+	case id3v2.UserDefinedTextFrame:
+		return id + "\x00" + f.Description
+	case id3v2.CommentFrame:
+		return id + "\x00" + f.Language + "\x00" + f.Description
+	case id3v2.UnsynchronisedLyricsFrame:
+		return id + "\x00" + f.Language + "\x00" + f.ContentDescriptor
+	case id3v2.PictureFrame:
+		return id + "\x00" + string(f.PictureType)
+	case id3v2.PopularimeterFrame:
+		return id + "\x00" + f.Email
+	case id3v2.UnknownFrame:
+		if len(f.Body) == 0 {
+			break
+		}
+		switch id {
+		case "WXXX":
+			description, _, _ := cutEncodedString(f.Body[1:], f.Body[0])
+			return id + "\x00" + decodeText(description, f.Body[0])
+		case "PRIV":
+			owner, _, _ := bytes.Cut(f.Body, []byte{0x00})
+			return id + "\x00" + string(owner)
+		}
+	}
+	return id
+}
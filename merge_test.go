@@ -0,0 +1,122 @@
+package id3v24
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+func TestWithTagMerge(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chapters := []Chapter{{Title: "Intro", Start: "00:00:00.000"}}
+	var tagged bytes.Buffer
+	if err := WriteID3v2TagTo(&tagged, bytes.NewReader(mp3), TrackInfo{Title: "Old", Artist: "The Hosts", Energy: "3", Chapters: chapters}); err != nil {
+		t.Fatal(err)
+	}
+	// Add frames this package does not write, as another tagger would.
+	tag, err := id3v2.ParseReader(bytes.NewReader(tagged.Bytes()), id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag.AddUnsynchronisedLyricsFrame(id3v2.UnsynchronisedLyricsFrame{Encoding: id3v2.EncodingUTF8, Language: "eng", Lyrics: "La la la"})
+	tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{Encoding: id3v2.EncodingUTF8, Description: "REPLAYGAIN_TRACK_GAIN", Value: "-3.2 dB"})
+	tag.AddFrame("PRIV", id3v2.UnknownFrame{Body: []byte("com.example\x00data")})
+	var buf bytes.Buffer
+	if _, err := tag.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	buf.Write(mp3)
+	original := buf.Bytes()
+
+	input := TrackInfo{Title: "New", Energy: "7"}
+	var out bytes.Buffer
+	if err := WriteID3v2TagTo(&out, bytes.NewReader(original), input, WithTagMerge()); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadID3v2TagFrom(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := TrackInfo{Title: "New", Artist: "The Hosts", Energy: "7", Custom: map[string]string{"REPLAYGAIN_TRACK_GAIN": "-3.2 dB"}, Chapters: chapters}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+	merged, err := id3v2.ParseReader(bytes.NewReader(out.Bytes()), id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lyrics := TagLyrics(merged); len(lyrics) != 1 || lyrics[0].Text != "La la la" {
+		t.Errorf("expected the lyrics to be kept, got %v", lyrics)
+	}
+	if n := len(merged.GetFrames("TXXX")); n != 2 {
+		t.Errorf("expected 2 TXXX frames, got %d", n)
+	}
+	if n := len(merged.GetFrames("PRIV")); n != 1 {
+		t.Errorf("expected 1 PRIV frame, got %d", n)
+	}
+
+	out.Reset()
+	if err := WriteID3v2TagTo(&out, bytes.NewReader(original), input); err != nil {
+		t.Fatal(err)
+	}
+	replaced, err := id3v2.ParseReader(bytes.NewReader(out.Bytes()), id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := replaced.Count(); n != 2 {
+		t.Errorf("expected only the 2 new frames without WithTagMerge, got %d", n)
+	}
+}
+
+func TestWithFrameDedup(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag := id3v2.NewEmptyTag()
+	tag.SetVersion(4)
+	for _, description := range []string{"", "Cover (front)"} {
+		tag.AddAttachedPicture(id3v2.PictureFrame{Encoding: id3v2.EncodingUTF8, MimeType: "image/jpeg", PictureType: id3v2.PTFrontCover, Description: description, Picture: []byte(description)})
+	}
+	tag.AddAttachedPicture(id3v2.PictureFrame{Encoding: id3v2.EncodingUTF8, MimeType: "image/jpeg", PictureType: id3v2.PTBackCover, Description: "Back", Picture: []byte("back")})
+	tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{Encoding: id3v2.EncodingUTF8, Description: "Season", Value: "1"})
+	AddUserDefinedURLFrame(tag, "Shop", "https://example.com/shop")
+	AddUserDefinedURLFrame(tag, "SHOP", "https://example.com/shop")
+	var buf bytes.Buffer
+	if _, err := tag.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	buf.Write(mp3)
+
+	for _, c := range []struct {
+		rules                []DedupRule
+		pictures, txxx, wxxx int
+	}{
+		{[]DedupRule{DedupAll}, 2, 1, 1},
+		{nil, 3, 2, 2},
+		{[]DedupRule{DedupFrontCover}, 2, 2, 2},
+		{[]DedupRule{DedupUserDefined}, 3, 1, 1},
+	} {
+		var out bytes.Buffer
+		if err := WriteID3v2TagTo(&out, bytes.NewReader(buf.Bytes()), TrackInfo{Season: "2"}, WithTagMerge(), WithFrameDedup(c.rules...)); err != nil {
+			t.Fatal(err)
+		}
+		merged, err := id3v2.ParseReader(bytes.NewReader(out.Bytes()), id3v2.Options{Parse: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pictures, txxx, wxxx := len(merged.GetFrames("APIC")), len(merged.GetFrames("TXXX")), len(merged.GetFrames("WXXX"))
+		if pictures != c.pictures || txxx != c.txxx || wxxx != c.wxxx {
+			t.Errorf("%v: expected %d APIC, %d TXXX and %d WXXX, got %d, %d and %d", c.rules, c.pictures, c.txxx, c.wxxx, pictures, txxx, wxxx)
+		}
+		if input, err := TagTrackInfo(merged); err != nil || input.Season != "2" {
+			t.Errorf("%v: expected season 2, got %q (%v)", c.rules, input.Season, err)
+		}
+	}
+}
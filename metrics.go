@@ -0,0 +1,73 @@
+package id3v24
+
+import "time"
+
+// Error kinds passed to Metrics.Error.
+const (
+	MetricErrDuration = "duration" // scanning the MP3 for its duration failed
+	MetricErrOpen     = "open"     // opening or parsing the existing tag failed
+	MetricErrInput    = "input"    // the track info is invalid, e.g a year that disagrees with the date
+	MetricErrCover    = "cover"    // reading or adding the cover picture failed
+	MetricErrChapters = "chapters" // encoding CHAP and CTOC frames failed
+	MetricErrSave     = "save"     // writing the tag to the file failed
+)
+
+// Metrics receives counters and observations from WriteID3v2Tag when
+// passed via WithMetrics, so that operators running this package in
+// a server or batch worker can monitor throughput. Implementations
+// must be safe for concurrent use. Adapting Metrics to e.g Prometheus
+// is a matter of wrapping a few collectors:
+//
+//	type promMetrics struct {
+//		files    prometheus.Counter
+//		bytes    prometheus.Counter
+//		scanned  prometheus.Histogram
+//		errors   *prometheus.CounterVec
+//	}
+//
+//	func (p promMetrics) FileTagged(n int64, _ time.Duration) {
+//		p.files.Inc()
+//		p.bytes.Add(float64(n))
+//	}
+//
+//	func (p promMetrics) DurationScanned(d, _ time.Duration) {
+//		p.scanned.Observe(d.Seconds())
+//	}
+//
+//	func (p promMetrics) Error(kind string) {
+//		p.errors.WithLabelValues(kind).Inc()
+//	}
+type Metrics interface {
+	// FileTagged is called after a tag has been saved. n is the
+	// number of bytes rewritten (the size of the resulting file) and
+	// elapsed is the wall time spent tagging the file.
+	FileTagged(n int64, elapsed time.Duration)
+	// DurationScanned is called after an MP3 has been scanned,
+	// duration is the duration of the audio and elapsed the time it
+	// took to scan it.
+	DurationScanned(duration, elapsed time.Duration)
+	// Error is called once for every failure, kind is one of the
+	// MetricErr constants.
+	Error(kind string)
+}
+
+func (o *options) fileTagged(n int64, elapsed time.Duration) {
+	if o.metrics != nil {
+		o.metrics.FileTagged(n, elapsed)
+	}
+}
+
+func (o *options) durationScanned(duration, elapsed time.Duration) {
+	if o.metrics != nil {
+		o.metrics.DurationScanned(duration, elapsed)
+	}
+}
+
+// fail reports err as kind to the metrics hook (if any) and returns
+// err unmodified.
+func (o *options) fail(kind string, err error) error {
+	if o.metrics != nil && err != nil {
+		o.metrics.Error(kind)
+	}
+	return err
+}
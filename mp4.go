@@ -0,0 +1,497 @@
+package id3v24
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrNotMP4 error = errors.New("not an MPEG-4 file")
+
+// mp4Box is a box (atom) of an MPEG-4 file. Containers have children
+// instead of data.
+type mp4Box struct {
+	kind     string
+	data     []byte
+	children []*mp4Box
+	header   []byte // version and flags of a meta container, nil if none
+}
+
+// mp4Containers are the boxes WriteMP4TagTo descends into, to reach
+// moov/udta/meta/ilst and the chunk offsets of the tracks.
+var mp4Containers = map[string]bool{
+	"moov": true, "trak": true, "mdia": true, "minf": true, "stbl": true, "udta": true, "meta": true,
+}
+
+// parseMP4Boxes returns the boxes in b, descending into mp4Containers.
+func parseMP4Boxes(b []byte) ([]*mp4Box, error) {
+	var boxes []*mp4Box
+	for len(b) > 0 {
+		if len(b) < 8 {
+			return nil, ErrNotMP4
+		}
+		size, headerSize := uint64(binary.BigEndian.Uint32(b)), uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(b))
+		case 1:
+			if len(b) < 16 {
+				return nil, ErrNotMP4
+			}
+			size, headerSize = binary.BigEndian.Uint64(b[8:]), 16
+		}
+		if size < headerSize || size > uint64(len(b)) {
+			return nil, ErrNotMP4
+		}
+		box := &mp4Box{kind: string(b[4:8])}
+		payload := b[headerSize:size]
+		if box.kind == "meta" && !(len(payload) >= 8 && string(payload[4:8]) == "hdlr") {
+			// An ISO meta box is a full box, a QuickTime one is not.
+			if len(payload) < 4 {
+				return nil, ErrNotMP4
+			}
+			box.header, payload = payload[:4], payload[4:]
+		}
+		if mp4Containers[box.kind] {
+			children, err := parseMP4Boxes(payload)
+			if err != nil {
+				return nil, err
+			}
+			box.children = children
+		} else {
+			box.data = payload
+		}
+		boxes = append(boxes, box)
+		b = b[size:]
+	}
+	return boxes, nil
+}
+
+// bytes returns box as written to a file.
+func (box *mp4Box) bytes() []byte {
+	payload := append([]byte(nil), box.header...)
+	if mp4Containers[box.kind] {
+		for _, child := range box.children {
+			payload = append(payload, child.bytes()...)
+		}
+	} else {
+		payload = append(payload, box.data...)
+	}
+	return mp4BoxBytes(box.kind, payload)
+}
+
+func mp4BoxBytes(kind string, payload []byte) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(8+len(payload)))
+	b = append(b, kind...)
+	return append(b, payload...)
+}
+
+// child returns the first child of box of kind, adding it if add is
+// set and there is none.
+func (box *mp4Box) child(kind string, add bool) *mp4Box {
+	for _, c := range box.children {
+		if c.kind == kind {
+			return c
+		}
+	}
+	if !add {
+		return nil
+	}
+	c := &mp4Box{kind: kind}
+	box.children = append(box.children, c)
+	return c
+}
+
+// remove removes the children of box of kind.
+func (box *mp4Box) remove(kind string) {
+	children := box.children[:0]
+	for _, c := range box.children {
+		if c.kind != kind {
+			children = append(children, c)
+		}
+	}
+	box.children = children
+}
+
+// walk calls fn for box and every box below it.
+func (box *mp4Box) walk(fn func(*mp4Box) error) error {
+	if err := fn(box); err != nil {
+		return err
+	}
+	for _, c := range box.children {
+		if err := c.walk(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// duration returns the duration of the movie in the mvhd box of moov,
+// zero if unknown.
+func (box *mp4Box) duration() time.Duration {
+	mvhd := box.child("mvhd", false)
+	if mvhd == nil || len(mvhd.data) < 20 {
+		return 0
+	}
+	var timescale, duration uint64
+	if mvhd.data[0] == 1 {
+		if len(mvhd.data) < 32 {
+			return 0
+		}
+		timescale, duration = uint64(binary.BigEndian.Uint32(mvhd.data[20:])), binary.BigEndian.Uint64(mvhd.data[24:])
+	} else {
+		timescale, duration = uint64(binary.BigEndian.Uint32(mvhd.data[12:])), uint64(binary.BigEndian.Uint32(mvhd.data[16:]))
+	}
+	if timescale == 0 || duration == math.MaxUint32 || duration == math.MaxUint64 {
+		return 0
+	}
+	return time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+}
+
+// mp4TopLevelBox is the position of a top-level box in a file.
+type mp4TopLevelBox struct {
+	kind         string
+	offset, size int64
+}
+
+// readMP4Layout returns the top-level boxes of the MPEG-4 file in r.
+func readMP4Layout(r io.ReadSeeker) ([]mp4TopLevelBox, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	var boxes []mp4TopLevelBox
+	header := make([]byte, 16)
+	for offset := int64(0); offset < end; {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return nil, ErrNotMP4
+		}
+		size, headerSize := int64(binary.BigEndian.Uint32(header)), int64(8)
+		switch size {
+		case 0:
+			size = end - offset
+		case 1:
+			if _, err := io.ReadFull(r, header[8:]); err != nil {
+				return nil, ErrNotMP4
+			}
+			size, headerSize = int64(binary.BigEndian.Uint64(header[8:])), 16
+		}
+		if size < headerSize || size > end-offset {
+			return nil, ErrNotMP4
+		}
+		boxes = append(boxes, mp4TopLevelBox{kind: string(header[4:8]), offset: offset, size: size})
+		offset += size
+	}
+	if len(boxes) == 0 || boxes[0].kind != "ftyp" {
+		return nil, ErrNotMP4
+	}
+	return boxes, nil
+}
+
+// WriteMP4Tag writes input as iTunes metadata (an ilst box in
+// moov/udta/meta) to the MPEG-4 audio file mp4file (e.g m4a or m4b),
+// replacing the existing metadata. Chapters are written as a Nero
+// chpl box in moov/udta. The file will be modified.
+func WriteMP4Tag(mp4file string, input TrackInfo, opts ...Option) error {
+	return rewriteFile(newOptions(opts...), mp4file, func(w io.Writer, r io.ReadSeeker) error {
+		return WriteMP4TagTo(w, r, input, opts...)
+	})
+}
+
+// WriteMP4TagTo is the io-only variant of WriteMP4Tag, reading the
+// MPEG-4 file from r and writing the tagged file to w. The chunk
+// offsets of the tracks are updated when the moov box precedes the
+// media data. Fragmented files return ErrUnsupportedFormat.
+func WriteMP4TagTo(w io.Writer, r io.ReadSeeker, input TrackInfo, opts ...Option) error {
+	o := newOptions(opts...)
+	layout, err := readMP4Layout(r)
+	if err != nil {
+		return o.fail(MetricErrOpen, err)
+	}
+	var moovBox *mp4TopLevelBox
+	for i, box := range layout {
+		switch box.kind {
+		case "moov":
+			moovBox = &layout[i]
+		case "moof":
+			return fmt.Errorf("%w: fragmented MPEG-4", ErrUnsupportedFormat)
+		}
+	}
+	if moovBox == nil {
+		return o.fail(MetricErrOpen, ErrNotMP4)
+	}
+	raw := make([]byte, moovBox.size)
+	if _, err := r.Seek(moovBox.offset, io.SeekStart); err != nil {
+		return o.fail(MetricErrOpen, err)
+	}
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return o.fail(MetricErrOpen, err)
+	}
+	boxes, err := parseMP4Boxes(raw)
+	if err != nil || len(boxes) != 1 {
+		return o.fail(MetricErrOpen, ErrNotMP4)
+	}
+	moov := boxes[0]
+	items, err := mp4Items(o, input)
+	if err != nil {
+		return err
+	}
+	sizes := []FrameSize{{ID: "ilst", Count: 1, Size: len(items)}}
+	if err := o.checkTagSize(len(items), func() []FrameSize { return sizes }); err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	chapters, err := o.prepareChapterTree(input.Chapters, moov.duration())
+	if err != nil {
+		return o.fail(MetricErrChapters, err)
+	}
+	chpl, err := neroChapters(FlattenChapters(chapters))
+	if err != nil {
+		return o.fail(MetricErrChapters, err)
+	}
+	old := mp4Fields(moov)
+	udta := moov.child("udta", true)
+	meta := udta.child("meta", true)
+	if meta.header == nil && meta.child("hdlr", false) == nil {
+		meta.header = []byte{0, 0, 0, 0}
+	}
+	if meta.child("hdlr", false) == nil {
+		hdlr := &mp4Box{kind: "hdlr", data: append(make([]byte, 8), "mdirappl\x00\x00\x00\x00\x00\x00\x00\x00\x00"...)}
+		meta.children = append([]*mp4Box{hdlr}, meta.children...)
+	}
+	meta.child("ilst", true).data = items
+	udta.remove("chpl")
+	if chpl != nil {
+		udta.children = append(udta.children, &mp4Box{kind: "chpl", data: chpl})
+	}
+	record := o.fieldJournalRecord(r, old, mp4Fields(moov))
+	// Media data after moov moves by as much as moov grows.
+	delta := int64(len(moov.bytes())) - moovBox.size
+	moovEnd := moovBox.offset + moovBox.size
+	err = moov.walk(func(box *mp4Box) error {
+		switch box.kind {
+		case "stco":
+			return shiftChunkOffsets(box.data, 4, moovEnd, delta)
+		case "co64":
+			return shiftChunkOffsets(box.data, 8, moovEnd, delta)
+		}
+		return nil
+	})
+	if err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	for _, box := range layout {
+		if box.kind == "moov" {
+			if _, err := w.Write(moov.bytes()); err != nil {
+				return o.fail(MetricErrSave, err)
+			}
+			continue
+		}
+		if _, err := r.Seek(box.offset, io.SeekStart); err != nil {
+			return o.fail(MetricErrSave, err)
+		}
+		if _, err := io.CopyN(w, r, box.size); err != nil {
+			return o.fail(MetricErrSave, err)
+		}
+	}
+	if err := o.writeJournal(record); err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	return nil
+}
+
+// mp4Fields returns the items of the ilst box of moov by kind, or name
+// for freeform items, and the chpl box, for fieldJournalRecord.
+func mp4Fields(moov *mp4Box) map[string]string {
+	fields := map[string]string{}
+	udta := moov.child("udta", false)
+	if udta == nil {
+		return fields
+	}
+	if chpl := udta.child("chpl", false); chpl != nil {
+		fields["chpl"] = string(chpl.data)
+	}
+	meta := udta.child("meta", false)
+	if meta == nil || meta.child("ilst", false) == nil {
+		return fields
+	}
+	items, _ := parseMP4Boxes(meta.child("ilst", false).data)
+	for _, item := range items {
+		key := strings.Replace(item.kind, "\xa9", "©", 1) // as listed by tools
+		if key == "----" {
+			// mean, name and data boxes
+			if boxes, err := parseMP4Boxes(item.data); err == nil && len(boxes) > 1 && len(boxes[1].data) >= 4 {
+				key = string(boxes[1].data[4:])
+			}
+		}
+		fields[key] += string(item.data) + "\x00"
+	}
+	return fields
+}
+
+// shiftChunkOffsets adds delta to the chunk offsets of width bytes in
+// the stco or co64 box data that are at or after from.
+func shiftChunkOffsets(data []byte, width int, from, delta int64) error {
+	if len(data) < 8 {
+		return ErrNotMP4
+	}
+	n := int(binary.BigEndian.Uint32(data[4:]))
+	if n > (len(data)-8)/width {
+		return ErrNotMP4
+	}
+	for i := 0; i < n; i++ {
+		b := data[8+i*width:]
+		if width == 4 {
+			offset := int64(binary.BigEndian.Uint32(b))
+			if offset >= from {
+				offset += delta
+				if offset > math.MaxUint32 {
+					return errors.New("chunk offset beyond 4 GiB in stco")
+				}
+				binary.BigEndian.PutUint32(b, uint32(offset))
+			}
+		} else if offset := int64(binary.BigEndian.Uint64(b)); offset >= from {
+			binary.BigEndian.PutUint64(b, uint64(offset+delta))
+		}
+	}
+	return nil
+}
+
+// Types of the data boxes of iTunes metadata items.
+const (
+	mp4DataBinary  = 0
+	mp4DataUTF8    = 1
+	mp4DataJPEG    = 13
+	mp4DataPNG     = 14
+	mp4DataInteger = 21
+)
+
+// mp4Data returns the data box of an item of type dataType.
+func mp4Data(dataType uint32, value []byte) []byte {
+	payload := binary.BigEndian.AppendUint32(nil, dataType)
+	payload = append(payload, 0, 0, 0, 0) // locale
+	return mp4BoxBytes("data", append(payload, value...))
+}
+
+// mp4Items returns the ilst payload of input: the usual text fields,
+// track and disc numbers, the cover and other fields (Custom
+// included) as com.apple.iTunes freeform items.
+func mp4Items(o *options, input TrackInfo) ([]byte, error) {
+	input, err := ApplyLicense(input)
+	if err != nil {
+		return nil, o.fail(MetricErrInput, err)
+	}
+	if err := o.resolveYear(&input); err != nil {
+		return nil, o.fail(MetricErrInput, err)
+	}
+	var ilst []byte
+	add := func(kind, value string) {
+		if value != "" {
+			ilst = append(ilst, mp4BoxBytes(kind, mp4Data(mp4DataUTF8, []byte(value)))...)
+		}
+	}
+	addNumber := func(kind, field, value string) {
+		if value == "" {
+			return
+		}
+		n, total, _ := strings.Cut(value, "/")
+		number, err := strconv.ParseUint(strings.TrimSpace(n), 10, 16)
+		of, err2 := strconv.ParseUint("0"+strings.TrimSpace(total), 10, 16)
+		if err != nil || err2 != nil {
+			o.warn(fmt.Errorf("%s %q is not a number, left out of the MPEG-4 tag", field, value))
+			return
+		}
+		b := []byte{0, 0}
+		b = binary.BigEndian.AppendUint16(b, uint16(number))
+		b = binary.BigEndian.AppendUint16(b, uint16(of))
+		if kind == "trkn" {
+			b = append(b, 0, 0)
+		}
+		ilst = append(ilst, mp4BoxBytes(kind, mp4Data(mp4DataBinary, b))...)
+	}
+	addFreeform := func(name, value string) {
+		if value == "" {
+			return
+		}
+		payload := mp4BoxBytes("mean", append([]byte{0, 0, 0, 0}, "com.apple.iTunes"...))
+		payload = append(payload, mp4BoxBytes("name", append([]byte{0, 0, 0, 0}, name...))...)
+		payload = append(payload, mp4Data(mp4DataUTF8, []byte(value))...)
+		ilst = append(ilst, mp4BoxBytes("----", payload)...)
+	}
+	add("\xa9nam", input.Title)
+	add("\xa9alb", input.Album)
+	add("\xa9ART", input.Artist)
+	add("aART", input.AlbumArtist)
+	add("\xa9wrt", input.Composer)
+	add("\xa9grp", input.Grouping)
+	add("\xa9gen", input.Genre)
+	if !input.Date.IsZero() {
+		add("\xa9day", formatDate(input.Date))
+	} else {
+		add("\xa9day", input.Year)
+	}
+	addNumber("trkn", "track", input.Track)
+	addNumber("disk", "disc", input.Disc)
+	add("\xa9cmt", input.Comment)
+	add("desc", input.Description)
+	add("cprt", input.Copyright)
+	addFreeform("LANGUAGE", input.Language)
+	addFreeform("LICENSE", input.CopyrightURL)
+	addFreeform("MOOD", input.Mood)
+	keys := make([]string, 0, len(input.Custom))
+	for key := range input.Custom {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		addFreeform(key, input.Custom[key])
+	}
+	mimeType, img, err := coverImage(o, input)
+	if err != nil {
+		return nil, o.fail(MetricErrCover, err)
+	}
+	if img != nil {
+		dataType := uint32(mp4DataJPEG)
+		if mimeType == "image/png" {
+			dataType = mp4DataPNG
+		}
+		ilst = append(ilst, mp4BoxBytes("covr", mp4Data(dataType, img))...)
+	}
+	return ilst, nil
+}
+
+// neroChapters returns the payload of a Nero chpl box listing
+// chapters, nil if there are none. Titles are cut to 255 bytes.
+func neroChapters(chapters []Chapter) ([]byte, error) {
+	if len(chapters) == 0 {
+		return nil, nil
+	}
+	if len(chapters) > 255 {
+		return nil, fmt.Errorf("%d chapters, a chpl box holds at most 255", len(chapters))
+	}
+	chpl := []byte{1, 0, 0, 0, 0, 0, 0, 0, byte(len(chapters))} // version 1, flags and reserved
+	for _, ch := range chapters {
+		start, err := StringTimeToMillis(ch.Start)
+		if err != nil {
+			return nil, err
+		}
+		title := ch.Title
+		for len(title) > 255 {
+			_, size := utf8.DecodeLastRuneInString(title)
+			title = title[:len(title)-size]
+		}
+		chpl = binary.BigEndian.AppendUint64(chpl, uint64(start)*10000) // 100 ns units
+		chpl = append(chpl, byte(len(title)))
+		chpl = append(chpl, title...)
+	}
+	return chpl, nil
+}
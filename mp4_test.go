@@ -0,0 +1,159 @@
+package id3v24
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// minimalMP4 returns an MPEG-4 file with a 3 second movie of one
+// track with one chunk of audio, the moov box before the mdat box if
+// moovFirst is set.
+func minimalMP4(audio []byte, moovFirst bool) []byte {
+	ftyp := mp4BoxBytes("ftyp", []byte("M4A \x00\x00\x00\x00M4A mp42isom"))
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000) // timescale
+	binary.BigEndian.PutUint32(mvhd[16:], 3000) // duration
+	moov := func(chunkOffset uint32) []byte {
+		stco := binary.BigEndian.AppendUint32(make([]byte, 4), 1)
+		stco = binary.BigEndian.AppendUint32(stco, chunkOffset)
+		stbl := mp4BoxBytes("stbl", mp4BoxBytes("stco", stco))
+		trak := mp4BoxBytes("trak", mp4BoxBytes("mdia", mp4BoxBytes("minf", stbl)))
+		return mp4BoxBytes("moov", append(mp4BoxBytes("mvhd", mvhd), trak...))
+	}
+	mdat := mp4BoxBytes("mdat", audio)
+	if moovFirst {
+		offset := len(ftyp) + len(moov(0)) + 8
+		return append(append(ftyp, moov(uint32(offset))...), mdat...)
+	}
+	return append(append(ftyp, mdat...), moov(uint32(len(ftyp)+8))...)
+}
+
+// mp4Moov returns the moov box of the MPEG-4 file data.
+func mp4Moov(t *testing.T, data []byte) *mp4Box {
+	t.Helper()
+	boxes, err := parseMP4Boxes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, box := range boxes {
+		if box.kind == "moov" {
+			return box
+		}
+	}
+	t.Fatal("no moov box")
+	return nil
+}
+
+// mp4ItemValue returns the value of the first data box of the item of
+// kind in the ilst payload.
+func mp4ItemValue(ilst []byte, kind string) ([]byte, bool) {
+	items, err := parseMP4Boxes(ilst)
+	if err != nil {
+		return nil, false
+	}
+	for _, item := range items {
+		if item.kind == kind && len(item.data) >= 16 && string(item.data[4:8]) == "data" {
+			return item.data[16:binary.BigEndian.Uint32(item.data)], true
+		}
+	}
+	return nil, false
+}
+
+func TestWriteMP4TagTo(t *testing.T) {
+	audio := bytes.Repeat([]byte{0x69}, 64)
+	input := TrackInfo{
+		Title:     "Hello world",
+		Track:     "3/12",
+		CoverData: []byte("\xff\xd8\xff\xe0 not quite a JPEG"),
+		Custom:    map[string]string{"NOTES": "Some notes"},
+		Chapters: []Chapter{
+			{Title: "Intro", Start: "00:00:00"},
+			{Title: "Outro", Start: "00:00:02.5"},
+		},
+	}
+	for _, moovFirst := range []bool{true, false} {
+		var out bytes.Buffer
+		if err := WriteMP4TagTo(&out, bytes.NewReader(minimalMP4(audio, moovFirst)), input); err != nil {
+			t.Fatal(err)
+		}
+		moov := mp4Moov(t, out.Bytes())
+		stco := moov.child("trak", false).child("mdia", false).child("minf", false).child("stbl", false).child("stco", false)
+		offset := binary.BigEndian.Uint32(stco.data[8:])
+		if got := out.Bytes()[offset:]; !bytes.HasPrefix(got, audio) {
+			t.Errorf("moov first %t: chunk offset %d does not point at the audio", moovFirst, offset)
+		}
+		meta := moov.child("udta", false).child("meta", false)
+		if meta.header == nil || meta.child("hdlr", false) == nil {
+			t.Fatalf("moov first %t: expected a full meta box with a handler", moovFirst)
+		}
+		ilst := meta.child("ilst", false).data
+		if title, _ := mp4ItemValue(ilst, "\xa9nam"); string(title) != input.Title {
+			t.Errorf("expected title %q, got %q", input.Title, title)
+		}
+		if track, _ := mp4ItemValue(ilst, "trkn"); !bytes.Equal(track, []byte{0, 0, 0, 3, 0, 12, 0, 0}) {
+			t.Errorf("unexpected trkn %v", track)
+		}
+		if _, ok := mp4ItemValue(ilst, "covr"); !ok {
+			t.Error("expected a covr item")
+		}
+		if !bytes.Contains(ilst, []byte("NOTES")) {
+			t.Error("expected a freeform NOTES item")
+		}
+		chpl := moov.child("udta", false).child("chpl", false)
+		if chpl == nil || chpl.data[8] != 2 || binary.BigEndian.Uint64(chpl.data[9+8+1+5:]) != 25000000 {
+			t.Errorf("unexpected chpl box %v", chpl)
+		}
+		// Re-tagging replaces the metadata.
+		var again bytes.Buffer
+		if err := WriteMP4TagTo(&again, bytes.NewReader(out.Bytes()), TrackInfo{Title: "Again"}); err != nil {
+			t.Fatal(err)
+		}
+		moov = mp4Moov(t, again.Bytes())
+		udta := moov.child("udta", false)
+		if title, _ := mp4ItemValue(udta.child("meta", false).child("ilst", false).data, "\xa9nam"); string(title) != "Again" || udta.child("chpl", false) != nil {
+			t.Errorf("expected the metadata replaced, got %q", title)
+		}
+		stco = moov.child("trak", false).child("mdia", false).child("minf", false).child("stbl", false).child("stco", false)
+		if offset := binary.BigEndian.Uint32(stco.data[8:]); !bytes.HasPrefix(again.Bytes()[offset:], audio) {
+			t.Errorf("moov first %t: chunk offset %d does not point at the audio after re-tagging", moovFirst, offset)
+		}
+	}
+	if err := WriteMP4TagTo(&bytes.Buffer{}, bytes.NewReader(minimalFLAC(nil)), input); !errors.Is(err, ErrNotMP4) {
+		t.Errorf("expected ErrNotMP4, got %v", err)
+	}
+}
+
+func TestWriteTagMP4AndOggFLAC(t *testing.T) {
+	dir := t.TempDir()
+	m4a := filepath.Join(dir, "test.m4a")
+	if err := os.WriteFile(m4a, minimalMP4([]byte{0x69}, true), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTag(m4a, TrackInfo{Title: "Hello world"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(m4a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ilst := mp4Moov(t, data).child("udta", false).child("meta", false).child("ilst", false)
+	if title, _ := mp4ItemValue(ilst.data, "\xa9nam"); string(title) != "Hello world" {
+		t.Errorf("expected a tagged MPEG-4 file, got title %q", title)
+	}
+	first := paginateOgg([][]byte{append([]byte("\x7fFLAC\x01\x00\x00\x01fLaC"), make([]byte, 38)...)}, 42, 0, 0)[0]
+	first.headerType = 0x02
+	oggFLAC := filepath.Join(dir, "test.oga")
+	if err := os.WriteFile(oggFLAC, first.bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTag(oggFLAC, TrackInfo{Title: "Hello world"}); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat, got %v", err)
+	}
+	if err := WriteTagTo(&bytes.Buffer{}, bytes.NewReader(first.bytes()), TrackInfo{}); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
@@ -0,0 +1,253 @@
+package id3v24
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var ErrNotOgg error = errors.New("not an Ogg Vorbis or Opus stream")
+
+// oggPageHeaderSize is the size of an Ogg page header without the
+// segment table.
+const oggPageHeaderSize = 27
+
+// oggPage is a page of an Ogg bitstream (RFC 3533).
+type oggPage struct {
+	headerType byte
+	granule    uint64
+	serial     uint32
+	sequence   uint32
+	segments   []byte // lacing values
+	data       []byte
+}
+
+// readOggPage reads the next page of r. It returns io.EOF at the end
+// of r and ErrNotOgg for anything that is not a complete page.
+func readOggPage(r io.Reader) (*oggPage, error) {
+	header := make([]byte, oggPageHeaderSize)
+	if n, err := io.ReadFull(r, header); err != nil {
+		if n == 0 && err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, ErrNotOgg
+	}
+	if string(header[0:4]) != "OggS" || header[4] != 0 {
+		return nil, ErrNotOgg
+	}
+	p := &oggPage{
+		headerType: header[5],
+		granule:    binary.LittleEndian.Uint64(header[6:14]),
+		serial:     binary.LittleEndian.Uint32(header[14:18]),
+		sequence:   binary.LittleEndian.Uint32(header[18:22]),
+		segments:   make([]byte, header[26]),
+	}
+	if _, err := io.ReadFull(r, p.segments); err != nil {
+		return nil, ErrNotOgg
+	}
+	size := 0
+	for _, s := range p.segments {
+		size += int(s)
+	}
+	p.data = make([]byte, size)
+	if _, err := io.ReadFull(r, p.data); err != nil {
+		return nil, ErrNotOgg
+	}
+	return p, nil
+}
+
+// bytes returns p as written to a stream, with its CRC computed.
+func (p *oggPage) bytes() []byte {
+	b := append([]byte("OggS"), 0, p.headerType)
+	b = binary.LittleEndian.AppendUint64(b, p.granule)
+	b = binary.LittleEndian.AppendUint32(b, p.serial)
+	b = binary.LittleEndian.AppendUint32(b, p.sequence)
+	b = append(b, 0, 0, 0, 0, byte(len(p.segments)))
+	b = append(b, p.segments...)
+	b = append(b, p.data...)
+	binary.LittleEndian.PutUint32(b[22:26], oggCRC(b))
+	return b
+}
+
+// oggCRCTable is the table of the CRC-32 of Ogg pages, polynomial
+// 0x04C11DB7 without bit reflection.
+var oggCRCTable = func() (table [256]uint32) {
+	for i := range table {
+		crc := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04C11DB7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+func oggCRC(b []byte) uint32 {
+	var crc uint32
+	for _, c := range b {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^c]
+	}
+	return crc
+}
+
+// paginateOgg laces packets one after the other into pages of the
+// stream serial, numbered from sequence, of at most 255 segments. The
+// last page has the granule position granule and the others none
+// (-1), as for the header pages of a stream.
+func paginateOgg(packets [][]byte, serial, sequence uint32, granule uint64) []*oggPage {
+	var lacing []byte
+	var data []byte
+	for _, packet := range packets {
+		for n := len(packet); ; n -= 255 {
+			if n < 255 {
+				lacing = append(lacing, byte(n))
+				break
+			}
+			lacing = append(lacing, 255)
+		}
+		data = append(data, packet...)
+	}
+	var pages []*oggPage
+	continued := false
+	for len(lacing) > 0 {
+		n := min(len(lacing), 255)
+		p := &oggPage{serial: serial, sequence: sequence, granule: ^uint64(0), segments: lacing[:n]}
+		if continued {
+			p.headerType = 0x01
+		}
+		size := 0
+		for _, s := range p.segments {
+			size += int(s)
+		}
+		p.data, data = data[:size], data[size:]
+		continued = p.segments[n-1] == 255
+		lacing = lacing[n:]
+		pages = append(pages, p)
+		sequence++
+	}
+	if len(pages) > 0 {
+		pages[len(pages)-1].granule = granule
+	}
+	return pages
+}
+
+// WriteOggTag writes input as Vorbis comments (see VorbisComments) to
+// the Ogg Vorbis or Opus file oggfile, replacing the existing
+// comments but keeping their vendor string. The cover is embedded as
+// a METADATA_BLOCK_PICTURE comment. The file will be modified.
+func WriteOggTag(oggfile string, input TrackInfo, opts ...Option) error {
+	return rewriteFile(newOptions(opts...), oggfile, func(w io.Writer, r io.ReadSeeker) error {
+		return WriteOggTagTo(w, r, input, opts...)
+	})
+}
+
+// WriteOggTagTo is the io-only variant of WriteOggTag, reading the
+// Ogg stream from r and writing the tagged stream to w. The header
+// pages of the first logical stream are rewritten and the pages after
+// them renumbered, multiplexed and chained streams return ErrNotOgg.
+func WriteOggTagTo(w io.Writer, r io.Reader, input TrackInfo, opts ...Option) error {
+	o := newOptions(opts...)
+	first, err := readOggPage(r)
+	if err != nil || first.headerType&0x02 == 0 {
+		return ErrNotOgg
+	}
+	// The identification header is alone on the first page.
+	var magic []byte
+	headers := 2
+	switch {
+	case bytes.HasPrefix(first.data, []byte("\x01vorbis")):
+		magic, headers = []byte("\x03vorbis"), 3
+	case bytes.HasPrefix(first.data, []byte("OpusHead")):
+		magic = []byte("OpusTags")
+	default:
+		return ErrNotOgg
+	}
+	if len(first.segments) == 0 || first.segments[len(first.segments)-1] == 255 {
+		return ErrNotOgg
+	}
+	// Read the other header packets, which end a page before the
+	// audio begins.
+	var packets [][]byte
+	var packet []byte
+	sequence := first.sequence + 1
+	for len(packets) < headers-1 {
+		p, err := readOggPage(r)
+		if err != nil || p.serial != first.serial {
+			return ErrNotOgg
+		}
+		offset := 0
+		for _, s := range p.segments {
+			if len(packets) == headers-1 {
+				return ErrNotOgg // audio on a header page
+			}
+			packet = append(packet, p.data[offset:offset+int(s)]...)
+			offset += int(s)
+			if s < 255 {
+				packets = append(packets, packet)
+				packet = nil
+			}
+		}
+		sequence = p.sequence + 1
+	}
+	if len(packet) > 0 || !bytes.HasPrefix(packets[0], magic) {
+		return ErrNotOgg
+	}
+	vendor := "id3v24"
+	v, oldComments, ok := parseVorbisComments(packets[0][len(magic):])
+	if ok {
+		vendor = v
+	}
+	comments, err := VorbisComments(input, opts...)
+	if err != nil {
+		return o.fail(MetricErrCover, err)
+	}
+	record := o.fieldJournalRecord(r, vorbisFields(oldComments), vorbisFields(comments))
+	vc := append([]byte{}, magic...)
+	vc = binary.LittleEndian.AppendUint32(vc, uint32(len(vendor)))
+	vc = append(vc, vendor...)
+	vc = binary.LittleEndian.AppendUint32(vc, uint32(len(comments)))
+	for _, c := range comments {
+		vc = binary.LittleEndian.AppendUint32(vc, uint32(len(c)))
+		vc = append(vc, c...)
+	}
+	if headers == 3 {
+		vc = append(vc, 0x01) // framing bit
+	}
+	sizes := []FrameSize{{ID: "VORBIS_COMMENT", Count: 1, Size: len(vc)}}
+	if err := o.checkTagSize(len(vc), func() []FrameSize { return sizes }); err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	packets[0] = vc
+	pages := append([]*oggPage{first}, paginateOgg(packets, first.serial, first.sequence+1, 0)...)
+	for _, p := range pages {
+		if _, err := w.Write(p.bytes()); err != nil {
+			return o.fail(MetricErrSave, err)
+		}
+	}
+	// Renumber the pages of the stream after the headers.
+	shift := pages[len(pages)-1].sequence + 1 - sequence
+	for {
+		p, err := readOggPage(r)
+		if err == io.EOF {
+			if err := o.writeJournal(record); err != nil {
+				return o.fail(MetricErrSave, err)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if p.serial != first.serial {
+			return ErrNotOgg
+		}
+		p.sequence += shift
+		if _, err := w.Write(p.bytes()); err != nil {
+			return o.fail(MetricErrSave, err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package id3v24
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// minimalOgg returns an Ogg stream with the identification header of
+// codec ("vorbis" or "opus"), the other header packets on one page and
+// a page of audio.
+func minimalOgg(codec string, audio []byte) []byte {
+	comments := binary.LittleEndian.AppendUint32(nil, 6)
+	comments = append(comments, "vendor"...)
+	comments = binary.LittleEndian.AppendUint32(comments, 1)
+	comments = binary.LittleEndian.AppendUint32(comments, 9)
+	comments = append(comments, "TITLE=Old"...)
+	var id []byte
+	var headers [][]byte
+	if codec == "vorbis" {
+		id = append([]byte("\x01vorbis"), make([]byte, 23)...)
+		headers = [][]byte{append(append([]byte("\x03vorbis"), comments...), 0x01), []byte("\x05vorbis setup")}
+	} else {
+		id = append([]byte("OpusHead"), make([]byte, 11)...)
+		headers = [][]byte{append([]byte("OpusTags"), comments...)}
+	}
+	first := paginateOgg([][]byte{id}, 42, 0, 0)[0]
+	first.headerType = 0x02
+	stream := first.bytes()
+	for _, p := range paginateOgg(headers, 42, 1, 0) {
+		stream = append(stream, p.bytes()...)
+	}
+	last := paginateOgg([][]byte{audio}, 42, 2, 48000)[0]
+	last.headerType = 0x04
+	return append(stream, last.bytes()...)
+}
+
+// oggPackets returns the packets and pages of stream, failing t on
+// bad CRCs.
+func oggPackets(t *testing.T, stream []byte) (packets [][]byte, pages []*oggPage) {
+	t.Helper()
+	r := bytes.NewReader(stream)
+	var packet []byte
+	for {
+		offset := len(stream) - r.Len()
+		p, err := readOggPage(r)
+		if err == io.EOF {
+			return packets, pages
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if raw := stream[offset : len(stream)-r.Len()]; !bytes.Equal(p.bytes(), raw) {
+			t.Fatalf("bad CRC of page %d", p.sequence)
+		}
+		pages = append(pages, p)
+		offset = 0
+		for _, s := range p.segments {
+			packet = append(packet, p.data[offset:offset+int(s)]...)
+			offset += int(s)
+			if s < 255 {
+				packets = append(packets, packet)
+				packet = nil
+			}
+		}
+	}
+}
+
+func TestWriteOggTagTo(t *testing.T) {
+	audio := bytes.Repeat([]byte{0x69}, 64)
+	input := TrackInfo{
+		Title:  "Hello world",
+		Custom: map[string]string{"NOTES": strings.Repeat("x", 70000)}, // spans two pages
+	}
+	for _, codec := range []string{"vorbis", "opus"} {
+		var out bytes.Buffer
+		if err := WriteOggTagTo(&out, bytes.NewReader(minimalOgg(codec, audio)), input); err != nil {
+			t.Fatalf("%s: %v", codec, err)
+		}
+		packets, pages := oggPackets(t, out.Bytes())
+		headers := 3
+		if codec == "opus" {
+			headers = 2
+		}
+		if len(packets) != headers+1 {
+			t.Fatalf("%s: expected %d packets, got %d", codec, headers+1, len(packets))
+		}
+		comments := string(packets[1])
+		if !strings.Contains(comments, "vendor") || !strings.Contains(comments, "TITLE=Hello world") || strings.Contains(comments, "TITLE=Old") {
+			t.Errorf("%s: unexpected comment header %.80q", codec, comments)
+		}
+		if !bytes.Equal(packets[len(packets)-1], audio) {
+			t.Errorf("%s: audio was not copied unmodified", codec)
+		}
+		if len(pages) != 4 {
+			t.Errorf("%s: expected the comments on two pages, got %d pages", codec, len(pages))
+		}
+		for i, p := range pages {
+			if p.sequence != uint32(i) {
+				t.Errorf("%s: expected page %d to have sequence %d, got %d", codec, i, i, p.sequence)
+			}
+		}
+		if last := pages[len(pages)-1]; last.granule != 48000 || last.headerType != 0x04 {
+			t.Errorf("%s: expected the audio page kept, got granule %d and type %d", codec, last.granule, last.headerType)
+		}
+		// Re-tagging replaces the comments again.
+		var again bytes.Buffer
+		if err := WriteOggTagTo(&again, bytes.NewReader(out.Bytes()), TrackInfo{Title: "Again"}); err != nil {
+			t.Fatal(err)
+		}
+		if packets, _ := oggPackets(t, again.Bytes()); len(packets) != headers+1 || !strings.Contains(string(packets[1]), "TITLE=Again") {
+			t.Errorf("%s: expected a re-tagged stream", codec)
+		}
+	}
+	if err := WriteOggTagTo(io.Discard, bytes.NewReader(minimalFLAC(nil)), input); !errors.Is(err, ErrNotOgg) {
+		t.Errorf("expected ErrNotOgg, got %v", err)
+	}
+}
+
+func TestWriteTagOgg(t *testing.T) {
+	oggfile := filepath.Join(t.TempDir(), "test.opus")
+	if err := os.WriteFile(oggfile, minimalOgg("opus", []byte{0x69}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTag(oggfile, TrackInfo{Title: "Hello world"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(oggfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packets, _ := oggPackets(t, data); len(packets) != 3 || !strings.Contains(string(packets[1]), "TITLE=Hello world") {
+		t.Errorf("expected a tagged Opus file, got %d packets", len(packets))
+	}
+}
@@ -0,0 +1,117 @@
+package id3v24
+
+import (
+	"io/fs"
+	"os"
+	"time"
+)
+
+// Option configures optional behaviour of the functions in this
+// package that accept a variadic list of options, e.g
+// WriteID3v2Tag. Options not relevant to a function are ignored.
+type Option func(*options)
+
+type options struct {
+	metrics  Metrics
+	fsys     fs.FS
+	warnings func(error)
+	files    *fileCache
+	journal  func(JournalRecord) error
+	undo     bool
+	output   string
+
+	tagSnapshot bool
+	tagMerge    bool
+	dedup       DedupRule
+	cleanStream bool
+	provenance  *Provenance
+	strict      bool
+
+	yearFromDate bool
+
+	ffmetadataKeys         []string
+	ffmetadataDateFirst    bool
+	noSynthesizedCopyright bool
+	timebase               int64
+
+	seratoCues  bool
+	chapterTXXX bool
+
+	useTLEN       bool
+	tlenTolerance time.Duration
+	writeTLEN     bool
+	exactDuration bool
+
+	chapterAutoFix  bool
+	chapterSort     bool
+	chapterClamp    bool
+	sidecarChapters bool
+	chapterMerge    bool
+	chapterArt      *TitleCard
+	coverArt        *TitleCard
+
+	loudnessTarget float64
+
+	descriptionFrames DescriptionFrame
+
+	maxTagSize int
+
+	textEncoding *TextEncoding
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		loudnessTarget:    DefaultLoudnessTarget,
+		descriptionFrames: DescriptionTDES,
+		dedup:             DedupAll,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// WithMetrics reports counters and observations to m. See Metrics.
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithWarnings calls fn with every non-fatal problem found while
+// writing, e.g a *DurationMismatchError. Warnings are otherwise
+// ignored.
+func WithWarnings(fn func(error)) Option {
+	return func(o *options) {
+		o.warnings = fn
+	}
+}
+
+func (o *options) warn(err error) {
+	if o.warnings != nil {
+		o.warnings(err)
+	}
+}
+
+// WithFS resolves paths in TrackInfo (e.g CoverJPEG) through fsys
+// instead of the operating system, which makes it possible to tag
+// files entirely in memory, for example in a js/wasm build.
+func WithFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.fsys = fsys
+	}
+}
+
+// readFile reads name from the file system given by WithFS, or from
+// the operating system if none was given.
+func (o *options) readFile(name string) ([]byte, error) {
+	if o.fsys != nil {
+		return fs.ReadFile(o.fsys, name)
+	}
+	if o.files != nil {
+		return o.files.readFile(name)
+	}
+	return os.ReadFile(name)
+}
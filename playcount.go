@@ -0,0 +1,59 @@
+package id3v24
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// TagPlayCount returns the play count of the PCNT frame of tag. ok is
+// false if tag has none. Counters beyond 64 bits are capped.
+func TagPlayCount(tag *id3v2.Tag) (count uint64, ok bool) {
+	f, isUnknown := tag.GetLastFrame("PCNT").(id3v2.UnknownFrame)
+	if !isUnknown || len(f.Body) == 0 {
+		return 0, false
+	}
+	for _, b := range f.Body {
+		if count > ^uint64(0)>>8 {
+			return ^uint64(0), true
+		}
+		count = count<<8 | uint64(b)
+	}
+	return count, true
+}
+
+// SetPlayCount sets the PCNT (play counter) frame of tag to count,
+// encoded in 4 bytes or, once it needs more, 8, replacing any earlier
+// PCNT frame.
+func SetPlayCount(tag *id3v2.Tag, count uint64) {
+	var body []byte
+	if count <= 1<<32-1 {
+		body = binary.BigEndian.AppendUint32(nil, uint32(count))
+	} else {
+		body = binary.BigEndian.AppendUint64(nil, count)
+	}
+	// PCNT is a sequence of unknown frames to id3v2, each with a
+	// unique identifier, so AddFrame alone would add one more.
+	tag.DeleteFrames("PCNT")
+	tag.AddFrame("PCNT", id3v2.UnknownFrame{Body: body})
+}
+
+// IncrementPlayCount adds one to the PCNT frame of mp3file (adding
+// the frame if it has none) in place and returns the new count, e.g
+// for audiobook players tracking what has been listened to. The rest
+// of the tag and the audio are kept as is.
+func IncrementPlayCount(mp3file string, opts ...Option) (uint64, error) {
+	o := newOptions(opts...)
+	var count uint64
+	err := updateLeadingTag(o, mp3file, func(tag *id3v2.Tag) error {
+		count, _ = TagPlayCount(tag)
+		if count == ^uint64(0) {
+			return fmt.Errorf("%w: PCNT at its maximum", ErrBadFrame)
+		}
+		count++
+		SetPlayCount(tag, count)
+		return nil
+	})
+	return count, err
+}
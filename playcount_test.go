@@ -0,0 +1,50 @@
+package id3v24
+
+import (
+	"testing"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+func TestIncrementPlayCount(t *testing.T) {
+	name := copyTestMP3(t)
+	if err := WriteID3v2Tag(name, TrackInfo{Title: "Chapter 1", Chapters: []Chapter{{Title: "Start", Start: "0"}}}); err != nil {
+		t.Fatal(err)
+	}
+	for expected := uint64(1); expected <= 3; expected++ {
+		count, err := IncrementPlayCount(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != expected {
+			t.Errorf("expected %d, got %d", expected, count)
+		}
+	}
+	input, err := ReadID3v2Tag(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.PlayCount != 3 || input.Title != "Chapter 1" || len(input.Chapters) != 1 {
+		t.Errorf("expected play count 3 with the tag kept, got %+v", input)
+	}
+	written, err := id3v2.Open(name, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frames := written.GetFrames("PCNT"); len(frames) != 1 {
+		t.Errorf("expected one PCNT frame, got %d", len(frames))
+	}
+	written.Close()
+
+	tag := id3v2.NewEmptyTag()
+	if _, ok := TagPlayCount(tag); ok {
+		t.Error("expected no play count")
+	}
+	SetPlayCount(tag, 1<<40)
+	if f := tag.GetLastFrame("PCNT").(id3v2.UnknownFrame); len(f.Body) != 8 {
+		t.Errorf("expected an 8 byte counter, got %d bytes", len(f.Body))
+	}
+	if count, ok := TagPlayCount(tag); !ok || count != 1<<40 {
+		t.Errorf("expected %d, got %d", uint64(1<<40), count)
+	}
+}
@@ -0,0 +1,217 @@
+package id3v24
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var ErrBadPlist error = errors.New("malformed plist")
+
+const plistHeader = xml.Header + `<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n"
+
+// ChapterPlist returns chapters as an XML property list, the way
+// macOS audiobook tools exchange chapter start times: a dict with a
+// "chapters" array of dicts with the "start time" in seconds (a real),
+// the "title" and, where set, the "end time" and "url" of a chapter.
+// Nested chapters are flattened, see FlattenChapters.
+func ChapterPlist(chapters []Chapter) ([]byte, error) {
+	chapters = FlattenChapters(chapters)
+	var b bytes.Buffer
+	b.WriteString(plistHeader)
+	b.WriteString("<plist version=\"1.0\">\n<dict>\n\t<key>chapters</key>\n\t<array>\n")
+	seconds := func(t string) (string, error) {
+		d, err := StringTimeToDuration(t)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatFloat(d.Seconds(), 'f', -1, 64), nil
+	}
+	entry := func(key, typ, value string) {
+		b.WriteString("\t\t\t<key>")
+		xml.EscapeText(&b, []byte(key))
+		fmt.Fprintf(&b, "</key>\n\t\t\t<%s>", typ)
+		xml.EscapeText(&b, []byte(value))
+		fmt.Fprintf(&b, "</%s>\n", typ)
+	}
+	for i, ch := range chapters {
+		start, err := seconds(ch.Start)
+		if err != nil {
+			return nil, fmt.Errorf("chapter %d: %w", i+1, err)
+		}
+		b.WriteString("\t\t<dict>\n")
+		entry("start time", "real", start)
+		entry("title", "string", ch.Title)
+		if ch.End != "" {
+			end, err := seconds(ch.End)
+			if err != nil {
+				return nil, fmt.Errorf("chapter %d: %w", i+1, err)
+			}
+			entry("end time", "real", end)
+		}
+		if ch.URL != "" {
+			entry("url", "string", ch.URL)
+		}
+		b.WriteString("\t\t</dict>\n")
+	}
+	b.WriteString("\t</array>\n</dict>\n</plist>\n")
+	return b.Bytes(), nil
+}
+
+// ParseChapterPlist returns the chapters of the XML property list in
+// r, see ChapterPlist. Also accepted are a top-level array of chapter
+// dicts, "name" for "title", "start" or "startTime" for "start time"
+// (likewise for the end) and times as strings, e.g "00:05:00.500".
+// Keys are matched case insensitively.
+func ParseChapterPlist(r io.Reader) ([]Chapter, error) {
+	d := xml.NewDecoder(r)
+	var root any
+	for root == nil {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil, ErrBadPlist
+		} else if err != nil {
+			return nil, err
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local != "plist" {
+			if root, err = decodePlistValue(d, start); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if dict, ok := root.(map[string]any); ok {
+		root = plistLookup(dict, "chapters")
+	}
+	list, ok := root.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: no chapters array", ErrBadPlist)
+	}
+	chapters := make([]Chapter, 0, len(list))
+	for i, v := range list {
+		dict, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: chapter %d is not a dict", ErrBadPlist, i+1)
+		}
+		var ch Chapter
+		for _, key := range []string{"title", "name"} {
+			if s, ok := plistLookup(dict, key).(string); ok {
+				ch.Title = strings.TrimSpace(s)
+				break
+			}
+		}
+		start, err := plistTime(plistLookup(dict, "starttime", "start"))
+		if err != nil {
+			return nil, fmt.Errorf("chapter %d: %w", i+1, err)
+		}
+		ch.Start = MillisToStringTime(start)
+		if v := plistLookup(dict, "endtime", "end"); v != nil {
+			end, err := plistTime(v)
+			if err != nil {
+				return nil, fmt.Errorf("chapter %d: %w", i+1, err)
+			}
+			ch.End = MillisToStringTime(end)
+		}
+		if s, ok := plistLookup(dict, "url", "link").(string); ok {
+			ch.URL = strings.TrimSpace(s)
+		}
+		chapters = append(chapters, ch)
+	}
+	return chapters, nil
+}
+
+// plistLookup returns the value of the first of keys in dict, ignoring
+// case, spaces and underscores in the keys of dict.
+func plistLookup(dict map[string]any, keys ...string) any {
+	normalize := strings.NewReplacer(" ", "", "_", "")
+	for _, key := range keys {
+		for k, v := range dict {
+			if strings.EqualFold(normalize.Replace(k), key) {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// plistTime returns the seconds (or time string) v in milliseconds.
+func plistTime(v any) (uint32, error) {
+	switch t := v.(type) {
+	case float64:
+		if t >= 0 && t*1000 < 1<<32 {
+			return uint32(t*1000 + 0.5), nil
+		}
+	case int64:
+		if t >= 0 && t*1000 < 1<<32 {
+			return uint32(t * 1000), nil
+		}
+	case string:
+		return StringTimeToMillis(strings.TrimSpace(t))
+	case nil:
+		return 0, fmt.Errorf("%w: no start time", ErrBadPlist)
+	}
+	return 0, fmt.Errorf("%w: bad time %v", ErrBadPlist, v)
+}
+
+// decodePlistValue decodes the plist value of the element start as a
+// map[string]any (dict), []any (array), string (string, date and
+// base64 data), float64 (real), int64 (integer) or bool.
+func decodePlistValue(d *xml.Decoder, start xml.StartElement) (any, error) {
+	switch start.Name.Local {
+	case "dict", "array":
+		dict, list := map[string]any{}, []any{}
+		key, haveKey := "", false
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.EndElement:
+				if start.Name.Local == "dict" {
+					return dict, nil
+				}
+				return list, nil
+			case xml.StartElement:
+				if start.Name.Local == "dict" && !haveKey {
+					if t.Name.Local != "key" {
+						return nil, fmt.Errorf("%w: expected key in dict, got %s", ErrBadPlist, t.Name.Local)
+					}
+					if err := d.DecodeElement(&key, &t); err != nil {
+						return nil, err
+					}
+					haveKey = true
+					continue
+				}
+				v, err := decodePlistValue(d, t)
+				if err != nil {
+					return nil, err
+				}
+				if start.Name.Local == "dict" {
+					dict[key], haveKey = v, false
+				} else {
+					list = append(list, v)
+				}
+			}
+		}
+	}
+	var text string
+	if err := d.DecodeElement(&text, &start); err != nil {
+		return nil, err
+	}
+	text = strings.TrimSpace(text)
+	switch start.Name.Local {
+	case "string", "date", "data":
+		return text, nil
+	case "real":
+		return strconv.ParseFloat(text, 64)
+	case "integer":
+		return strconv.ParseInt(text, 10, 64)
+	case "true", "false":
+		return start.Name.Local == "true", nil
+	}
+	return nil, fmt.Errorf("%w: unknown element %s", ErrBadPlist, start.Name.Local)
+}
@@ -0,0 +1,59 @@
+package id3v24
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChapterPlist(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Intro & welcome", Start: "00:00:00"},
+		{Title: "Main", Start: "00:01:05.250", End: "00:10:00", URL: "https://example.com/main"},
+	}
+	data, err := ChapterPlist(chapters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "<key>start time</key>\n\t\t\t<real>65.25</real>") {
+		t.Errorf("expected the start in seconds, got:\n%s", data)
+	}
+	parsed, err := ParseChapterPlist(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Chapter{
+		{Title: "Intro & welcome", Start: "00:00:00.000"},
+		{Title: "Main", Start: "00:01:05.250", End: "00:10:00.000", URL: "https://example.com/main"},
+	}
+	if !reflect.DeepEqual(parsed, expected) {
+		t.Errorf("expected %v, got %v", expected, parsed)
+	}
+
+	// A top-level array with other keys and time types.
+	parsed, err = ParseChapterPlist(strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<array>
+	<dict><key>Name</key><string>One</string><key>startTime</key><integer>0</integer><key>flag</key><true/></dict>
+	<dict><key>Name</key><string>Two</string><key>startTime</key><string>5:30</string></dict>
+</array>
+</plist>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []Chapter{{Title: "One", Start: "00:00:00.000"}, {Title: "Two", Start: "00:05:30.000"}}
+	if !reflect.DeepEqual(parsed, expected) {
+		t.Errorf("expected %v, got %v", expected, parsed)
+	}
+
+	for _, doc := range []string{
+		`<plist version="1.0"><dict><key>other</key><string>x</string></dict></plist>`,
+		`<plist version="1.0"><array><dict><key>title</key><string>No start</string></dict></array></plist>`,
+		`<plist version="1.0"><array><dict><string>no key</string></dict></array></plist>`,
+	} {
+		if _, err := ParseChapterPlist(strings.NewReader(doc)); !errors.Is(err, ErrBadPlist) {
+			t.Errorf("%s: expected ErrBadPlist, got %v", doc, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package id3v24
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrNoPodcastChapters error = errors.New("episode has no chapters URL")
+
+// PodcastIndexBaseURL is the default base URL of the Podcast Index
+// API.
+const PodcastIndexBaseURL = "https://api.podcastindex.org/api/1.0"
+
+// PodcastIndexClient fetches the official chapters of episodes, the
+// Podcasting 2.0 JSON chapters file advertised by the publisher, via
+// the Podcast Index API (https://podcastindex-org.github.io/docs-api/),
+// e.g to enrich archived downloads. Key and Secret are the API
+// credentials.
+type PodcastIndexClient struct {
+	Key, Secret string
+	UserAgent   string       // default "id3v24"
+	BaseURL     string       // default PodcastIndexBaseURL
+	HTTPClient  *http.Client // default http.DefaultClient
+}
+
+// EpisodeChapters returns the chapters of the episode with the Podcast
+// Index id, see ParsePodcastChapters. Returns ErrNoPodcastChapters if
+// the episode has no chapters URL.
+func (c *PodcastIndexClient) EpisodeChapters(ctx context.Context, id int64) ([]Chapter, error) {
+	return c.episodeChapters(ctx, "episodes/byid", url.Values{"id": {strconv.FormatInt(id, 10)}})
+}
+
+// EpisodeChaptersByGUID is EpisodeChapters for the episode with the
+// RSS guid in the feed at feedURL.
+func (c *PodcastIndexClient) EpisodeChaptersByGUID(ctx context.Context, guid, feedURL string) ([]Chapter, error) {
+	return c.episodeChapters(ctx, "episodes/byguid", url.Values{"guid": {guid}, "feedurl": {feedURL}})
+}
+
+// ChapterSource returns the chapters of the episode with the Podcast
+// Index id as a ChapterSource named "podcastindex", for
+// ResolveChapters. An episode without chapters has none rather than
+// an error.
+func (c *PodcastIndexClient) ChapterSource(ctx context.Context, id int64) ChapterSource {
+	return ChapterSource{Name: "podcastindex", Load: func() ([]Chapter, error) {
+		chapters, err := c.EpisodeChapters(ctx, id)
+		if errors.Is(err, ErrNoPodcastChapters) {
+			return nil, nil
+		}
+		return chapters, err
+	}}
+}
+
+func (c *PodcastIndexClient) episodeChapters(ctx context.Context, endpoint string, query url.Values) ([]Chapter, error) {
+	base := c.BaseURL
+	if base == "" {
+		base = PodcastIndexBaseURL
+	}
+	var episode struct {
+		Episode struct {
+			ChaptersURL string `json:"chaptersUrl"`
+		} `json:"episode"`
+	}
+	body, err := c.get(ctx, strings.TrimSuffix(base, "/")+"/"+endpoint+"?"+query.Encode(), true)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, &episode); err != nil {
+		return nil, fmt.Errorf("podcast index: %w", err)
+	}
+	if episode.Episode.ChaptersURL == "" {
+		return nil, ErrNoPodcastChapters
+	}
+	body, err = c.get(ctx, episode.Episode.ChaptersURL, false)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePodcastChapters(bytes.NewReader(body))
+}
+
+// get returns the body of a GET of rawURL, signed with the API
+// credentials if auth is set.
+func (c *PodcastIndexClient) get(ctx context.Context, rawURL string, auth bool) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	userAgent := c.UserAgent
+	if userAgent == "" {
+		userAgent = "id3v24"
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if auth {
+		date := strconv.FormatInt(time.Now().Unix(), 10)
+		sum := sha1.Sum([]byte(c.Key + c.Secret + date))
+		req.Header.Set("X-Auth-Key", c.Key)
+		req.Header.Set("X-Auth-Date", date)
+		req.Header.Set("Authorization", hex.EncodeToString(sum[:]))
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", rawURL, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// ParsePodcastChapters returns the chapters of the Podcasting 2.0
+// JSON chapters file in r, with start and end times in seconds:
+//
+//	{"version": "1.2.0", "chapters": [
+//	  {"startTime": 0, "title": "Intro", "img": "https://...", "url": "https://..."},
+//	  {"startTime": 300.5, "title": "The interview", "endTime": 1800}
+//	]}
+//
+// Chapters with "toc": false, meant to change the artwork or link
+// rather than to be navigated to, are skipped.
+func ParsePodcastChapters(r io.Reader) ([]Chapter, error) {
+	var doc struct {
+		Chapters []struct {
+			StartTime *float64 `json:"startTime"`
+			EndTime   *float64 `json:"endTime"`
+			Title     string   `json:"title"`
+			Img       string   `json:"img"`
+			URL       string   `json:"url"`
+			TOC       *bool    `json:"toc"`
+		} `json:"chapters"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	seconds := func(s float64) (string, error) {
+		if s < 0 || s*1000 >= 1<<32 {
+			return "", ErrBadChapterStartTime
+		}
+		return MillisToStringTime(uint32(s*1000 + 0.5)), nil
+	}
+	var chapters []Chapter
+	for i, ch := range doc.Chapters {
+		if ch.TOC != nil && !*ch.TOC {
+			continue
+		}
+		if ch.StartTime == nil {
+			return nil, fmt.Errorf("chapter %d: %w", i+1, ErrBadChapterStartTime)
+		}
+		start, err := seconds(*ch.StartTime)
+		if err != nil {
+			return nil, fmt.Errorf("chapter %d: %w", i+1, err)
+		}
+		chapter := Chapter{Title: strings.TrimSpace(ch.Title), Start: start, Image: ch.Img, URL: ch.URL}
+		if ch.EndTime != nil {
+			if chapter.End, err = seconds(*ch.EndTime); err != nil {
+				return nil, fmt.Errorf("chapter %d: %w", i+1, ErrBadChapterEndTime)
+			}
+		}
+		chapters = append(chapters, chapter)
+	}
+	return chapters, nil
+}
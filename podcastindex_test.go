@@ -0,0 +1,65 @@
+package id3v24
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestPodcastIndexClient(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/episodes/byid":
+			sum := sha1.Sum([]byte("key" + "secret" + r.Header.Get("X-Auth-Date")))
+			if r.Header.Get("X-Auth-Key") != "key" || r.Header.Get("Authorization") != hex.EncodeToString(sum[:]) {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			chaptersURL := ""
+			if r.URL.Query().Get("id") == "42" {
+				chaptersURL = server.URL + "/chapters.json"
+			}
+			fmt.Fprintf(w, `{"status": "true", "episode": {"id": 42, "chaptersUrl": %q}}`, chaptersURL)
+		case "/chapters.json":
+			fmt.Fprint(w, `{"version": "1.2.0", "chapters": [
+				{"startTime": 0, "title": "Intro", "img": "https://example.com/intro.jpg"},
+				{"startTime": 120, "title": "Artwork change", "toc": false},
+				{"startTime": 300.5, "title": "The interview", "url": "https://example.com", "endTime": 1800}
+			]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := &PodcastIndexClient{Key: "key", Secret: "secret", BaseURL: server.URL}
+	chapters, err := client.EpisodeChapters(context.Background(), 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Chapter{
+		{Title: "Intro", Start: "00:00:00.000", Image: "https://example.com/intro.jpg"},
+		{Title: "The interview", Start: "00:05:00.500", End: "00:30:00.000", URL: "https://example.com"},
+	}
+	if !reflect.DeepEqual(chapters, expected) {
+		t.Errorf("expected %v, got %v", expected, chapters)
+	}
+	if _, err := client.EpisodeChapters(context.Background(), 7); !errors.Is(err, ErrNoPodcastChapters) {
+		t.Errorf("expected %v, got %v", ErrNoPodcastChapters, err)
+	}
+	resolved, err := ResolveChapters([]ChapterSource{client.ChapterSource(context.Background(), 7), client.ChapterSource(context.Background(), 42)}, false)
+	if err != nil || resolved.Source != "podcastindex" || len(resolved.Chapters) != 2 {
+		t.Errorf("expected the chapters of episode 42, got %+v, %v", resolved, err)
+	}
+	client.Secret = "wrong"
+	if _, err := client.EpisodeChapters(context.Background(), 42); err == nil {
+		t.Error("expected an error for bad credentials")
+	}
+}
@@ -0,0 +1,174 @@
+package id3v24
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+
+	"github.com/sa6mwa/mp3duration"
+	"github.com/tcolgate/mp3"
+)
+
+var ErrInexactDuration error = errors.New("duration of the audio is not exact")
+
+// DurationAccuracy tells how far the duration of a ProbeInfo can be
+// trusted.
+type DurationAccuracy int
+
+const (
+	// DurationExact is a duration from counting every frame of a
+	// stream without gaps that agrees with its Xing, Info or VBRI
+	// header, if any.
+	DurationExact DurationAccuracy = iota
+	// DurationEstimated is a duration from a stream where data
+	// between frames had to be skipped to find the next frame, or
+	// where the frame count disagrees with the Xing, Info or VBRI
+	// header, i.e a damaged or sketchy file.
+	DurationEstimated
+)
+
+func (a DurationAccuracy) String() string {
+	if a == DurationExact {
+		return "exact"
+	}
+	return "estimated"
+}
+
+// WithExactDuration makes WriteID3v2Tag fail with ErrInexactDuration
+// if the scanned duration of the audio is not DurationExact, rather
+// than calculating the end of the last chapter from it. The audio is
+// then scanned even with WithTLEN, the TLEN frame is still used if
+// present.
+func WithExactDuration() Option {
+	return func(o *options) {
+		o.exactDuration = true
+	}
+}
+
+// ProbeInfo is what Probe found out about an MP3 file. The embedded
+// mp3duration.Info holds the duration, frame count and, in Length, the
+// size of the file in bytes, e.g for an RSS enclosure length.
+type ProbeInfo struct {
+	mp3duration.Info
+	MIMEType    string // always audio/mpeg, e.g for an RSS enclosure type
+	Bitrate     int    // average bit rate of the audio in bits per second
+	SampleRate  int    // sample rate in Hz of the first frame
+	ChannelMode string // Stereo, JointStereo, DualChannel or SingleChannel
+	VBR         bool   // frames have different bit rates
+	// Accuracy tells whether the duration is exact, see
+	// DurationAccuracy. HeaderFrames is the frame count of the Xing,
+	// Info or VBRI header of the stream (0 if it has none) and
+	// Skipped the number of bytes skipped between frames.
+	Accuracy     DurationAccuracy
+	HeaderFrames int
+	Skipped      int64
+	// EmbeddedTags is the number of ID3v2, ID3v1 and APE tags
+	// between frames, found in chained (concatenated) MP3 streams.
+	// They are skipped, not scanned for frames.
+	EmbeddedTags int
+}
+
+// Probe reads mp3file and returns its duration, average bit rate,
+// sample rate, channel mode and whether it is CBR or VBR.
+func Probe(mp3file string) (ProbeInfo, error) {
+	f, err := os.Open(mp3file)
+	if err != nil {
+		return ProbeInfo{}, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return ProbeInfo{}, err
+	}
+	info, err := ProbeReader(f)
+	if err != nil {
+		return info, err
+	}
+	info.Name = fi.Name()
+	info.ModTime = fi.ModTime()
+	return info, nil
+}
+
+// ProbeReader is the io-only variant of Probe, Name and ModTime are
+// left empty and Length is the number of bytes read from r.
+func ProbeReader(r io.Reader) (ProbeInfo, error) {
+	info := ProbeInfo{MIMEType: "audio/mpeg"}
+	var audioSize int64
+	var bitrate mp3.FrameBitRate
+	var next int64
+	tags := 0 // since the last frame
+	n, err := scanMP3Stream(r, func(offset int64, frame *mp3.Frame) {
+		header := frame.Header()
+		if info.Frames == 0 {
+			info.SampleRate = int(header.SampleRate())
+			info.ChannelMode = header.ChannelMode().String()
+			bitrate = header.BitRate()
+			info.HeaderFrames = vbrHeaderFrames(frame)
+		} else {
+			if header.BitRate() != bitrate {
+				info.VBR = true
+			}
+			info.Skipped += offset - next
+		}
+		if info.Frames > 0 {
+			info.EmbeddedTags += tags
+		}
+		tags = 0
+		next = offset + int64(frame.Size())
+		info.TimeDuration += frame.Duration()
+		info.Frames++
+		audioSize += int64(frame.Size())
+	}, func(offset, size int64) {
+		tags++
+		if offset == next {
+			next += size
+		}
+	})
+	if err != nil {
+		return info, err
+	}
+	info.Length = n
+	info.Seconds = info.TimeDuration.Seconds()
+	info.SecondsInt = int(math.Round(info.Seconds))
+	info.Duration = mp3duration.FormatDuration(info.TimeDuration)
+	// The Xing, Info or VBRI frame itself is not counted by its
+	// header, which only covers the first of chained streams.
+	if info.Skipped > 0 || info.HeaderFrames > 0 && info.EmbeddedTags == 0 && info.HeaderFrames != info.Frames-1 {
+		info.Accuracy = DurationEstimated
+	}
+	if info.TimeDuration > 0 {
+		info.Bitrate = int(math.Round(float64(audioSize*8) / info.TimeDuration.Seconds()))
+	}
+	return info, nil
+}
+
+// vbrHeaderFrames returns the frame count of the Xing, Info or VBRI
+// header in the first frame of a stream, or 0 if there is none.
+func vbrHeaderFrames(frame *mp3.Frame) int {
+	data, err := io.ReadAll(frame.Reader())
+	if err != nil {
+		return 0
+	}
+	sideInfo, err := frame.SideInfoLength()
+	if err != nil {
+		return 0
+	}
+	xing := 4 + sideInfo
+	if frame.Header().Protection() {
+		xing += 2 // CRC
+	}
+	if len(data) >= xing+12 && (bytes.HasPrefix(data[xing:], []byte("Xing")) || bytes.HasPrefix(data[xing:], []byte("Info"))) {
+		if flags := binary.BigEndian.Uint32(data[xing+4:]); flags&0x01 != 0 {
+			return int(binary.BigEndian.Uint32(data[xing+8:]))
+		}
+		return 0
+	}
+	const vbri = 4 + 32
+	if len(data) >= vbri+18 && bytes.HasPrefix(data[vbri:], []byte("VBRI")) {
+		return int(binary.BigEndian.Uint32(data[vbri+14:]))
+	}
+	return 0
+}
@@ -0,0 +1,116 @@
+package id3v24
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/tcolgate/mp3"
+)
+
+func TestProbe(t *testing.T) {
+	info, err := Probe("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := GetMP3Duration("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != "test.mp3" || info.Length != 48900 || info.TimeDuration != want {
+		t.Errorf("unexpected info %+v", info.Info)
+	}
+	if info.MIMEType != "audio/mpeg" || info.SampleRate != 44100 || info.ChannelMode != "SingleChannel" || info.VBR {
+		t.Errorf("unexpected info %+v", info)
+	}
+	if info.Bitrate < 127000 || info.Bitrate > 129000 {
+		t.Errorf("expected bitrate about 128000, got %d", info.Bitrate)
+	}
+}
+
+func TestGetEnclosure(t *testing.T) {
+	mp3file := copyTestMP3(t)
+	if err := WriteID3v2Tag(mp3file, TrackInfo{Title: "Hello world"}); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(mp3file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enclosure, err := GetEnclosure(mp3file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Enclosure{Length: fi.Size(), Type: "audio/mpeg", Duration: "00:00:03"}
+	if enclosure != expected || fi.Size() <= 48900 {
+		t.Errorf("expected %+v, got %+v", expected, enclosure)
+	}
+}
+
+func TestProbeAccuracy(t *testing.T) {
+	data, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := ProbeReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Accuracy != DurationExact || info.HeaderFrames != info.Frames-1 || info.Skipped != 0 {
+		t.Errorf("unexpected accuracy %v, header frames %d of %d, skipped %d", info.Accuracy, info.HeaderFrames, info.Frames, info.Skipped)
+	}
+	var offsets []int64
+	if _, err := scanMP3Frames(bytes.NewReader(data), func(offset int64, _ *mp3.Frame) { offsets = append(offsets, offset) }); err != nil {
+		t.Fatal(err)
+	}
+	junk := append(append(append([]byte{}, data[:offsets[10]]...), "junk"...), data[offsets[10]:]...)
+	info, err = ProbeReader(bytes.NewReader(junk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Accuracy != DurationEstimated || info.Skipped != 4 {
+		t.Errorf("unexpected accuracy %v, skipped %d", info.Accuracy, info.Skipped)
+	}
+	input := TrackInfo{Chapters: []Chapter{{Title: "Chapter 1", Start: "00:00:00"}}}
+	if err := WriteID3v2TagTo(io.Discard, bytes.NewReader(junk), input, WithExactDuration()); !errors.Is(err, ErrInexactDuration) {
+		t.Errorf("expected %v, got %v", ErrInexactDuration, err)
+	}
+	if err := WriteID3v2TagTo(io.Discard, bytes.NewReader(data), input, WithExactDuration()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProbeChainedStreams(t *testing.T) {
+	data, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	single, err := ProbeReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cover, err := TitleCard{Width: 64, Height: 64}.Render("Part 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chained bytes.Buffer
+	for _, input := range []TrackInfo{{Title: "Part 1"}, {Title: "Part 2", CoverData: cover}} {
+		if err := WriteID3v2TagTo(&chained, bytes.NewReader(data), input); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chained.WriteString("TAG")
+	chained.Write(make([]byte, id3v1TagSize-3))
+	info, err := ProbeReader(bytes.NewReader(chained.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Frames != 2*single.Frames || info.TimeDuration != 2*single.TimeDuration {
+		t.Errorf("expected %d frames and %v, got %d and %v", 2*single.Frames, 2*single.TimeDuration, info.Frames, info.TimeDuration)
+	}
+	if info.EmbeddedTags != 1 || info.Accuracy != DurationExact {
+		t.Errorf("expected 1 embedded tag and an exact duration, got %d and %v", info.EmbeddedTags, info.Accuracy)
+	}
+}
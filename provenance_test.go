@@ -0,0 +1,42 @@
+package id3v24
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+func TestWithProvenance(t *testing.T) {
+	name := copyTestMP3(t)
+	stamp := time.Date(2024, time.September, 17, 10, 0, 0, 0, time.UTC)
+	p := Provenance{Tool: "my pipeline", Version: "1.4.0", Time: stamp}
+	if err := WriteID3v2Tag(name, TrackInfo{Title: "Episode 1"}, WithProvenance(p)); err != nil {
+		t.Fatal(err)
+	}
+	tag, err := id3v2.Open(name, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tag.Close()
+	got, ok := TagProvenance(tag)
+	expected := Provenance{Tool: "my_pipeline", Version: "1.4.0", Time: stamp}
+	if !ok || got != expected {
+		t.Errorf("expected %+v, got %+v (%v)", expected, got, ok)
+	}
+	input, err := ReadID3v2Tag(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.Custom != nil {
+		t.Errorf("expected TAGGED_BY not to be read as custom, got %v", input.Custom)
+	}
+
+	if s := (Provenance{Tool: "id3v24", Time: stamp}).String(); s != "id3v24 2024-09-17T10:00:00Z" {
+		t.Errorf("expected %q, got %q", "id3v24 2024-09-17T10:00:00Z", s)
+	}
+	if _, err := ParseProvenance("id3v24 yesterday"); !errors.Is(err, ErrBadProvenance) {
+		t.Errorf("expected %v, got %v", ErrBadProvenance, err)
+	}
+}
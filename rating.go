@@ -0,0 +1,75 @@
+package id3v24
+
+import (
+	"math/big"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// Rating is a POPM (popularimeter) frame, the rating of a track by
+// the user or library identified by Email, as synced by library
+// managers. Windows Media Player, for example, uses the Email
+// "Windows Media Player 9 Series".
+type Rating struct {
+	Email   string `json:"email" yaml:"email,omitempty"`
+	Rating  uint8  `json:"rating" yaml:"rating"`                       // 1 (worst) to 255 (best), 0 is unknown, see StarsToRating
+	Counter uint64 `json:"counter,omitempty" yaml:"counter,omitempty"` // play count
+}
+
+// StarsToRating returns the POPM rating of 0 to 5 stars in the scale
+// most library managers use: 1, 64, 128, 196 and 255. 0 stars (or
+// less) is unknown, more than 5 are 5.
+func StarsToRating(stars int) uint8 {
+	return [...]uint8{0, 1, 64, 128, 196, 255}[min(max(stars, 0), 5)]
+}
+
+// RatingToStars returns the stars of a POPM rating, the inverse of
+// StarsToRating that also maps the ratings in between to the nearest
+// lower star, e.g 100 is 2 stars.
+func RatingToStars(rating uint8) int {
+	switch {
+	case rating == 0:
+		return 0
+	case rating < 64:
+		return 1
+	case rating < 128:
+		return 2
+	case rating < 196:
+		return 3
+	case rating < 255:
+		return 4
+	}
+	return 5
+}
+
+// AddRating adds r to tag as a POPM frame, replacing one with the same
+// Email.
+func AddRating(tag *id3v2.Tag, r Rating) {
+	tag.AddFrame(tag.CommonID("Popularimeter"), id3v2.PopularimeterFrame{
+		Email:   r.Email,
+		Rating:  r.Rating,
+		Counter: new(big.Int).SetUint64(r.Counter),
+	})
+}
+
+// TagRatings returns the POPM frames of tag in the order they appear.
+// Play counts beyond 64 bits are capped.
+func TagRatings(tag *id3v2.Tag) []Rating {
+	var ratings []Rating
+	for _, f := range tag.GetFrames(tag.CommonID("Popularimeter")) {
+		pf, ok := f.(id3v2.PopularimeterFrame)
+		if !ok {
+			continue
+		}
+		r := Rating{Email: pf.Email, Rating: pf.Rating}
+		switch {
+		case pf.Counter == nil:
+		case pf.Counter.IsUint64():
+			r.Counter = pf.Counter.Uint64()
+		default:
+			r.Counter = ^uint64(0)
+		}
+		ratings = append(ratings, r)
+	}
+	return ratings
+}
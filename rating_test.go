@@ -0,0 +1,24 @@
+package id3v24
+
+import (
+	"testing"
+)
+
+func TestStarsToRating(t *testing.T) {
+	for stars := 0; stars <= 5; stars++ {
+		if got := RatingToStars(StarsToRating(stars)); got != stars {
+			t.Errorf("expected %d stars, got %d", stars, got)
+		}
+	}
+	for _, c := range []struct {
+		rating uint8
+		stars  int
+	}{{100, 2}, {254, 4}, {255, 5}, {30, 1}} {
+		if got := RatingToStars(c.rating); got != c.stars {
+			t.Errorf("rating %d: expected %d stars, got %d", c.rating, c.stars, got)
+		}
+	}
+	if got := StarsToRating(7); got != 255 {
+		t.Errorf("expected 255, got %d", got)
+	}
+}
@@ -0,0 +1,154 @@
+package id3v24
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// ReadID3v2Tag parses the ID3v2 tag of mp3file into a TrackInfo, the
+// reverse of WriteID3v2Tag, so that existing files can be round-tripped
+// and edited. See TagTrackInfo for what is read. Returns a zero
+// TrackInfo if the file has no tag.
+func ReadID3v2Tag(mp3file string) (TrackInfo, error) {
+	f, err := os.Open(mp3file)
+	if err != nil {
+		return TrackInfo{}, err
+	}
+	defer f.Close()
+	return ReadID3v2TagFrom(f)
+}
+
+// ReadID3v2TagFrom is the io-only variant of ReadID3v2Tag, reading
+// the tag at the start of r.
+func ReadID3v2TagFrom(r io.Reader) (TrackInfo, error) {
+	tag, err := id3v2.ParseReader(r, id3v2.Options{Parse: true})
+	if err != nil {
+		return TrackInfo{}, err
+	}
+	return TagTrackInfo(tag)
+}
+
+// TagTrackInfo returns the fields of TrackInfo found in tag: the text
+// frames written by WriteID3v2Tag (title, album, artist, album
+// artist, composer, grouping from TIT1 or GRP1, genre, year or
+// recording date, track, disc, language, copyright, mood, TDES
+// description), the comment (in TrackInfo.Language if there are
+// several, see SelectLanguage) with the other comments in Comments,
+// the user defined TXXX and WXXX fields (other TXXX frames than
+// AddChapterTXXX and WithProvenance write in Custom, other WXXX frames
+// in Links), the WCOP, WOAR, WOAS, WCOM and WPUB URLs, the first
+// POPM rating, the PCNT play count, the front cover (or the first
+// picture) as CoverData and the chapters, see TagChapters. Loudness is
+// not read back as RVA2 only holds the adjustment.
+func TagTrackInfo(tag *id3v2.Tag) (TrackInfo, error) {
+	input := TrackInfo{
+		Title:       tag.Title(),
+		Album:       tag.Album(),
+		Artist:      tag.Artist(),
+		AlbumArtist: tag.GetTextFrame(tag.CommonID("Band/Orchestra/Accompaniment")).Text,
+		Composer:    tag.GetTextFrame(tag.CommonID("Composer")).Text,
+		Grouping:    tag.GetTextFrame(tag.CommonID("Content group description")).Text,
+		Genre:       tag.Genre(),
+		Track:       tag.GetTextFrame("TRCK").Text,
+		Disc:        tag.GetTextFrame(tag.CommonID("Part of a set")).Text,
+		Language:    tag.GetTextFrame("TLAN").Text,
+		Copyright:   tag.GetTextFrame(tag.CommonID("Copyright message")).Text,
+		Mood:        tag.GetTextFrame("TMOO").Text,
+	}
+	input.Description = tag.GetTextFrame("TDES").Text
+	if f, ok := tag.GetLastFrame("GRP1").(id3v2.UnknownFrame); ok && input.Grouping == "" && len(f.Body) > 0 {
+		input.Grouping = decodeText(f.Body[1:], f.Body[0])
+	}
+	year := tag.Year()
+	if len(year) >= 10 {
+		if date, err := time.Parse("2006-01-02", year[:10]); err == nil {
+			input.Date = date
+		}
+	}
+	if len(year) > 4 {
+		year = year[:4]
+	}
+	input.Year = year
+	comments := TagComments(tag)
+	if comment, ok := SelectLanguage(comments, input.Language); ok {
+		input.Comment = comment.Text
+		for _, c := range comments {
+			if c != comment {
+				input.Comments = append(input.Comments, Comment(c))
+			}
+		}
+	}
+	for _, f := range tag.GetFrames(tag.CommonID("User defined text information frame")) {
+		udf, ok := f.(id3v2.UserDefinedTextFrame)
+		if !ok {
+			continue
+		}
+		switch udf.Description {
+		case LicenseDescription:
+			input.License = udf.Value
+		case SeasonDescription:
+			input.Season = udf.Value
+		case EpisodeDescription:
+			input.Episode = udf.Value
+		case EnergyLevelDescription:
+			input.Energy = udf.Value
+		case ColorDescription:
+			input.Color = udf.Value
+		case CuePointsDescription:
+			input.CuePoints = udf.Value
+		default:
+			if udf.Description == "" || udf.Description == TaggedByDescription || isChapterTXXX(udf.Description) {
+				continue
+			}
+			if input.Custom == nil {
+				input.Custom = map[string]string{}
+			}
+			input.Custom[udf.Description] = udf.Value
+		}
+	}
+	for _, f := range tag.GetFrames("WXXX") {
+		if uf, ok := f.(id3v2.UnknownFrame); ok && len(uf.Body) > 0 {
+			encoded, url, _ := cutEncodedString(uf.Body[1:], uf.Body[0])
+			description := decodeText(encoded, uf.Body[0])
+			link := strings.TrimRight(string(url), "\x00")
+			if description == FundingDescription && input.Funding == "" {
+				input.Funding = link
+				continue
+			}
+			if input.Links == nil {
+				input.Links = map[string]string{}
+			}
+			input.Links[description] = link
+		}
+	}
+	input.CopyrightURL = tagURL(tag, "WCOP")
+	input.ArtistURL = tagURL(tag, "WOAR")
+	input.SourceURL = tagURL(tag, "WOAS")
+	input.CommercialURL = tagURL(tag, "WCOM")
+	input.PublisherURL = tagURL(tag, "WPUB")
+	if ratings := TagRatings(tag); len(ratings) > 0 {
+		input.Rating = &ratings[0]
+	}
+	input.PlayCount, _ = TagPlayCount(tag)
+	input.CoverData = tagCover(tag)
+	chapters, err := TagChapters(tag)
+	if err != nil {
+		return input, err
+	}
+	input.Chapters = chapters
+	return input, nil
+}
+
+// tagCover returns the front cover picture of tag, or the first
+// picture if it has no front cover.
+func tagCover(tag *id3v2.Tag) []byte {
+	if pf, ok := tagCoverFrame(tag); ok {
+		return bytes.Clone(pf.Picture)
+	}
+	return nil
+}
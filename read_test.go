@@ -0,0 +1,92 @@
+package id3v24
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+func TestReadID3v2Tag(t *testing.T) {
+	mp3file := copyTestMP3(t)
+	if input, err := ReadID3v2Tag(mp3file); err != nil || !reflect.DeepEqual(input, TrackInfo{}) {
+		t.Fatalf("expected zero TrackInfo for untagged file, got %+v, %v", input, err)
+	}
+	input := TrackInfo{
+		Title:         "Episode 1",
+		Album:         "The Show",
+		Artist:        "The Hosts",
+		AlbumArtist:   "The Network",
+		Composer:      "The Composer",
+		Grouping:      "Interviews",
+		Genre:         "Podcast",
+		Year:          "2024",
+		Date:          time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Track:         "3/10",
+		Disc:          "1/2",
+		Language:      "eng",
+		Comment:       "A comment.",
+		Description:   "The first episode.",
+		Season:        "2",
+		Episode:       "1",
+		Copyright:     "CC BY 4.0",
+		CopyrightURL:  "https://creativecommons.org/licenses/by/4.0/",
+		Funding:       "https://example.com/donate",
+		ArtistURL:     "https://example.com",
+		SourceURL:     "https://example.com/episodes/1",
+		CommercialURL: "https://store.example.com/episode-1",
+		PublisherURL:  "https://publisher.example.com",
+		Links:         map[string]string{"transcript": "https://example.com/episodes/1.txt"},
+		Mood:          "Calm",
+		Energy:        "5",
+		Color:         "#FF0000",
+		Custom:        map[string]string{"EPISODE_GUID": "3f2a9c1e", "PODCAST_ID": "42"},
+		PlayCount:     7,
+		CoverData:     []byte("\xFF\xD8\xFF\xE0 not really a JPEG"),
+		Chapters: []Chapter{
+			{Title: "Intro", Start: "00:00:00.000"},
+			{Title: "Början", Start: "00:00:01.500"},
+		},
+	}
+	if err := WriteID3v2Tag(mp3file, input); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadID3v2Tag(mp3file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, input) {
+		t.Errorf("expected %+v, got %+v", input, got)
+	}
+}
+
+func TestTagTrackInfo(t *testing.T) {
+	tag := id3v2.NewEmptyTag()
+	tag.SetVersion(4)
+	tag.SetYear("2024-05-01")
+	tag.AddTextFrame("TRCK", tag.DefaultEncoding(), "3/10")
+	tag.AddTextFrame("TLAN", tag.DefaultEncoding(), "swe")
+	tag.AddCommentFrame(id3v2.CommentFrame{Encoding: id3v2.EncodingUTF8, Language: "eng", Text: "Hello"})
+	tag.AddCommentFrame(id3v2.CommentFrame{Encoding: id3v2.EncodingUTF8, Language: "swe", Text: "Hej"})
+	tag.AddAttachedPicture(id3v2.PictureFrame{Encoding: id3v2.EncodingUTF8, MimeType: "image/png", PictureType: id3v2.PTBackCover, Picture: []byte("back")})
+	tag.AddAttachedPicture(id3v2.PictureFrame{Encoding: id3v2.EncodingUTF8, MimeType: "image/png", PictureType: id3v2.PTFrontCover, Picture: []byte("front")})
+	var buf bytes.Buffer
+	if _, err := tag.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	input, err := ReadID3v2TagFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.Year != "2024" || input.Date.Format("2006-01-02") != "2024-05-01" {
+		t.Errorf("expected year 2024 and date 2024-05-01, got %q and %v", input.Year, input.Date)
+	}
+	if input.Track != "3/10" || input.Language != "swe" || input.Comment != "Hej" {
+		t.Errorf("unexpected track %q, language %q or comment %q", input.Track, input.Language, input.Comment)
+	}
+	if string(input.CoverData) != "front" {
+		t.Errorf("expected %q, got %q", "front", input.CoverData)
+	}
+}
@@ -0,0 +1,176 @@
+package id3v24
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// ChapterSource is a named source of chapters for ResolveChapters.
+// Load returns nil (and no error) if the source has no chapters, e.g
+// a sidecar file that does not exist.
+type ChapterSource struct {
+	Name string
+	Load func() ([]Chapter, error)
+}
+
+// EmbeddedChapterSource returns the CHAP frames of the ID3v2 tag of
+// path, see TagChapters.
+func EmbeddedChapterSource(path string) ChapterSource {
+	return ChapterSource{Name: "embedded", Load: func() ([]Chapter, error) {
+		tag, err := id3v2.Open(path, id3v2.Options{Parse: true, ParseFrames: []string{"CHAP"}})
+		if err != nil {
+			return nil, err
+		}
+		defer tag.Close()
+		return TagChapters(tag)
+	}}
+}
+
+// JSONChapterSource returns the chapters of the sidecar JSON file
+// path, either a TrackInfo or a bare list of chapters.
+func JSONChapterSource(path string) ChapterSource {
+	return ChapterSource{Name: "json", Load: func() ([]Chapter, error) {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return parseJSONChapters(bytes.NewReader(data))
+	}}
+}
+
+// CueChapterSource returns the chapters of the cue sheet path, see
+// ParseCueSheet.
+func CueChapterSource(path string) ChapterSource {
+	return ChapterSource{Name: "cue", Load: func() ([]Chapter, error) {
+		f, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ParseCueSheet(f)
+	}}
+}
+
+// TextChapterSource returns the chapters of the sidecar text file
+// path, an ffmpeg metadata file (see ParseFFmetadataChapters) or a
+// chapter list (see ParseChapterList).
+func TextChapterSource(path string) ChapterSource {
+	return ChapterSource{Name: "txt", Load: func() ([]Chapter, error) {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if bytes.HasPrefix(bytes.TrimLeft(data, "\ufeff \t\r\n"), []byte(";FFMETADATA")) {
+			return ParseFFmetadataChapters(bytes.NewReader(data))
+		}
+		return ParseChapterList(bytes.NewReader(data))
+	}}
+}
+
+// ParseChapterList returns the chapters of a plain list with one
+// chapter per line, its start followed by the title, as in YouTube
+// descriptions and the show notes of many podcast tools:
+//
+//	00:00 Intro
+//	5:30 - The interview
+//	1:02:03.500 Outro
+//
+// The start is in any format of Chapter.Start and may be followed by
+// a dash, colon or pipe. Blank lines and lines starting with # are
+// skipped.
+func ParseChapterList(r io.Reader) ([]Chapter, error) {
+	var chapters []Chapter
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		start, title, _ := strings.Cut(line, " ")
+		start = strings.Trim(start, "[]()")
+		if _, err := StringTimeToDuration(start); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		title = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(title), "-–—:|"))
+		chapters = append(chapters, Chapter{Title: title, Start: start})
+	}
+	return chapters, scanner.Err()
+}
+
+// SidecarChapterSources returns the sidecar chapter files of mp3file
+// in order of precedence: <basename>.chapters.txt (see
+// TextChapterSource), <basename>.chapters.json and
+// <basename>.chapters.cue, where basename is mp3file without its
+// extension.
+func SidecarChapterSources(mp3file string) []ChapterSource {
+	base := strings.TrimSuffix(mp3file, filepath.Ext(mp3file)) + ".chapters"
+	return []ChapterSource{
+		TextChapterSource(base + ".txt"),
+		JSONChapterSource(base + ".json"),
+		CueChapterSource(base + ".cue"),
+	}
+}
+
+// WithSidecarChapters makes WriteID3v2Tag read the chapters of a
+// TrackInfo without any from the first sidecar file of the MP3 file
+// that has chapters, see SidecarChapterSources.
+func WithSidecarChapters() Option {
+	return func(o *options) {
+		o.sidecarChapters = true
+	}
+}
+
+// ResolvedChapters is the result of ResolveChapters.
+type ResolvedChapters struct {
+	Chapters []Chapter
+	Source   string   // name of the source that won, empty if none had chapters
+	Sources  []string // names of all sources with chapters, in order of precedence
+}
+
+// ResolveChapters loads chapters from sources, given in order of
+// precedence (highest first). Unless merge is true the chapters of
+// the first source that has any win. With merge, the chapters of all
+// sources are merged (see MergeChapters) and on equal start times the
+// source with the higher precedence wins; Source is then the highest
+// source contributing. Errors are returned wrapped with the source
+// name.
+func ResolveChapters(sources []ChapterSource, merge bool) (ResolvedChapters, error) {
+	var resolved ResolvedChapters
+	for _, source := range sources {
+		chapters, err := source.Load()
+		if err != nil {
+			return resolved, fmt.Errorf("%s: %w", source.Name, err)
+		}
+		if len(chapters) == 0 {
+			continue
+		}
+		resolved.Sources = append(resolved.Sources, source.Name)
+		if resolved.Source == "" {
+			resolved.Source = source.Name
+			resolved.Chapters = chapters
+			if !merge {
+				return resolved, nil
+			}
+			continue
+		}
+		resolved.Chapters, err = MergeChapters(chapters, resolved.Chapters)
+		if err != nil {
+			return resolved, fmt.Errorf("%s: %w", source.Name, err)
+		}
+	}
+	return resolved, nil
+}
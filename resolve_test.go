@@ -0,0 +1,124 @@
+package id3v24
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCueSheet(t *testing.T) {
+	cue := `PERFORMER "Universe"
+TITLE "Galaxy"
+FILE "galaxy.mp3" MP3
+  TRACK 01 AUDIO
+    TITLE "Intro"
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    TITLE "The \"big\" one"
+    INDEX 00 02:59:00
+    INDEX 01 03:00:37
+  TRACK 03 AUDIO
+    INDEX 01 75:01:74
+`
+	chapters, err := ParseCueSheet(strings.NewReader(cue))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Chapter{
+		{Title: "Intro", Start: "00:00:00.000"},
+		{Title: `The "big" one`, Start: "00:03:00.493"},
+		{Title: "Track 3", Start: "01:15:01.986"},
+	}
+	if !reflect.DeepEqual(chapters, expected) {
+		t.Errorf("expected %v, got %v", expected, chapters)
+	}
+	if _, err := ParseCueSheet(strings.NewReader("TRACK 01 AUDIO\n  INDEX 01 00:00:75\n")); err == nil {
+		t.Error("expected an error for a bad frame count")
+	}
+	files, err := CueSheetFiles(strings.NewReader(cue + "FILE \"part two.mp3\" MP3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"galaxy.mp3", "part two.mp3"}; !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected %v, got %v", expected, files)
+	}
+}
+
+func TestResolveChapters(t *testing.T) {
+	dir := t.TempDir()
+	cue := filepath.Join(dir, "episode.cue")
+	if err := os.WriteFile(cue, []byte("TRACK 01 AUDIO\n TITLE \"Cue intro\"\n INDEX 01 00:00:00\nTRACK 02 AUDIO\n TITLE \"Cue end\"\n INDEX 01 00:02:00\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sidecar := filepath.Join(dir, "episode.json")
+	if err := os.WriteFile(sidecar, []byte(`{"title":"Episode","chapters":[{"title":"Intro","start":"00:00:00"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mp3file := copyTestMP3(t)
+	sources := []ChapterSource{
+		EmbeddedChapterSource(mp3file),
+		JSONChapterSource(sidecar),
+		JSONChapterSource(filepath.Join(dir, "missing.json")),
+		CueChapterSource(cue),
+	}
+	resolved, err := ResolveChapters(sources, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Source != "json" || len(resolved.Chapters) != 1 || resolved.Chapters[0].Title != "Intro" {
+		t.Errorf("unexpected result %+v", resolved)
+	}
+	resolved, err = ResolveChapters(sources, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Chapter{
+		{Title: "Intro", Start: "00:00:00"},
+		{Title: "Cue end", Start: "00:00:02.000"},
+	}
+	if resolved.Source != "json" || !reflect.DeepEqual(resolved.Sources, []string{"json", "cue"}) || !reflect.DeepEqual(resolved.Chapters, expected) {
+		t.Errorf("unexpected result %+v", resolved)
+	}
+}
+
+func TestWithSidecarChapters(t *testing.T) {
+	mp3file := copyTestMP3(t)
+	sidecar := strings.TrimSuffix(mp3file, ".mp3") + ".chapters.txt"
+	list := "# Chapters\n00:00 Intro\n0:01.5 - The \"main\" part\n\n[00:02] | Outro\n"
+	if err := os.WriteFile(sidecar, []byte(list), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteID3v2Tag(mp3file, TrackInfo{Title: "Sidecar"}, WithSidecarChapters()); err != nil {
+		t.Fatal(err)
+	}
+	input, err := ReadID3v2Tag(mp3file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Chapter{
+		{Title: "Intro", Start: "00:00:00.000"},
+		{Title: `The "main" part`, Start: "00:00:01.500"},
+		{Title: "Outro", Start: "00:00:02.000"},
+	}
+	if !reflect.DeepEqual(input.Chapters, expected) {
+		t.Errorf("expected %v, got %v", expected, input.Chapters)
+	}
+
+	// Chapters of the track info win over the sidecar.
+	chapters := []Chapter{{Title: "Only", Start: "00:00:00.000"}}
+	if err := WriteID3v2Tag(mp3file, TrackInfo{Chapters: chapters}, WithSidecarChapters()); err != nil {
+		t.Fatal(err)
+	}
+	if input, err = ReadID3v2Tag(mp3file); err != nil || !reflect.DeepEqual(input.Chapters, chapters) {
+		t.Errorf("expected %v, got %v (%v)", chapters, input.Chapters, err)
+	}
+
+	if err := os.WriteFile(sidecar, []byte("intro 00:00\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteID3v2Tag(mp3file, TrackInfo{}, WithSidecarChapters()); err == nil {
+		t.Error("expected an error for a malformed sidecar")
+	}
+}
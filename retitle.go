@@ -0,0 +1,169 @@
+package id3v24
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// TitleReplacement is a search-and-replace rule for
+// ReplaceChapterTitles. If Pattern is set, its matches are replaced
+// with Replacement as by regexp.ReplaceAllString (so $1 etc expand),
+// otherwise every occurrence of Old is.
+type TitleReplacement struct {
+	Pattern     *regexp.Regexp
+	Old         string
+	Replacement string
+}
+
+// TitleMapReplacements returns a literal replacement for each entry
+// of m, e.g {"Chapter": "Kapitel"}, longest key first so that longer
+// phrases win over words they contain.
+func TitleMapReplacements(m map[string]string) []TitleReplacement {
+	rules := make([]TitleReplacement, 0, len(m))
+	for old, replacement := range m {
+		rules = append(rules, TitleReplacement{Old: old, Replacement: replacement})
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if len(rules[i].Old) != len(rules[j].Old) {
+			return len(rules[i].Old) > len(rules[j].Old)
+		}
+		return rules[i].Old < rules[j].Old
+	})
+	return rules
+}
+
+// apply returns title with r applied.
+func (r TitleReplacement) apply(title string) string {
+	if r.Pattern != nil {
+		return r.Pattern.ReplaceAllString(title, r.Replacement)
+	}
+	if r.Old == "" {
+		return title
+	}
+	return strings.ReplaceAll(title, r.Old, r.Replacement)
+}
+
+// TitleChange is a chapter title changed by ReplaceChapterTitles.
+type TitleChange struct {
+	Start string
+	Old   string
+	New   string
+}
+
+// String returns c as a two line diff.
+func (c TitleChange) String() string {
+	return fmt.Sprintf("-%s %s\n+%s %s", c.Start, c.Old, c.Start, c.New)
+}
+
+// ReplaceChapterTitles returns a copy of chapters with rules applied,
+// in order, to every title, and the titles that changed.
+func ReplaceChapterTitles(chapters []Chapter, rules []TitleReplacement) ([]Chapter, []TitleChange) {
+	replaced := make([]Chapter, len(chapters))
+	var changes []TitleChange
+	for i, ch := range chapters {
+		title := ch.Title
+		for _, rule := range rules {
+			title = rule.apply(title)
+		}
+		if title != ch.Title {
+			changes = append(changes, TitleChange{Start: ch.Start, Old: ch.Title, New: title})
+			ch.Title = title
+		}
+		replaced[i] = ch
+	}
+	return replaced, changes
+}
+
+// ReplaceChapterTitlesInFile applies rules to the titles of the CHAP
+// frames of mp3file, e.g to localize a chaptered library, and returns
+// the titles that changed. Only the TIT2 sub-frames are rewritten,
+// the rest of the tag is kept. With dryRun the file is left as is.
+func ReplaceChapterTitlesInFile(mp3file string, rules []TitleReplacement, dryRun bool, opts ...Option) ([]TitleChange, error) {
+	o := newOptions(opts...)
+	f, err := os.Open(mp3file)
+	if err != nil {
+		return nil, o.fail(MetricErrOpen, err)
+	}
+	previous, err := readLeadingTag(f)
+	f.Close()
+	if err != nil {
+		return nil, o.fail(MetricErrOpen, err)
+	}
+	if len(previous) == 0 {
+		return nil, nil
+	}
+	tag, err := id3v2.ParseReader(bytes.NewReader(previous), id3v2.Options{Parse: true})
+	if err != nil {
+		return nil, o.fail(MetricErrOpen, err)
+	}
+	var changes []TitleChange
+	var bodies [][]byte
+	for _, f := range tag.GetFrames("CHAP") {
+		uf, ok := f.(id3v2.UnknownFrame)
+		if !ok {
+			continue
+		}
+		ch, err := parseCHAP(uf.Body)
+		if err != nil {
+			return nil, o.fail(MetricErrChapters, err)
+		}
+		chapters, changed := ReplaceChapterTitles([]Chapter{{Title: ch.Title, Start: ch.Start}}, rules)
+		body := uf.Body
+		if changed != nil {
+			changes = append(changes, changed...)
+			if body, err = o.replaceCHAPTitle(body, chapters[0].Title); err != nil {
+				return nil, o.fail(MetricErrChapters, err)
+			}
+		}
+		bodies = append(bodies, body)
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Start < changes[j].Start })
+	if changes == nil || dryRun {
+		return changes, nil
+	}
+	tag.DeleteFrames("CHAP")
+	for _, body := range bodies {
+		tag.AddFrame("CHAP", id3v2.UnknownFrame{Body: body})
+	}
+	var buf bytes.Buffer
+	if _, err := tag.WriteTo(&buf); err != nil {
+		return nil, o.fail(MetricErrSave, err)
+	}
+	if err := replaceLeadingTag(o, mp3file, buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// replaceCHAPTitle returns the CHAP frame body with its TIT2
+// sub-frame replaced by one holding title, added if missing. Other
+// sub-frames are kept as is.
+func (o *options) replaceCHAPTitle(body []byte, title string) ([]byte, error) {
+	i := bytes.IndexByte(body, 0x00)
+	if i < 0 || len(body) < i+1+16 {
+		return nil, ErrBadFrame
+	}
+	replaced := append([]byte{}, body[:i+1+16]...)
+	titleFrame := subFrame("TIT2", o.chapterTitleFrame(title))
+	subFrames := body[i+1+16:]
+	for len(subFrames) >= id3v2HeaderSize {
+		size, ok := subFrameSize(subFrames[4:8], len(subFrames)-id3v2HeaderSize)
+		if !ok {
+			return nil, ErrBadFrame
+		}
+		if string(subFrames[0:4]) == "TIT2" && titleFrame != nil {
+			replaced = append(replaced, titleFrame...)
+			titleFrame = nil
+		} else if string(subFrames[0:4]) != "TIT2" {
+			replaced = append(replaced, subFrames[:id3v2HeaderSize+size]...)
+		}
+		subFrames = subFrames[id3v2HeaderSize+size:]
+	}
+	return append(replaced, titleFrame...), nil
+}
@@ -0,0 +1,95 @@
+package id3v24
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+func TestReplaceChapterTitles(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Chapter 1", Start: "00:00:00.000"},
+		{Title: "Chapter 2: The end", Start: "00:01:00.000"},
+		{Title: "Credits", Start: "00:02:00.000"},
+	}
+	rules := append(TitleMapReplacements(map[string]string{"Chapter": "Kapitel", "The end": "Slutet"}),
+		TitleReplacement{Pattern: regexp.MustCompile(`^Kapitel (\d+)$`), Replacement: "Kapitel $1 av 2"})
+	replaced, changes := ReplaceChapterTitles(chapters, rules)
+	expected := []Chapter{
+		{Title: "Kapitel 1 av 2", Start: "00:00:00.000"},
+		{Title: "Kapitel 2: Slutet", Start: "00:01:00.000"},
+		{Title: "Credits", Start: "00:02:00.000"},
+	}
+	if !reflect.DeepEqual(replaced, expected) {
+		t.Errorf("expected %v, got %v", expected, replaced)
+	}
+	if len(changes) != 2 || changes[1].String() != "-00:01:00.000 Chapter 2: The end\n+00:01:00.000 Kapitel 2: Slutet" {
+		t.Errorf("unexpected changes %q", changes)
+	}
+	if chapters[0].Title != "Chapter 1" {
+		t.Error("expected chapters to be left as is")
+	}
+}
+
+func TestReplaceChapterTitlesInFile(t *testing.T) {
+	mp3file := copyTestMP3(t)
+	input := TrackInfo{
+		Title: "Episode 1",
+		Chapters: []Chapter{
+			{Title: "Chapter 1", Start: "00:00:00.000"},
+			{Title: "Chapter 2", Start: "00:00:01.000"},
+		},
+	}
+	if err := WriteID3v2Tag(mp3file, input, WithChapterArt(TitleCard{Width: 16, Height: 16})); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(mp3file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := TitleMapReplacements(map[string]string{"Chapter": "Kapitel"})
+	changes, err := ReplaceChapterTitlesInFile(mp3file, rules, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].New != "Kapitel 1" {
+		t.Errorf("unexpected changes %q", changes)
+	}
+	if after, _ := os.ReadFile(mp3file); !bytes.Equal(before, after) {
+		t.Error("expected dry run to leave the file as is")
+	}
+	if _, err := ReplaceChapterTitlesInFile(mp3file, rules, false); err != nil {
+		t.Fatal(err)
+	}
+	tag, err := id3v2.Open(mp3file, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tag.Close()
+	if tag.Title() != input.Title {
+		t.Errorf("expected %q, got %q", input.Title, tag.Title())
+	}
+	chapters, err := TagChapters(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Chapter{{Title: "Kapitel 1", Start: "00:00:00.000"}, {Title: "Kapitel 2", Start: "00:00:01.000"}}
+	if !reflect.DeepEqual(chapters, expected) {
+		t.Errorf("expected %v, got %v", expected, chapters)
+	}
+	for _, f := range tag.GetFrames("CHAP") {
+		if !bytes.Contains(f.(id3v2.UnknownFrame).Body, []byte("APIC")) {
+			t.Error("expected the APIC sub-frame to be kept")
+		}
+	}
+	if d, err := GetMP3Duration(mp3file); err != nil || d == 0 {
+		t.Errorf("expected audio to be kept, got %v, %v", d, err)
+	}
+	if changes, err := ReplaceChapterTitlesInFile(mp3file, rules, false); err != nil || changes != nil {
+		t.Errorf("expected no changes, got %v, %v", changes, err)
+	}
+}
@@ -0,0 +1,133 @@
+package id3v24
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// FileReport is the summary of one audio file produced by
+// ScanLibrary. Duration, Chapters and HasCover are only filled in for
+// MP3 and DSF files.
+type FileReport struct {
+	Path       string
+	Format     AudioFormat
+	TagVersion byte // ID3v2 major version, 0 if there is no tag
+	TagSize    int  // size of the ID3v2 tag in bytes
+	Duration   time.Duration
+	Chapters   int  // number of CHAP frames
+	HasCover   bool // tag has an APIC frame
+	Err        error
+}
+
+// ScanLibrary walks the tree rooted at root and sends a FileReport for
+// every audio file (see DetectAudioFormat) on the returned channel,
+// inspecting up to workers files concurrently (runtime.NumCPU() if
+// workers is less than 1). Files in other formats are skipped. The
+// channel is closed when the walk is done or ctx is cancelled.
+// Problems with individual files are reported in FileReport.Err.
+func ScanLibrary(ctx context.Context, root string, workers int) (<-chan FileReport, error) {
+	if _, err := os.Stat(root); err != nil {
+		return nil, err
+	}
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	paths := make(chan string)
+	reports := make(chan FileReport)
+	go func() {
+		defer close(paths)
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return nil
+			}
+			select {
+			case paths <- path:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				report, ok := scanFile(path)
+				if !ok {
+					continue
+				}
+				select {
+				case reports <- report:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(reports)
+	}()
+	return reports, nil
+}
+
+// scanFile returns the FileReport of path, ok is false if path is not
+// an audio file.
+func scanFile(path string) (report FileReport, ok bool) {
+	report.Path = path
+	f, err := os.Open(path)
+	if err != nil {
+		report.Err = err
+		return report, true
+	}
+	defer f.Close()
+	report.Format, err = DetectAudioFormat(f)
+	if err != nil {
+		return report, false
+	}
+	version, tagOffset, size, hasTag := findID3v2Tag(f)
+	if hasTag {
+		report.TagVersion, report.TagSize = version, size
+	}
+	var tag *id3v2.Tag
+	switch report.Format {
+	case FormatMP3:
+		info, err := ProbeReader(f)
+		if err != nil {
+			report.Err = err
+			return report, true
+		}
+		report.Duration = info.TimeDuration
+		if hasTag {
+			tag, err = id3v2.ParseReader(io.NewSectionReader(f, tagOffset, int64(size)), id3v2.Options{Parse: true, ParseFrames: []string{"CHAP", "APIC"}})
+		}
+	case FormatDSF:
+		var layout *dsfLayout
+		if layout, err = readDSFLayout(f); err == nil {
+			report.Duration = layout.duration
+			tag, err = ReadDSFTag(f)
+			if tag != nil {
+				report.TagVersion, report.TagSize = tag.Version(), tag.Size()
+			}
+		}
+	}
+	if err != nil {
+		report.Err = err
+		return report, true
+	}
+	if tag != nil {
+		report.Chapters = len(tag.GetFrames("CHAP"))
+		report.HasCover = len(tag.GetFrames("APIC")) > 0
+	}
+	return report, true
+}
@@ -0,0 +1,88 @@
+package id3v24
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestScanLibrary(t *testing.T) {
+	root := t.TempDir()
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tagged bytes.Buffer
+	if err := WriteID3v2TagTo(&tagged, bytes.NewReader(mp3), TrackInfo{
+		CoverData: []byte("\xFF\xD8\xFF not really a jpeg"),
+		Chapters: []Chapter{
+			{Title: "Chapter 1", Start: "00:00:00"},
+			{Title: "Chapter 2", Start: "00:00:01.5"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "show"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{
+		"plain.mp3":       mp3,
+		"show/tagged.mp3": tagged.Bytes(),
+		"show/notes.txt":  []byte("hello"),
+		"track.flac":      minimalFLAC(nil),
+	} {
+		if err := os.WriteFile(filepath.Join(root, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reports, err := ScanLibrary(context.Background(), root, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []FileReport
+	for r := range reports {
+		if r.Err != nil {
+			t.Errorf("%s: %v", r.Path, r.Err)
+		}
+		got = append(got, r)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].Path < got[j].Path })
+	if len(got) != 3 {
+		t.Fatalf("expected 3 reports, got %d", len(got))
+	}
+	if r := got[0]; r.Format != FormatMP3 || r.TagVersion != 0 || r.Duration == 0 {
+		t.Errorf("unexpected report %+v", r)
+	}
+	if r := got[1]; r.Format != FormatMP3 || r.TagVersion != 4 || r.Chapters != 2 || !r.HasCover || r.Duration != got[0].Duration {
+		t.Errorf("unexpected report %+v", r)
+	}
+	if r := got[2]; r.Format != FormatFLAC {
+		t.Errorf("unexpected report %+v", r)
+	}
+	if _, err := ScanLibrary(context.Background(), filepath.Join(root, "missing"), 1); err == nil {
+		t.Error("expected an error for a missing root")
+	}
+}
+
+func TestWriteLibraryJSONL(t *testing.T) {
+	reports := make(chan FileReport, 2)
+	reports <- FileReport{Path: "a.mp3", Format: FormatMP3, TagVersion: 4, TagSize: 1024, Duration: 3056 * time.Millisecond, Chapters: 2, HasCover: true}
+	reports <- FileReport{Path: "b.mp3", Format: FormatMP3, Err: errors.New("broken")}
+	close(reports)
+	var out bytes.Buffer
+	n, err := WriteLibraryJSONL(&out, reports)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"path":"a.mp3","format":"mp3","tagVersion":4,"tagSize":1024,"durationMs":3056,"chapters":2,"hasCover":true}
+{"path":"b.mp3","format":"mp3","error":"broken"}
+`
+	if n != 2 || out.String() != expected {
+		t.Errorf("expected %q, got %d %q", expected, n, out.String())
+	}
+}
@@ -0,0 +1,126 @@
+package id3v24
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+func TestSeratoMarkers2(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Intro", Start: "00:00:00"},
+		{Title: "Drop", Start: "00:01:30.250"},
+	}
+	content, err := SeratoMarkers2(chapters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content) != 470 || !bytes.HasPrefix(content, []byte{0x01, 0x01}) {
+		t.Fatalf("unexpected content %q", content)
+	}
+	encoded := bytes.TrimRight(content[2:], "\x00")
+	payload, err := base64.RawStdEncoding.DecodeString(string(bytes.ReplaceAll(encoded, []byte("\n"), nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte("\x01\x01" +
+		"CUE\x00\x00\x00\x00\x12\x00\x00\x00\x00\x00\x00\x00\xCC\x00\x00\x00\x00Intro\x00" +
+		"CUE\x00\x00\x00\x00\x11\x00\x01\x00\x01\x60\x8A\x00\xCC\x88\x00\x00\x00Drop\x00" +
+		"\x00")
+	if !bytes.Equal(payload, expected) {
+		t.Errorf("expected payload %q, got %q", expected, payload)
+	}
+}
+
+func TestSeratoChapters(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Intro", Start: "00:00:00.000"},
+		{Title: "Drop", Start: "00:01:30.250"},
+		{Title: "Outro", Start: "01:02:03.004"},
+	}
+	tag := id3v2.NewEmptyTag()
+	if err := AddSeratoCues(tag, chapters); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := tag.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := id3v2.ParseReader(&buf, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := SeratoChapters(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, chapters) {
+		t.Errorf("expected %v, got %v", chapters, got)
+	}
+	if _, err := ParseSeratoMarkers2([]byte("\x01\x01AQ")); !errors.Is(err, ErrBadSeratoMarkers) {
+		t.Errorf("expected ErrBadSeratoMarkers, got %v", err)
+	}
+}
+
+// traktorFrame returns a Traktor frame with id, data and the number of
+// children in it.
+func traktorFrame(id string, children int, data []byte) []byte {
+	b := []byte{id[3], id[2], id[1], id[0]}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(data)))
+	b = binary.LittleEndian.AppendUint32(b, uint32(children))
+	return append(b, data...)
+}
+
+func TestTraktorChapters(t *testing.T) {
+	cue := func(name string, kind uint32, start float64, hotcue int32) []byte {
+		b := binary.LittleEndian.AppendUint32(nil, 1)
+		units := utf16.Encode([]rune(name))
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(units)))
+		for _, u := range units {
+			b = binary.LittleEndian.AppendUint16(b, u)
+		}
+		b = binary.LittleEndian.AppendUint32(b, 0) // display order
+		b = binary.LittleEndian.AppendUint32(b, kind)
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(start))
+		b = binary.LittleEndian.AppendUint64(b, 0) // length
+		b = binary.LittleEndian.AppendUint32(b, math.MaxUint32)
+		return binary.LittleEndian.AppendUint32(b, uint32(hotcue))
+	}
+	cuep := binary.LittleEndian.AppendUint32(nil, 4)
+	cuep = append(cuep, cue("Drop", 0, 90250, 1)...)
+	cuep = append(cuep, cue("", 4, 12.5, -1)...) // beat grid
+	cuep = append(cuep, cue("Intro", 3, 0, 0)...)
+	cuep = append(cuep, cue("n.n.", 0, 3723004, 2)...)
+	data := traktorFrame("TRMD", 2, append(
+		traktorFrame("HDR ", 0, []byte("header")),
+		traktorFrame("DATA", 1, traktorFrame("CUEP", 0, cuep))...,
+	))
+	tag := id3v2.NewEmptyTag()
+	tag.AddFrame("GEOB", id3v2.UnknownFrame{Body: []byte{0x00}}) // malformed, skipped
+	tag.AddFrame("PRIV", id3v2.UnknownFrame{Body: append([]byte(TraktorOwner+"\x00"), data...)})
+	if got, err := SeratoChapters(tag); err != nil || got != nil {
+		t.Errorf("expected no Serato chapters, got %v and %v", got, err)
+	}
+	got, err := TraktorChapters(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Chapter{
+		{Title: "Intro", Start: "00:00:00.000"},
+		{Title: "Drop", Start: "00:01:30.250"},
+		{Title: "Cue 3", Start: "01:02:03.004"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if _, err := ParseTraktorCues(data[:len(data)-3]); !errors.Is(err, ErrBadTraktorCues) {
+		t.Errorf("expected ErrBadTraktorCues, got %v", err)
+	}
+}
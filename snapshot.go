@@ -0,0 +1,103 @@
+package id3v24
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// SnapshotOwner is the owner identifier of the PRIV frame holding the
+// snapshot of the original tag written with WithTagSnapshot.
+const SnapshotOwner = "https://github.com/sa6mwa/id3v24#snapshot"
+
+// WithTagSnapshot makes WriteID3v2Tag and WriteID3v2TagTo store a
+// gzip compressed copy of the tag they replace in a PRIV frame (see
+// SnapshotOwner), so that the original metadata can be recovered with
+// TagSnapshot or RestoreTagSnapshot without external backups. A
+// snapshot already present in the replaced tag is carried over, so
+// the snapshot is always of the tag the file had before it was first
+// written with WithTagSnapshot.
+func WithTagSnapshot() Option {
+	return func(o *options) {
+		o.tagSnapshot = true
+	}
+}
+
+// TagSnapshot returns the original tag stored in tag by
+// WithTagSnapshot, or nil if tag has no snapshot.
+func TagSnapshot(tag *id3v2.Tag) ([]byte, error) {
+	compressed := snapshotFrame(tag)
+	if compressed == nil {
+		return nil, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// RestoreTagSnapshot replaces the tag of mp3file with the original tag
+// stored in it by WithTagSnapshot. Returns ErrNothingToUndo if the
+// tag has no snapshot.
+func RestoreTagSnapshot(mp3file string, opts ...Option) error {
+	tag, err := id3v2.Open(mp3file, id3v2.Options{Parse: true, ParseFrames: []string{"PRIV"}})
+	if err != nil {
+		return err
+	}
+	original, err := TagSnapshot(tag)
+	tag.Close()
+	if err != nil {
+		return err
+	}
+	if original == nil {
+		return ErrNothingToUndo
+	}
+	return replaceLeadingTag(newOptions(opts...), mp3file, original)
+}
+
+// snapshotFrame returns the compressed snapshot in tag, if any.
+func snapshotFrame(tag *id3v2.Tag) []byte {
+	for _, f := range tag.GetFrames("PRIV") {
+		uf, ok := f.(id3v2.UnknownFrame)
+		if !ok {
+			continue
+		}
+		if owner, data, ok := bytes.Cut(uf.Body, []byte{0x00}); ok && string(owner) == SnapshotOwner {
+			return data
+		}
+	}
+	return nil
+}
+
+// addTagSnapshot adds a snapshot of the tag at the start of r to tag
+// if WithTagSnapshot was given and r starts with a tag.
+func (o *options) addTagSnapshot(r io.ReadSeeker, tag *id3v2.Tag) error {
+	if !o.tagSnapshot {
+		return nil
+	}
+	previous, err := readLeadingTag(r)
+	if err != nil || len(previous) == 0 {
+		return err
+	}
+	existing, err := id3v2.ParseReader(bytes.NewReader(previous), id3v2.Options{Parse: true, ParseFrames: []string{"PRIV"}})
+	if err != nil {
+		return err
+	}
+	compressed := snapshotFrame(existing)
+	if compressed == nil {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(previous)
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		compressed = buf.Bytes()
+	}
+	body := append([]byte(SnapshotOwner), 0x00)
+	tag.AddFrame("PRIV", id3v2.UnknownFrame{Body: append(body, compressed...)})
+	return nil
+}
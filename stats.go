@@ -0,0 +1,98 @@
+package id3v24
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+// TagStatistics is the result of TagStats.
+type TagStatistics struct {
+	Version byte        // major version, e.g 4 for ID3v2.4, 0 if there is no tag
+	Size    int         // total size of the tag, including header, padding and footer
+	Padding int         // bytes of padding after the last frame
+	Audio   int64       // bytes after the tag
+	Frames  []FrameSize // sizes per frame ID including frame headers, largest first
+}
+
+// TagStats returns how many bytes each frame ID (the cover, chapters,
+// lyrics etc) consumes in the ID3v2 tag of path, as stored in the
+// file, to find out what bloats it before deciding on e.g cover
+// resizing or WithMaxTagSize.
+func TagStats(path string) (*TagStatistics, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return TagStatsFrom(f)
+}
+
+// TagStatsFrom is the io-only variant of TagStats.
+func TagStatsFrom(r io.ReadSeeker) (*TagStatistics, error) {
+	tag, err := readLeadingTag(r)
+	if err != nil {
+		return nil, err
+	}
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	stats := &TagStatistics{Size: len(tag), Audio: end - int64(len(tag))}
+	if len(tag) == 0 {
+		return stats, nil
+	}
+	stats.Version = tag[3]
+	frames := tag[id3v2HeaderSize:]
+	if tag[5]&0x10 != 0 {
+		frames = frames[:len(frames)-id3v2HeaderSize] // footer
+	}
+	if tag[5]&0x40 != 0 && len(frames) >= 4 {
+		// Extended header, its size includes itself in ID3v2.4 only.
+		size := int(binary.BigEndian.Uint32(frames))
+		if stats.Version >= 4 {
+			size, _ = subFrameSize(frames[:4], len(frames))
+		} else {
+			size += 4
+		}
+		if size > len(frames) {
+			return nil, ErrBadFrame
+		}
+		frames = frames[size:]
+	}
+	idSize, headerSize := 4, id3v2HeaderSize
+	if stats.Version == 2 {
+		idSize, headerSize = 3, 6
+	}
+	index := map[string]int{}
+	for len(frames) >= headerSize && frames[0] != 0x00 {
+		id := string(frames[:idSize])
+		var size int
+		switch stats.Version {
+		case 2:
+			size = int(frames[3])<<16 | int(frames[4])<<8 | int(frames[5])
+		case 3:
+			size = int(binary.BigEndian.Uint32(frames[4:8]))
+		default:
+			var ok bool
+			if size, ok = subFrameSize(frames[4:8], len(frames)-headerSize); !ok {
+				return nil, ErrBadFrame
+			}
+		}
+		if size > len(frames)-headerSize {
+			return nil, ErrBadFrame
+		}
+		i, ok := index[id]
+		if !ok {
+			i = len(stats.Frames)
+			index[id] = i
+			stats.Frames = append(stats.Frames, FrameSize{ID: id})
+		}
+		stats.Frames[i].Count++
+		stats.Frames[i].Size += headerSize + size
+		frames = frames[headerSize+size:]
+	}
+	stats.Padding = len(frames)
+	sortFrameSizes(stats.Frames)
+	return stats, nil
+}
@@ -0,0 +1,55 @@
+package id3v24
+
+import (
+	"os"
+	"testing"
+)
+
+func TestTagStats(t *testing.T) {
+	mp3file := copyTestMP3(t)
+	stats, err := TagStats(mp3file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Version != 0 || stats.Size != 0 || stats.Audio != 48900 || stats.Frames != nil {
+		t.Errorf("unexpected stats for untagged file %+v", stats)
+	}
+	cover := make([]byte, 5000)
+	input := TrackInfo{
+		Title:     "Episode 1",
+		CoverData: cover,
+		Chapters: []Chapter{
+			{Title: "Chapter 1", Start: "00:00:00.000"},
+			{Title: "Chapter 2", Start: "00:00:01.000"},
+		},
+	}
+	if err := WriteID3v2Tag(mp3file, input); err != nil {
+		t.Fatal(err)
+	}
+	stats, err = TagStats(mp3file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(mp3file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Version != 4 || stats.Audio != 48900 || int64(stats.Size)+stats.Audio != fi.Size() {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	total := id3v2HeaderSize + stats.Padding
+	for _, f := range stats.Frames {
+		total += f.Size
+	}
+	if total != stats.Size {
+		t.Errorf("expected frames and padding to add up to %d, got %d", stats.Size, total)
+	}
+	if len(stats.Frames) != 4 || stats.Frames[0].ID != "APIC" || stats.Frames[0].Size < len(cover) {
+		t.Errorf("expected APIC first, got %+v", stats.Frames)
+	}
+	for _, f := range stats.Frames {
+		if f.ID == "CHAP" && f.Count != 2 {
+			t.Errorf("expected 2 CHAP frames, got %d", f.Count)
+		}
+	}
+}
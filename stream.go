@@ -0,0 +1,436 @@
+package id3v24
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"time"
+
+	id3v2 "github.com/bogem/id3v2"
+	"github.com/sa6mwa/mp3duration"
+	"github.com/tcolgate/mp3"
+)
+
+const id3v2HeaderSize = 10
+
+// ReadMP3Duration reads duration and frame count of the MP3 stream
+// in r, skipping a leading ID3v2 tag if present. It is the io-only
+// equivalent of mp3duration.Read; Name and ModTime are left empty and
+// Length is the number of bytes read from r.
+func ReadMP3Duration(r io.Reader) (mp3duration.Info, error) {
+	info, err := ProbeReader(r)
+	return info.Info, err
+}
+
+// scanMP3Frames calls fn for every MPEG audio frame in r with the
+// byte offset of the frame from the start of r, skipping a leading
+// ID3v2 tag. Returns the number of bytes read from r.
+func scanMP3Frames(r io.Reader, fn func(offset int64, frame *mp3.Frame)) (int64, error) {
+	return scanMP3Stream(r, fn, nil)
+}
+
+// scanMP3Stream is scanMP3Frames also skipping the ID3v2, ID3v1 and
+// APE tags between frames of chained (concatenated) MP3 streams, which
+// would otherwise be scanned for frames, calling tagFn (if not nil)
+// with the offset and size of each.
+func scanMP3Stream(r io.Reader, fn func(offset int64, frame *mp3.Frame), tagFn func(offset, size int64)) (int64, error) {
+	cr := &countingReader{r: r}
+	header := make([]byte, id3v2HeaderSize)
+	n, err := io.ReadFull(cr, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return cr.n, err
+	}
+	header = header[:n]
+	var src io.Reader = cr
+	var offset int64
+	if size := id3v2TagSize(header); size > 0 {
+		if _, err := io.CopyN(io.Discard, cr, size-int64(len(header))); err != nil {
+			return cr.n, err
+		}
+		offset = size
+	} else {
+		src = io.MultiReader(bytes.NewReader(header), cr)
+	}
+	br := bufio.NewReader(src)
+	var frame mp3.Frame
+	skipped := 0
+	decoder := mp3.NewDecoder(br)
+	for {
+		if size := embeddedTagSize(br); size > 0 {
+			discarded, err := br.Discard(int(size))
+			if err != nil {
+				break
+			}
+			if tagFn != nil {
+				tagFn(offset, size)
+			}
+			offset += int64(discarded)
+			continue
+		}
+		if err := decoder.Decode(&frame, &skipped); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return cr.n, err
+		}
+		offset += int64(skipped)
+		fn(offset, &frame)
+		offset += int64(frame.Size())
+	}
+	return cr.n, nil
+}
+
+// id3v1TagSize is the size of an ID3v1 tag.
+const id3v1TagSize = 128
+
+// apeTagHeaderSize is the size of the header and footer of an APEv2
+// tag.
+const apeTagHeaderSize = 32
+
+// embeddedTagSize returns the size of the ID3v2, ID3v1 or APE tag
+// that br is positioned at, or 0 if there is none. A frame header
+// never starts with "ID3", "TAG" or "APETAGEX".
+func embeddedTagSize(br *bufio.Reader) int64 {
+	header, _ := br.Peek(apeTagHeaderSize)
+	if size := id3v2TagSize(header); size > 0 {
+		return size
+	}
+	if len(header) >= 3 && string(header[:3]) == "TAG" {
+		return id3v1TagSize
+	}
+	if len(header) == apeTagHeaderSize && string(header[:8]) == "APETAGEX" {
+		if binary.LittleEndian.Uint32(header[20:])&(1<<29) == 0 {
+			return apeTagHeaderSize // footer, the items were skipped as junk
+		}
+		return apeTagHeaderSize + int64(binary.LittleEndian.Uint32(header[12:]))
+	}
+	return 0
+}
+
+// WriteID3v2TagTo is the io-only variant of WriteID3v2Tag. It reads
+// the MP3 from r, replaces any leading ID3v2 tag with a new one built
+// from input and writes the tagged MP3 to w. A tag appended at the
+// end of r (see HasAppendedID3v2Tag) is removed, i.e migrated to the
+// leading tag with WithTagMerge, so players do not see two
+// conflicting tags. Paths in input (e.g
+// CoverJPEG) are resolved through the file system given by WithFS,
+// or the operating system if none was given.
+func WriteID3v2TagTo(w io.Writer, r io.ReadSeeker, input TrackInfo, opts ...Option) error {
+	o := newOptions(opts...)
+	began := time.Now()
+	header := make([]byte, id3v2HeaderSize)
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return o.fail(MetricErrOpen, err)
+	}
+	audioOffset := id3v2TagSize(header[:n])
+	di, err := o.resolveDuration(r, audioOffset)
+	if err != nil {
+		return o.fail(MetricErrDuration, err)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return o.fail(MetricErrOpen, err)
+	}
+	input, err = o.mergeExistingChapters(r, audioOffset, input)
+	if err != nil {
+		return o.fail(MetricErrChapters, err)
+	}
+	existing, err := o.existingTag(r)
+	if err != nil {
+		return o.fail(MetricErrOpen, err)
+	}
+	tag := id3v2.NewEmptyTag()
+	if err := o.addTagSnapshot(r, tag); err != nil {
+		return o.fail(MetricErrOpen, err)
+	}
+	if err := setFrames(o, tag, existing, di, input); err != nil {
+		return err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return o.fail(MetricErrOpen, err)
+	}
+	record, err := o.journalRecord(r, audioOffset, tag)
+	if err != nil {
+		return o.fail(MetricErrOpen, err)
+	}
+	tagSize, err := tag.WriteTo(w)
+	if err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	appendedOffset, appendedSize, err := appendedTag(r)
+	if err != nil {
+		return o.fail(MetricErrOpen, err)
+	}
+	audioSize, err := o.copyAudio(w, r, audioOffset, appendedOffset, appendedSize)
+	if err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	if err := o.writeJournal(record); err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	o.fileTagged(tagSize+audioSize, time.Since(began))
+	return nil
+}
+
+// WithCleanStream makes WriteID3v2Tag write only the MPEG audio
+// frames after the new tag, removing leading junk, ID3v2 tags between
+// frames of chained streams and trailing APE and ID3v1 tags, e.g to
+// sanitize files from unknown sources.
+func WithCleanStream() Option {
+	return func(o *options) {
+		o.cleanStream = true
+	}
+}
+
+// copyAudio copies the audio of r, which starts at audioOffset, to w
+// and returns the number of bytes written, leaving out the appended
+// tag of appendedSize bytes at appendedOffset, if any. With
+// WithCleanStream only the audio frames are copied.
+func (o *options) copyAudio(w io.Writer, r io.ReadSeeker, audioOffset, appendedOffset, appendedSize int64) (int64, error) {
+	if !o.cleanStream {
+		if _, err := r.Seek(audioOffset, io.SeekStart); err != nil {
+			return 0, err
+		}
+		if appendedSize == 0 {
+			return io.Copy(w, r)
+		}
+		written, err := io.CopyN(w, r, appendedOffset-audioOffset)
+		if err != nil {
+			return written, err
+		}
+		if _, err := r.Seek(appendedOffset+appendedSize, io.SeekStart); err != nil {
+			return written, err
+		}
+		n, err := io.Copy(w, r) // e.g an ID3v1 tag
+		return written + n, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	var written int64
+	var werr error
+	_, err := scanMP3Stream(r, func(_ int64, frame *mp3.Frame) {
+		if werr == nil {
+			var n int64
+			n, werr = io.Copy(w, frame.Reader())
+			written += n
+		}
+	}, nil)
+	if werr != nil {
+		return written, werr
+	}
+	return written, err
+}
+
+// setFrames adds all non-empty fields of input to tag, followed by
+// the frames of existing (if not nil) that are not replaced by them.
+func setFrames(o *options, tag, existing *id3v2.Tag, di mp3duration.Info, input TrackInfo) error {
+	input, err := ApplyLicense(input)
+	if err != nil {
+		return o.fail(MetricErrInput, err)
+	}
+	if err := o.resolveYear(&input); err != nil {
+		return o.fail(MetricErrInput, err)
+	}
+	if o.strict {
+		if losses := o.lossyConversions(input); len(losses) > 0 {
+			return o.fail(MetricErrInput, &LossyWriteError{Losses: losses})
+		}
+	}
+	// Important
+	tag.SetVersion(4)
+	if o.textEncoding != nil {
+		tag.SetDefaultEncoding(o.textEncoding.id3v2Encoding())
+	}
+	// Set frames unless empty...
+	if len([]rune(input.Title)) > 0 {
+		tag.SetTitle(input.Title)
+	}
+	if len([]rune(input.Album)) > 0 {
+		tag.SetAlbum(input.Album)
+	}
+	if len([]rune(input.Artist)) > 0 {
+		tag.SetArtist(input.Artist)
+	}
+	if len([]rune(input.AlbumArtist)) > 0 {
+		tag.AddTextFrame(tag.CommonID("Band/Orchestra/Accompaniment"), tag.DefaultEncoding(), input.AlbumArtist)
+	}
+	if len([]rune(input.Composer)) > 0 {
+		tag.AddTextFrame(tag.CommonID("Composer"), tag.DefaultEncoding(), input.Composer)
+	}
+	if len([]rune(input.Grouping)) > 0 {
+		tag.AddTextFrame(tag.CommonID("Content group description"), tag.DefaultEncoding(), input.Grouping)
+		// iTunes 12.5.4 and later read the grouping from GRP1 and
+		// TIT1 as the work. GRP1 is not a text frame to id3v2, so
+		// it is not replaced by AddTextFrame.
+		tag.DeleteFrames("GRP1")
+		tag.AddTextFrame("GRP1", tag.DefaultEncoding(), input.Grouping)
+	}
+	if len([]rune(input.Genre)) > 0 {
+		tag.SetGenre(input.Genre)
+	}
+	if !input.Date.IsZero() {
+		tag.SetYear(formatDate(input.Date)) // TDRC
+	} else if len([]rune(input.Year)) > 0 {
+		tag.SetYear(input.Year)
+	}
+	if len([]rune(input.Track)) > 0 {
+		tag.AddTextFrame("TRCK", tag.DefaultEncoding(), input.Track)
+	}
+	if len([]rune(input.Disc)) > 0 {
+		tag.AddTextFrame(tag.CommonID("Part of a set"), tag.DefaultEncoding(), input.Disc)
+	}
+	if len([]rune(input.Language)) > 0 {
+		tag.AddTextFrame("TLAN", tag.DefaultEncoding(), input.Language)
+	}
+	if len([]rune(input.Comment)) > 0 {
+		AddComment(tag, input.Language, "", input.Comment)
+	}
+	for _, c := range input.Comments {
+		AddComment(tag, c.Language, c.Description, c.Text)
+	}
+	if len([]rune(input.Copyright)) > 0 {
+		tag.AddTextFrame(tag.CommonID("Copyright message"), tag.DefaultEncoding(), input.Copyright)
+	}
+	AddURLFrame(tag, "WCOP", input.CopyrightURL)
+	AddURLFrame(tag, "WOAR", input.ArtistURL)
+	AddURLFrame(tag, "WOAS", input.SourceURL)
+	AddURLFrame(tag, "WCOM", input.CommercialURL)
+	AddURLFrame(tag, "WPUB", input.PublisherURL)
+	if len([]rune(input.Mood)) > 0 {
+		tag.AddTextFrame("TMOO", tag.DefaultEncoding(), input.Mood)
+	}
+	o.addDescription(tag, input)
+	addCustomText(tag, input.Custom)
+	addUserDefinedText(tag, LicenseDescription, input.License)
+	addUserDefinedText(tag, SeasonDescription, input.Season)
+	addUserDefinedText(tag, EpisodeDescription, input.Episode)
+	addUserDefinedText(tag, EnergyLevelDescription, input.Energy)
+	addUserDefinedText(tag, ColorDescription, input.Color)
+	addUserDefinedText(tag, CuePointsDescription, input.CuePoints)
+	o.addProvenance(tag)
+	addLinks(tag, input.Links, input.Funding != "")
+	if len([]rune(input.Funding)) > 0 {
+		AddUserDefinedURLFrame(tag, FundingDescription, input.Funding)
+	}
+	if input.Loudness != nil {
+		AddRVA2(tag, *input.Loudness, o.loudnessTarget)
+	}
+	if input.Rating != nil {
+		AddRating(tag, *input.Rating)
+	}
+	if input.PlayCount > 0 {
+		SetPlayCount(tag, input.PlayCount)
+	}
+	o.addTLEN(tag, di.TimeDuration)
+	mimeType, imgData, err := coverImage(o, input)
+	if err != nil {
+		return o.fail(MetricErrCover, err)
+	}
+	if imgData != nil {
+		addCover(tag, mimeType, imgData)
+	}
+	if len(input.Chapters) > 0 {
+		if err := addCHAPAndCTOC(o, di, tag, input.Chapters); err != nil {
+			return o.fail(MetricErrChapters, err)
+		}
+		if o.chapterTXXX {
+			if err := AddChapterTXXX(tag, FlattenChapters(input.Chapters)); err != nil {
+				return o.fail(MetricErrChapters, err)
+			}
+		}
+		if o.seratoCues {
+			if err := AddSeratoCues(tag, FlattenChapters(input.Chapters)); err != nil {
+				return o.fail(MetricErrChapters, err)
+			}
+		}
+	}
+	mergeFrames(tag, existing, o.dedup)
+	if err := o.checkTagSize(tag.Size(), func() []FrameSize { return TagFrameSizes(tag) }); err != nil {
+		return o.fail(MetricErrSave, err)
+	}
+	return nil
+}
+
+// HasID3v2Tag reports whether r has an ID3v2 tag by reading only its
+// 10 byte header, e.g for fast directory scans. If r does not start
+// with one and its size is known (r has a Size method like
+// *bytes.Reader and *io.SectionReader, or a Stat method like *os.File),
+// the end of r is checked for an appended tag, see
+// HasAppendedID3v2Tag. version is the major version (3 for ID3v2.3, 4
+// for ID3v2.4) and size the total size of the tag in bytes, including
+// header and footer.
+func HasID3v2Tag(r io.ReaderAt) (version byte, size int, ok bool) {
+	version, _, size, ok = findID3v2Tag(r)
+	return version, size, ok
+}
+
+// findID3v2Tag is HasID3v2Tag also returning the offset of the tag.
+func findID3v2Tag(r io.ReaderAt) (version byte, offset int64, size int, ok bool) {
+	header := make([]byte, id3v2HeaderSize)
+	if n, _ := r.ReadAt(header, 0); n < id3v2HeaderSize {
+		return 0, 0, 0, false
+	}
+	if tagSize := id3v2TagSize(header); tagSize != 0 && header[3] != 0xFF && header[4] != 0xFF {
+		return header[3], 0, int(tagSize), true
+	}
+	end, known := readerAtSize(r)
+	if !known {
+		return 0, 0, 0, false
+	}
+	offset, size, ok = HasAppendedID3v2Tag(r, end)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	if n, _ := r.ReadAt(header, offset); n < id3v2HeaderSize {
+		return 0, 0, 0, false
+	}
+	return header[3], offset, size, true
+}
+
+// readerAtSize returns the size of r if it has a Size or Stat method.
+func readerAtSize(r io.ReaderAt) (size int64, ok bool) {
+	switch s := r.(type) {
+	case interface{ Size() int64 }:
+		return s.Size(), true
+	case interface{ Stat() (fs.FileInfo, error) }:
+		if fi, err := s.Stat(); err == nil {
+			return fi.Size(), true
+		}
+	}
+	return 0, false
+}
+
+// id3v2TagSize returns the total size of the ID3v2 tag (header,
+// frames and optional footer) described by the 10 byte header, or 0
+// if header is not an ID3v2 header.
+func id3v2TagSize(header []byte) int64 {
+	if len(header) < id3v2HeaderSize || string(header[0:3]) != "ID3" {
+		return 0
+	}
+	var size int64
+	for _, b := range header[6:10] {
+		if b&0x80 != 0 {
+			return 0
+		}
+		size = size<<7 | int64(b)
+	}
+	size += id3v2HeaderSize
+	if header[5]&0x10 != 0 {
+		size += id3v2HeaderSize // footer present
+	}
+	return size
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
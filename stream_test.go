@@ -0,0 +1,287 @@
+package id3v24
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	id3v2 "github.com/bogem/id3v2"
+	"github.com/sa6mwa/mp3duration"
+	"github.com/tcolgate/mp3"
+)
+
+func TestReadMP3Duration(t *testing.T) {
+	want, err := mp3duration.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := ReadMP3Duration(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.TimeDuration != want.TimeDuration || got.Frames != want.Frames || got.Length != want.Length {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestWriteID3v2TagTo(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"cover.jpg": &fstest.MapFile{Data: []byte("not really a jpeg")},
+	}
+	input := TrackInfo{
+		Title:     "Hello world",
+		CoverJPEG: "cover.jpg",
+		Chapters: []Chapter{
+			{Title: "Chapter 1", Start: "00:00:00"},
+			{Title: "Chapter 2", Start: "00:00:01.5"},
+		},
+	}
+	var out bytes.Buffer
+	if err := WriteID3v2TagTo(&out, bytes.NewReader(mp3), input, WithFS(fsys)); err != nil {
+		t.Fatal(err)
+	}
+	tag, err := id3v2.ParseReader(bytes.NewReader(out.Bytes()), id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.Title() != input.Title {
+		t.Errorf("expected title %q, got %q", input.Title, tag.Title())
+	}
+	if n := len(tag.GetFrames("CHAP")); n != 2 {
+		t.Errorf("expected 2 CHAP frames, got %d", n)
+	}
+	if n := len(tag.GetFrames("APIC")); n != 1 {
+		t.Errorf("expected 1 APIC frame, got %d", n)
+	}
+	if !bytes.HasSuffix(out.Bytes(), mp3) {
+		t.Error("audio was not copied unmodified after the tag")
+	}
+
+	// Re-tagging must replace, not stack, the tag.
+	var again bytes.Buffer
+	if err := WriteID3v2TagTo(&again, bytes.NewReader(out.Bytes()), TrackInfo{Title: "Again"}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(again.Bytes(), mp3) || again.Len() >= out.Len() {
+		t.Error("previous tag was not replaced")
+	}
+}
+
+func TestWriteID3v2TagToCoverData(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	png := []byte("\x89PNG\r\n\x1a\n not really a png")
+	for _, input := range []TrackInfo{
+		{CoverData: png},
+		{CoverJPEG: "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)},
+	} {
+		var out bytes.Buffer
+		if err := WriteID3v2TagTo(&out, bytes.NewReader(mp3), input); err != nil {
+			t.Fatal(err)
+		}
+		tag, err := id3v2.ParseReader(bytes.NewReader(out.Bytes()), id3v2.Options{Parse: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pics := tag.GetFrames("APIC")
+		if len(pics) != 1 {
+			t.Fatalf("expected 1 APIC frame, got %d", len(pics))
+		}
+		pic := pics[0].(id3v2.PictureFrame)
+		if pic.MimeType != "image/png" || !bytes.Equal(pic.Picture, png) {
+			t.Errorf("unexpected picture %q %q", pic.MimeType, pic.Picture)
+		}
+	}
+	var out bytes.Buffer
+	if err := WriteID3v2TagTo(&out, bytes.NewReader(mp3), TrackInfo{CoverJPEG: "data:image/png,notbase64"}); !errors.Is(err, ErrBadDataURI) {
+		t.Errorf("expected ErrBadDataURI, got %v", err)
+	}
+}
+
+func TestWithMaxTagSize(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := TrackInfo{
+		Title:     "Hello world",
+		CoverData: bytes.Repeat([]byte{0xFF}, 2000),
+		Chapters: []Chapter{
+			{Title: "Chapter 1", Start: "00:00:00"},
+			{Title: "Chapter 2", Start: "00:00:01.5"},
+		},
+	}
+	var out bytes.Buffer
+	if err := WriteID3v2TagTo(&out, bytes.NewReader(mp3), input, WithMaxTagSize(4096)); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	err = WriteID3v2TagTo(&out, bytes.NewReader(mp3), input, WithMaxTagSize(1024))
+	var sizeErr *TagSizeError
+	if !errors.Is(err, ErrTagTooLarge) || !errors.As(err, &sizeErr) {
+		t.Fatalf("expected a *TagSizeError, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Error("expected nothing written")
+	}
+	if len(sizeErr.Frames) < 3 || sizeErr.Frames[0].ID != "APIC" {
+		t.Fatalf("unexpected breakdown %v", sizeErr.Frames)
+	}
+	total := 10
+	for _, f := range sizeErr.Frames {
+		total += f.Size
+		if f.ID == "CHAP" && f.Count != 2 {
+			t.Errorf("expected 2 CHAP frames, got %d", f.Count)
+		}
+	}
+	if total != sizeErr.Size {
+		t.Errorf("breakdown adds up to %d, expected %d", total, sizeErr.Size)
+	}
+	var flac bytes.Buffer
+	if err := WriteFLACTagTo(&flac, bytes.NewReader(minimalFLAC(nil)), input, WithMaxTagSize(1024)); !errors.Is(err, ErrTagTooLarge) {
+		t.Errorf("expected ErrTagTooLarge, got %v", err)
+	}
+}
+
+func TestHasID3v2Tag(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := HasID3v2Tag(bytes.NewReader(mp3)); ok {
+		t.Error("expected no tag")
+	}
+	var out bytes.Buffer
+	if err := WriteID3v2TagTo(&out, bytes.NewReader(mp3), TrackInfo{Title: "Hello world"}); err != nil {
+		t.Fatal(err)
+	}
+	version, size, ok := HasID3v2Tag(bytes.NewReader(out.Bytes()))
+	if !ok || version != 4 || size != out.Len()-len(mp3) {
+		t.Errorf("expected version 4 and size %d, got %d %d %v", out.Len()-len(mp3), version, size, ok)
+	}
+	footer := []byte("ID3\x04\x00\x10\x00\x00\x00\x05")
+	if _, size, ok := HasID3v2Tag(bytes.NewReader(footer)); !ok || size != 25 {
+		t.Errorf("expected size 25 with footer, got %d %v", size, ok)
+	}
+	if _, _, ok := HasID3v2Tag(bytes.NewReader([]byte("ID3\x04"))); ok {
+		t.Error("expected a short header to be rejected")
+	}
+	appended := append([]byte{}, out.Bytes()[:size]...)
+	appended[5] |= 0x10 // footer present
+	appended = append(append(appended, "3DI"...), appended[3:10]...)
+	file := append(append([]byte{}, mp3...), appended...)
+	if version, size, ok := HasID3v2Tag(bytes.NewReader(file)); !ok || version != 4 || size != len(appended) {
+		t.Errorf("expected an appended version 4 tag of %d bytes, got %d %d %v", len(appended), version, size, ok)
+	}
+	if _, _, ok := HasID3v2Tag(struct{ io.ReaderAt }{bytes.NewReader(file)}); ok {
+		t.Error("expected no appended tag without a known size")
+	}
+}
+
+func TestWriteTagTo(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := TrackInfo{Title: "Episode 1"}
+	tagger := NewTagger()
+	var out bytes.Buffer
+	if err := tagger.WriteTagTo(&out, bytes.NewReader(mp3), input); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := TagStatsFrom(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Version != 4 || stats.Audio != int64(len(mp3)) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	out.Reset()
+	if err := WriteTagTo(&out, bytes.NewReader(minimalFLAC([]byte("audio"))), input); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(out.Bytes(), []byte("fLaC")) || !bytes.Contains(out.Bytes(), []byte("TITLE=Episode 1")) {
+		t.Errorf("expected a tagged FLAC stream, got %q", out.Bytes())
+	}
+	out.Reset()
+	wav := append([]byte("RIFF\x00\x00\x00\x00WAVEfmt "), make([]byte, 32)...)
+	if err := WriteTagTo(&out, bytes.NewReader(wav), input); !errors.Is(err, ErrUnsupportedFormat) || out.Len() != 0 {
+		t.Errorf("expected %v and no output, got %v and %d bytes", ErrUnsupportedFormat, err, out.Len())
+	}
+}
+
+func TestAddCoverJPEGFrom(t *testing.T) {
+	tag := id3v2.NewEmptyTag()
+	if err := AddCoverJPEGFrom(tag, bytes.NewReader([]byte("jpeg"))); err != nil {
+		t.Fatal(err)
+	}
+	if cover := tagCover(tag); string(cover) != "jpeg" {
+		t.Errorf("expected %q, got %q", "jpeg", cover)
+	}
+}
+
+func TestWithCleanStream(t *testing.T) {
+	data, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var offsets []int64
+	if _, err := scanMP3Frames(bytes.NewReader(data), func(offset int64, _ *mp3.Frame) { offsets = append(offsets, offset) }); err != nil {
+		t.Fatal(err)
+	}
+	var tagged bytes.Buffer
+	if err := WriteID3v2TagTo(&tagged, bytes.NewReader(data), TrackInfo{Title: "Embedded"}); err != nil {
+		t.Fatal(err)
+	}
+	embedded := tagged.Bytes()[:id3v2TagSize(tagged.Bytes())]
+	items := []byte("\x05\x00\x00\x00\x00\x00\x00\x00Title\x00Hello")
+	apeHeader := func(flags uint32) []byte {
+		h := []byte("APETAGEX")
+		h = binary.LittleEndian.AppendUint32(h, 2000)
+		h = binary.LittleEndian.AppendUint32(h, uint32(len(items)+32))
+		h = binary.LittleEndian.AppendUint32(h, 1)
+		h = binary.LittleEndian.AppendUint32(h, flags)
+		return append(h, make([]byte, 8)...)
+	}
+	var dirty bytes.Buffer
+	dirty.WriteString("junk!")
+	dirty.Write(data[:offsets[20]])
+	dirty.Write(embedded)
+	dirty.Write(data[offsets[20]:])
+	dirty.Write(apeHeader(1<<31 | 1<<29))
+	dirty.Write(items)
+	dirty.Write(apeHeader(1 << 31))
+	dirty.WriteString("TAG")
+	dirty.Write(make([]byte, id3v1TagSize-3))
+	var out bytes.Buffer
+	if err := WriteID3v2TagTo(&out, bytes.NewReader(dirty.Bytes()), TrackInfo{Title: "Clean"}, WithCleanStream()); err != nil {
+		t.Fatal(err)
+	}
+	tagSize := id3v2TagSize(out.Bytes())
+	if !bytes.Equal(out.Bytes()[tagSize:], data) {
+		t.Errorf("expected the %d bytes of audio after the tag, got %d bytes", len(data), int64(out.Len())-tagSize)
+	}
+	info, err := ProbeReader(bytes.NewReader(dirty.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.EmbeddedTags != 1 {
+		t.Errorf("expected 1 embedded tag, got %d", info.EmbeddedTags)
+	}
+}
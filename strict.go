@@ -0,0 +1,138 @@
+package id3v24
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrLossyWrite error = errors.New("track info can not be written without loss")
+
+// LossyWriteError is returned in strict mode (see WithStrict) when
+// fields of a TrackInfo can not be represented in the tag. It wraps
+// ErrLossyWrite and lists every lossy conversion.
+type LossyWriteError struct {
+	Losses []string
+}
+
+func (e *LossyWriteError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrLossyWrite, strings.Join(e.Losses, "; "))
+}
+
+func (e *LossyWriteError) Unwrap() error {
+	return ErrLossyWrite
+}
+
+// WithStrict makes the ID3v2 writers fail with a *LossyWriteError
+// instead of silently dropping, truncating or rewriting fields of a
+// TrackInfo that the tag can not represent, e.g the time of day of
+// Date, two comments with the same language and description, chapter
+// times finer than milliseconds or more chapters than the Serato cues
+// of WithSeratoCues hold. Off by default.
+func WithStrict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// lossyConversions returns a description of every field of input that
+// setFrames would not write as is with the options of o.
+func (o *options) lossyConversions(input TrackInfo) []string {
+	var losses []string
+	lossf := func(format string, args ...any) {
+		losses = append(losses, fmt.Sprintf(format, args...))
+	}
+
+	if !input.Date.IsZero() {
+		if h, m, s := input.Date.UTC().Clock(); h != 0 || m != 0 || s != 0 || input.Date.Nanosecond() != 0 {
+			lossf("date %s is written without the time of day as %s", input.Date.Format(time.RFC3339), formatDate(input.Date))
+		}
+	}
+
+	type commentKey struct{ language, description string }
+	comments := map[commentKey]string{}
+	addComment := func(what, language, description string) {
+		if language != "" && len(language) != 3 {
+			lossf("%s language %q is not an ISO 639-2 code and is written as XXX", what, language)
+		}
+		if len(language) != 3 {
+			language = "XXX"
+		}
+		key := commentKey{strings.ToLower(language), description}
+		if previous, ok := comments[key]; ok {
+			lossf("%s replaces %s, both in language %s with description %q", what, previous, language, description)
+		}
+		comments[key] = what
+	}
+	if o.descriptionFrames&DescriptionCOMM != 0 && input.Description != "" {
+		addComment("description", input.Language, "")
+	}
+	if input.Comment != "" {
+		addComment("comment", input.Language, "")
+	}
+	for i, c := range input.Comments {
+		addComment(fmt.Sprintf("comment %d", i+1), c.Language, c.Description)
+	}
+
+	dedicated := map[string]string{
+		LicenseDescription:     input.License,
+		SeasonDescription:      input.Season,
+		EpisodeDescription:     input.Episode,
+		EnergyLevelDescription: input.Energy,
+		ColorDescription:       input.Color,
+		CuePointsDescription:   input.CuePoints,
+	}
+	for key, value := range input.Custom {
+		switch {
+		case value == "":
+		case key == "":
+			lossf("custom value %q without a key is not written", value)
+		case dedicated[key] != "" && dedicated[key] != value:
+			lossf("custom %s is replaced by the field of the same TXXX frame", key)
+		case key == TaggedByDescription && o.provenance != nil && o.provenance.Tool != "":
+			lossf("custom %s is replaced by the provenance", key)
+		case o.chapterTXXX && len(input.Chapters) > 0 && isChapterTXXX(key):
+			lossf("custom %s is replaced by the chapter TXXX frames", key)
+		}
+	}
+	if url := input.Links[FundingDescription]; input.Funding != "" && url != "" && url != input.Funding {
+		lossf("link %s is replaced by the funding URL", FundingDescription)
+	}
+
+	// URL frames are ISO-8859-1, but written byte for byte.
+	urls := map[string]string{
+		"copyright URL":  input.CopyrightURL,
+		"artist URL":     input.ArtistURL,
+		"source URL":     input.SourceURL,
+		"commercial URL": input.CommercialURL,
+		"publisher URL":  input.PublisherURL,
+		"funding URL":    input.Funding,
+	}
+	for key, url := range input.Links {
+		urls["link "+key] = url
+	}
+	for what, url := range urls {
+		if strings.IndexFunc(url, func(r rune) bool { return r > 0x7F }) >= 0 {
+			lossf("%s %q has non-ASCII characters, URL frames are ISO-8859-1", what, url)
+		}
+	}
+
+	flat := FlattenChapters(input.Chapters)
+	for i, ch := range flat {
+		for _, t := range []struct{ what, value string }{{"start", ch.Start}, {"end", ch.End}} {
+			if d, err := StringTimeToDuration(t.value); err == nil && d%time.Millisecond != 0 {
+				lossf("chapter %d %s %s is rounded down to milliseconds", i+1, t.what, t.value)
+			}
+		}
+	}
+	if o.seratoCues && len(flat) > SeratoMaxCues {
+		lossf("only the first %d of %d chapters are written as Serato cues", SeratoMaxCues, len(flat))
+	}
+	if o.chapterTXXX && len(flat) > 999 {
+		lossf("%d chapters do not fit the three digits of the chapter TXXX frames", len(flat))
+	}
+	sort.Strings(losses) // some are found iterating maps
+	return losses
+}
@@ -0,0 +1,62 @@
+package id3v24
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithStrict(t *testing.T) {
+	name := copyTestMP3(t)
+	lossless := TrackInfo{
+		Title:    "Episode 1",
+		Year:     "2024",
+		Date:     time.Date(2024, time.September, 17, 0, 0, 0, 0, time.UTC),
+		Language: "eng",
+		Comment:  "A comment.",
+		Comments: []Comment{{Language: "swe", Text: "En kommentar."}},
+		Chapters: []Chapter{{Title: "Intro", Start: "0"}, {Title: "Outro", Start: "00:00:01.500"}},
+	}
+	if err := WriteID3v2Tag(name, lossless, WithStrict()); err != nil {
+		t.Fatalf("expected a lossless write, got %v", err)
+	}
+
+	lossy := lossless
+	lossy.Date = time.Date(2024, time.September, 17, 10, 30, 0, 0, time.UTC)
+	lossy.Comments = []Comment{{Language: "english", Text: "Another comment."}, {Language: "eng", Text: "Replaces the comment."}}
+	lossy.Custom = map[string]string{SeasonDescription: "3"}
+	lossy.Season = "2"
+	lossy.SourceURL = "https://example.com/avsnitt/första"
+	lossy.Chapters = []Chapter{{Title: "Intro", Start: "0"}, {Title: "Outro", Start: "00:00:01.5005"}}
+	err := WriteID3v2Tag(name, lossy, WithStrict())
+	var lossErr *LossyWriteError
+	if !errors.As(err, &lossErr) || !errors.Is(err, ErrLossyWrite) {
+		t.Fatalf("expected a *LossyWriteError, got %v", err)
+	}
+	if len(lossErr.Losses) != 6 {
+		t.Errorf("expected 6 losses, got %d: %v", len(lossErr.Losses), err)
+	}
+	input, err := ReadID3v2Tag(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(input.Chapters) != 2 || input.Chapters[1].Start != "00:00:01.500" || input.Season != "" {
+		t.Errorf("expected the file to be left as is, got %+v", input)
+	}
+	if err := WriteID3v2Tag(name, lossy); err != nil {
+		t.Errorf("expected lossy writes to succeed without WithStrict, got %v", err)
+	}
+}
+
+func TestStrictDateInUTC(t *testing.T) {
+	o := newOptions(WithStrict())
+	zone := time.FixedZone("CEST", 2*60*60)
+	midnightUTC := time.Date(2024, time.September, 17, 2, 0, 0, 0, zone)
+	if losses := o.lossyConversions(TrackInfo{Date: midnightUTC}); len(losses) != 0 {
+		t.Errorf("expected midnight UTC to be lossless, got %v", losses)
+	}
+	midnightLocal := time.Date(2024, time.September, 17, 0, 0, 0, 0, zone)
+	if losses := o.lossyConversions(TrackInfo{Date: midnightLocal}); len(losses) != 1 {
+		t.Errorf("expected the time of day of midnight CEST to be lost, got %v", losses)
+	}
+}
@@ -0,0 +1,132 @@
+package id3v24
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Tagger holds a set of options and a cache of cover images for
+// programs tagging many files, e.g every episode of a show. Its
+// methods are the package functions of the same name with the
+// Tagger's options applied before the per-call options.
+//
+// A Tagger is safe for concurrent use by multiple goroutines, as are
+// the package functions, which share no mutable state. Metrics and
+// the WithWarnings handler may however be called concurrently and
+// must then be safe for concurrent use themselves.
+type Tagger struct {
+	opts   []Option
+	covers *fileCache
+}
+
+// NewTagger returns a Tagger applying opts to every call. Cover images
+// read from the operating system (not through WithFS) are cached and
+// re-read only when their size or modification time changes.
+func NewTagger(opts ...Option) *Tagger {
+	t := &Tagger{covers: &fileCache{files: map[string]cachedFile{}}}
+	t.opts = append([]Option{withFileCache(t.covers)}, opts...)
+	return t
+}
+
+func (t *Tagger) options(opts []Option) []Option {
+	return append(append([]Option(nil), t.opts...), opts...)
+}
+
+// WriteTag is WriteTag with the options of t.
+func (t *Tagger) WriteTag(path string, input TrackInfo, opts ...Option) error {
+	return WriteTag(path, input, t.options(opts)...)
+}
+
+// WriteID3v2Tag is WriteID3v2Tag with the options of t.
+func (t *Tagger) WriteID3v2Tag(mp3file string, input TrackInfo, opts ...Option) error {
+	return WriteID3v2Tag(mp3file, input, t.options(opts)...)
+}
+
+// WriteFLACTag is WriteFLACTag with the options of t.
+func (t *Tagger) WriteFLACTag(flacfile string, input TrackInfo, opts ...Option) error {
+	return WriteFLACTag(flacfile, input, t.options(opts)...)
+}
+
+// WriteDSFTag is WriteDSFTag with the options of t.
+func (t *Tagger) WriteDSFTag(dsffile string, input TrackInfo, opts ...Option) error {
+	return WriteDSFTag(dsffile, input, t.options(opts)...)
+}
+
+// WriteTagTo is WriteTagTo with the options of t.
+func (t *Tagger) WriteTagTo(w io.Writer, r io.ReadSeeker, input TrackInfo, opts ...Option) error {
+	return WriteTagTo(w, r, input, t.options(opts)...)
+}
+
+// WriteID3v2TagTo is WriteID3v2TagTo with the options of t.
+func (t *Tagger) WriteID3v2TagTo(w io.Writer, r io.ReadSeeker, input TrackInfo, opts ...Option) error {
+	return WriteID3v2TagTo(w, r, input, t.options(opts)...)
+}
+
+// WriteFLACTagTo is WriteFLACTagTo with the options of t.
+func (t *Tagger) WriteFLACTagTo(w io.Writer, r io.Reader, input TrackInfo, opts ...Option) error {
+	return WriteFLACTagTo(w, r, input, t.options(opts)...)
+}
+
+// WriteDSFTagTo is WriteDSFTagTo with the options of t.
+func (t *Tagger) WriteDSFTagTo(w io.Writer, r io.ReadSeeker, input TrackInfo, opts ...Option) error {
+	return WriteDSFTagTo(w, r, input, t.options(opts)...)
+}
+
+// GetFFmpegMetadata is GetFFmpegMetadata with the options of t.
+func (t *Tagger) GetFFmpegMetadata(duration time.Duration, input TrackInfo, opts ...Option) ([]byte, error) {
+	return GetFFmpegMetadata(duration, input, t.options(opts)...)
+}
+
+// GetFFmpegChapters is GetFFmpegChapters with the options of t.
+func (t *Tagger) GetFFmpegChapters(duration time.Duration, chapters []Chapter, opts ...Option) ([]byte, error) {
+	return GetFFmpegChapters(duration, chapters, t.options(opts)...)
+}
+
+// VorbisComments is VorbisComments with the options of t.
+func (t *Tagger) VorbisComments(input TrackInfo, opts ...Option) ([]string, error) {
+	return VorbisComments(input, t.options(opts)...)
+}
+
+// fileCache caches files read from the operating system by
+// options.readFile.
+type fileCache struct {
+	mu    sync.Mutex
+	files map[string]cachedFile
+}
+
+type cachedFile struct {
+	size    int64
+	modTime time.Time
+	data    []byte
+}
+
+func withFileCache(c *fileCache) Option {
+	return func(o *options) {
+		o.files = c
+	}
+}
+
+// readFile returns the content of name, from the cache unless name
+// has changed since it was cached.
+func (c *fileCache) readFile(name string) ([]byte, error) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	cached, ok := c.files[name]
+	c.mu.Unlock()
+	if ok && cached.size == fi.Size() && cached.modTime.Equal(fi.ModTime()) {
+		return cached.data, nil
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.files[name] = cachedFile{size: fi.Size(), modTime: fi.ModTime(), data: data}
+	c.mu.Unlock()
+	return data, nil
+}
@@ -0,0 +1,49 @@
+package id3v24
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+func TestTaggerConcurrent(t *testing.T) {
+	cover := filepath.Join(t.TempDir(), "cover.jpg")
+	if err := os.WriteFile(cover, []byte("\xFF\xD8\xFF not really a jpeg"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tagger := NewTagger()
+	files := make([]string, 4)
+	for i := range files {
+		files[i] = copyTestMP3(t)
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, len(files))
+	for i, f := range files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = tagger.WriteID3v2Tag(f, TrackInfo{Title: "Hello world", CoverJPEG: cover})
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range files {
+		tag, err := id3v2.Open(f, id3v2.Options{Parse: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pics := tag.GetFrames("APIC")
+		tag.Close()
+		if len(pics) != 1 || !bytes.HasPrefix(pics[0].(id3v2.PictureFrame).Picture, []byte("\xFF\xD8\xFF")) {
+			t.Errorf("%s: expected the cover", f)
+		}
+	}
+}
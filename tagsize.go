@@ -0,0 +1,84 @@
+package id3v24
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// FrameSize is the total size in bytes, including frame headers, of
+// all frames (or FLAC metadata blocks) with the same ID in a tag.
+type FrameSize struct {
+	ID    string
+	Count int
+	Size  int
+}
+
+// TagSizeError is returned when a tag would exceed the size given to
+// WithMaxTagSize. It wraps ErrTagTooLarge and breaks the size down
+// per frame ID, largest first.
+type TagSizeError struct {
+	Size   int
+	Max    int
+	Frames []FrameSize
+}
+
+func (e *TagSizeError) Error() string {
+	parts := make([]string, len(e.Frames))
+	for i, f := range e.Frames {
+		parts[i] = fmt.Sprintf("%s %d", f.ID, f.Size)
+		if f.Count > 1 {
+			parts[i] = fmt.Sprintf("%dx%s %d", f.Count, f.ID, f.Size)
+		}
+	}
+	return fmt.Sprintf("%v: %d bytes exceeds the maximum of %d (%s)", ErrTagTooLarge, e.Size, e.Max, strings.Join(parts, ", "))
+}
+
+func (e *TagSizeError) Unwrap() error {
+	return ErrTagTooLarge
+}
+
+// WithMaxTagSize makes the tag writers fail with a *TagSizeError
+// instead of writing a tag (or FLAC metadata) larger than max bytes,
+// as some hosting platforms and players reject files with multi-MB
+// tags. There is no limit by default.
+func WithMaxTagSize(max int) Option {
+	return func(o *options) {
+		o.maxTagSize = max
+	}
+}
+
+// TagFrameSizes returns the sizes of the frames in tag per frame ID,
+// largest first.
+func TagFrameSizes(tag *id3v2.Tag) []FrameSize {
+	var sizes []FrameSize
+	for id, frames := range tag.AllFrames() {
+		fs := FrameSize{ID: id, Count: len(frames)}
+		for _, f := range frames {
+			fs.Size += id3v2HeaderSize + f.Size()
+		}
+		sizes = append(sizes, fs)
+	}
+	sortFrameSizes(sizes)
+	return sizes
+}
+
+func sortFrameSizes(sizes []FrameSize) {
+	sort.Slice(sizes, func(i, j int) bool {
+		if sizes[i].Size != sizes[j].Size {
+			return sizes[i].Size > sizes[j].Size
+		}
+		return sizes[i].ID < sizes[j].ID
+	})
+}
+
+// checkTagSize returns a *TagSizeError if size exceeds the maximum
+// given by WithMaxTagSize, frames is called for the breakdown.
+func (o *options) checkTagSize(size int, frames func() []FrameSize) error {
+	if o.maxTagSize <= 0 || size <= o.maxTagSize {
+		return nil
+	}
+	return &TagSizeError{Size: size, Max: o.maxTagSize, Frames: frames()}
+}
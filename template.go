@@ -0,0 +1,92 @@
+package id3v24
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrBadTemplateName error = errors.New("template name must be a plain file name")
+
+// TemplateDir returns the directory holding named tag templates,
+// $XDG_CONFIG_HOME/id3v24/templates (usually
+// ~/.config/id3v24/templates) on Unix. See os.UserConfigDir for other
+// platforms.
+func TemplateDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "id3v24", "templates"), nil
+}
+
+// LoadTemplate reads the template name (e.g "myshow" for
+// myshow.yaml) from TemplateDir. A template is a YAML encoded
+// TrackInfo with the defaults of a show or season, e.g:
+//
+//	artist: My Show
+//	genre: Podcast
+//	coverJPEG: myshow.jpg
+//	copyright: Copyright 2024 My Show
+//
+// Relative CoverJPEG paths are resolved against TemplateDir.
+func LoadTemplate(name string) (TrackInfo, error) {
+	if name == "" || filepath.Base(name) != name || strings.HasPrefix(name, ".") {
+		return TrackInfo{}, fmt.Errorf("%w: %q", ErrBadTemplateName, name)
+	}
+	dir, err := TemplateDir()
+	if err != nil {
+		return TrackInfo{}, err
+	}
+	path := filepath.Join(dir, name+".yaml")
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if _, err := os.Stat(filepath.Join(dir, name+".yml")); err == nil {
+			path = filepath.Join(dir, name+".yml")
+		}
+	}
+	return ReadTemplateFile(path)
+}
+
+// ReadTemplateFile reads a YAML template (see LoadTemplate) from path.
+// Relative CoverJPEG paths are resolved against the directory of
+// path.
+func ReadTemplateFile(path string) (TrackInfo, error) {
+	var template TrackInfo
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return template, err
+	}
+	if err := yaml.Unmarshal(data, &template); err != nil {
+		return template, fmt.Errorf("%s: %w", path, err)
+	}
+	if cover := template.CoverJPEG; cover != "" && !strings.HasPrefix(cover, "data:") && !filepath.IsAbs(cover) {
+		template.CoverJPEG = filepath.Join(filepath.Dir(path), cover)
+	}
+	return template, nil
+}
+
+// ApplyTemplate returns input with every empty field set from
+// template, e.g to merge show defaults like artist, genre, cover and
+// copyright into an episode. Chapters are never taken from the
+// template, and Year and Date only if input has neither, so that the
+// year of a show does not conflict with the date of an episode.
+func ApplyTemplate(input, template TrackInfo) TrackInfo {
+	template.Chapters = nil
+	if input.Year != "" || !input.Date.IsZero() {
+		template.Year, template.Date = "", time.Time{}
+	}
+	dst := reflect.ValueOf(&input).Elem()
+	src := reflect.ValueOf(template)
+	for i := 0; i < dst.NumField(); i++ {
+		if dst.Field(i).IsZero() {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+	return input
+}
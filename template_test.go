@@ -0,0 +1,61 @@
+package id3v24
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadTemplate(t *testing.T) {
+	config := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", config)
+	dir := filepath.Join(config, "id3v24", "templates")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	yaml := "artist: My Show\ngenre: Podcast\ncoverJPEG: myshow.jpg\ndate: 2024-01-02\nchapters:\n  - title: Intro\n    start: \"00:00:00\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "myshow.yaml"), []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	template, err := LoadTemplate("myshow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if template.CoverJPEG != filepath.Join(dir, "myshow.jpg") {
+		t.Errorf("expected cover %q, got %q", filepath.Join(dir, "myshow.jpg"), template.CoverJPEG)
+	}
+	if !template.Date.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date %v", template.Date)
+	}
+	episode := ApplyTemplate(TrackInfo{Title: "Episode 1", Genre: "Comedy"}, template)
+	if episode.Title != "Episode 1" || episode.Artist != "My Show" || episode.Genre != "Comedy" {
+		t.Errorf("unexpected merge result %+v", episode)
+	}
+	if len(episode.Chapters) != 0 {
+		t.Errorf("expected no chapters from the template, got %d", len(episode.Chapters))
+	}
+	if _, err := LoadTemplate("missing"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, got %v", err)
+	}
+	if _, err := LoadTemplate("../myshow"); !errors.Is(err, ErrBadTemplateName) {
+		t.Errorf("expected ErrBadTemplateName, got %v", err)
+	}
+}
+
+func TestApplyTemplateDate(t *testing.T) {
+	template := TrackInfo{Artist: "My Show", Year: "2024"}
+	date := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	episode := ApplyTemplate(TrackInfo{Title: "Episode 1", Date: date}, template)
+	if episode.Year != "" || !episode.Date.Equal(date) || episode.Artist != "My Show" {
+		t.Errorf("expected the date of the episode without the year of the template, got %+v", episode)
+	}
+	if _, err := GetFFmpegMetadata(time.Minute, episode); err != nil {
+		t.Errorf("expected no conflict, got %v", err)
+	}
+	episode = ApplyTemplate(TrackInfo{Title: "Episode 2"}, template)
+	if episode.Year != "2024" {
+		t.Errorf("expected the year of the template, got %q", episode.Year)
+	}
+}
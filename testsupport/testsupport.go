@@ -0,0 +1,125 @@
+// Package testsupport has helpers for golden tests of code that tags
+// audio with github.com/sa6mwa/id3v24: silent MP3 audio and
+// deterministically tagged copies of it built entirely in memory, and
+// decoding and diffing of the frames of tags.
+package testsupport
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/sa6mwa/id3v24"
+)
+
+var ErrNoTag error = errors.New("no ID3v2 tag")
+
+const (
+	headerSize    = 10
+	frameDuration = 1152 * time.Second / 44100
+)
+
+// silentFrame is an MPEG-1 Layer III frame, 128 kbit/s, 44.1 kHz,
+// joint stereo, of silence.
+var silentFrame = append([]byte{0xFF, 0xFB, 0x90, 0x64}, make([]byte, 413)...)
+
+// SilentMP3 returns an untagged MP3 of silence at least d long (at
+// least one frame).
+func SilentMP3(d time.Duration) []byte {
+	n := int((d + frameDuration - 1) / frameDuration)
+	if n < 1 {
+		n = 1
+	}
+	return bytes.Repeat(silentFrame, n)
+}
+
+// TaggedMP3 returns SilentMP3(d) tagged with input by
+// id3v24.WriteID3v2TagTo. The frames of the tag are in canonical
+// order, see Canonical, so the result can be compared byte by byte
+// with a golden file.
+func TaggedMP3(d time.Duration, input id3v24.TrackInfo, opts ...id3v24.Option) ([]byte, error) {
+	var b bytes.Buffer
+	if err := id3v24.WriteID3v2TagTo(&b, bytes.NewReader(SilentMP3(d)), input, opts...); err != nil {
+		return nil, err
+	}
+	return Canonical(b.Bytes())
+}
+
+// Canonical returns a copy of mp3 with the frames of its leading
+// ID3v2.3 or ID3v2.4 tag sorted by ID and content, as the order of
+// frames written by github.com/bogem/id3v2 is random. The size of the
+// tag, and so the padding, is unchanged. Returns ErrNoTag if mp3 does
+// not start with a tag.
+func Canonical(mp3 []byte) ([]byte, error) {
+	if len(mp3) < headerSize || string(mp3[0:3]) != "ID3" {
+		return nil, ErrNoTag
+	}
+	version, flags := mp3[3], mp3[5]
+	if version != 3 && version != 4 || flags&0xC0 != 0 {
+		// Older versions, unsynchronisation and extended headers
+		// are never written by this package.
+		return nil, id3v24.ErrBadFrame
+	}
+	size := headerSize + synchsafe(mp3[6:10])
+	if size > len(mp3) {
+		return nil, id3v24.ErrBadFrame
+	}
+	var frames [][]byte
+	b := mp3[headerSize:size]
+	for len(b) >= headerSize && b[0] != 0x00 {
+		frameSize := synchsafe(b[4:8])
+		if version == 3 {
+			frameSize = int(b[4])<<24 | int(b[5])<<16 | int(b[6])<<8 | int(b[7])
+		}
+		if frameSize > len(b)-headerSize {
+			return nil, id3v24.ErrBadFrame
+		}
+		frames = append(frames, b[:headerSize+frameSize])
+		b = b[headerSize+frameSize:]
+	}
+	sort.SliceStable(frames, func(i, j int) bool { return bytes.Compare(frames[i], frames[j]) < 0 })
+	canonical := make([]byte, 0, len(mp3))
+	canonical = append(canonical, mp3[:headerSize]...)
+	for _, f := range frames {
+		canonical = append(canonical, f...)
+	}
+	canonical = append(canonical, b...) // padding
+	return append(canonical, mp3[size:]...), nil
+}
+
+func synchsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
+
+// Decode returns the decoded frames of the ID3v2 tag at the start of
+// mp3 except those with an ID in ignoreFrames, see
+// id3v24.TagFrameSet.
+func Decode(mp3 []byte, ignoreFrames ...string) (id3v24.FrameSet, error) {
+	return id3v24.ReadFrameSet(bytes.NewReader(mp3), ignoreFrames...)
+}
+
+// DecodeFrom is Decode reading the tag from r.
+func DecodeFrom(r io.Reader, ignoreFrames ...string) (id3v24.FrameSet, error) {
+	return id3v24.ReadFrameSet(r, ignoreFrames...)
+}
+
+// AssertFrames fails t with one error per difference between the
+// decoded frames of the tags of got and want, ignoring frames with an
+// ID in ignoreFrames.
+func AssertFrames(t testing.TB, got, want []byte, ignoreFrames ...string) {
+	t.Helper()
+	gotFrames, err := Decode(got, ignoreFrames...)
+	if err != nil {
+		t.Fatalf("decoding got: %v", err)
+	}
+	wantFrames, err := Decode(want, ignoreFrames...)
+	if err != nil {
+		t.Fatalf("decoding want: %v", err)
+	}
+	for _, diff := range gotFrames.Diff(wantFrames) {
+		t.Error(diff)
+	}
+}
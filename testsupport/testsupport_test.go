@@ -0,0 +1,66 @@
+package testsupport
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/sa6mwa/id3v24"
+)
+
+func TestTaggedMP3(t *testing.T) {
+	input := id3v24.TrackInfo{
+		Title:  "Episode 1",
+		Artist: "The Hosts",
+		Genre:  "Podcast",
+		Chapters: []id3v24.Chapter{
+			{Title: "Intro", Start: "00:00:00"},
+			{Title: "Main", Start: "00:00:05"},
+		},
+	}
+	first, err := TaggedMP3(10*time.Second, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		again, err := TaggedMP3(10*time.Second, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(first, again) {
+			t.Fatal("TaggedMP3 is not deterministic")
+		}
+	}
+	info, err := id3v24.ReadMP3Duration(bytes.NewReader(first))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.TimeDuration < 10*time.Second || info.TimeDuration > 10*time.Second+frameDuration {
+		t.Errorf("expected 10s of audio, got %v", info.TimeDuration)
+	}
+	AssertFrames(t, first, first)
+	input.Artist = "Someone Else"
+	changed, err := TaggedMP3(10*time.Second, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotFrames, err := Decode(changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFrames, err := Decode(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffs := gotFrames.Diff(wantFrames)
+	if len(diffs) != 1 || diffs[0] != `TPE1: got "Someone Else", want "The Hosts"` {
+		t.Errorf("unexpected differences %q", diffs)
+	}
+	AssertFrames(t, changed, first, "TPE1")
+}
+
+func TestCanonical(t *testing.T) {
+	if _, err := Canonical(SilentMP3(time.Second)); err != ErrNoTag {
+		t.Errorf("expected %v, got %v", ErrNoTag, err)
+	}
+}
@@ -0,0 +1,94 @@
+package id3v24
+
+import (
+	"unicode/utf16"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// TextEncoding selects how text is encoded in the frames written by
+// this package, for interoperability with parsers that only accept
+// one flavour of UTF-16. See WithTextEncoding.
+type TextEncoding int
+
+const (
+	// UTF16LEBOM is UTF-16 with a little-endian byte order mark
+	// (encoding 0x01, FF FE), the default for chapter titles.
+	UTF16LEBOM TextEncoding = iota
+	// UTF16BEBOM is UTF-16 with a big-endian byte order mark
+	// (encoding 0x01, FE FF).
+	UTF16BEBOM
+	// UTF16BE is big-endian UTF-16 without byte order mark
+	// (encoding 0x02).
+	UTF16BE
+	// UTF8 is UTF-8 (encoding 0x03).
+	UTF8
+)
+
+// Key returns the ID3v2 text encoding byte of e.
+func (e TextEncoding) Key() byte {
+	switch e {
+	case UTF16BE:
+		return id3v2.EncodingUTF16BE.Key
+	case UTF8:
+		return id3v2.EncodingUTF8.Key
+	}
+	return id3v2.EncodingUTF16.Key
+}
+
+// id3v2Encoding returns the bogem/id3v2 encoding with the same key as
+// e.
+func (e TextEncoding) id3v2Encoding() id3v2.Encoding {
+	switch e {
+	case UTF16BE:
+		return id3v2.EncodingUTF16BE
+	case UTF8:
+		return id3v2.EncodingUTF8
+	}
+	return id3v2.EncodingUTF16
+}
+
+// WithTextEncoding makes the writers encode text frames with e
+// instead of the defaults; UTF16LEBOM for chapter titles and UTF-8
+// for everything else. Frames written by bogem/id3v2 (title, album,
+// artist, etc) always get a big-endian byte order mark when e is
+// UTF16LEBOM or UTF16BEBOM, only the chapter sub-frames honour the
+// byte order of e.
+func WithTextEncoding(e TextEncoding) Option {
+	return func(o *options) {
+		o.textEncoding = &e
+	}
+}
+
+// EncodeText returns the body of a text frame holding text encoded
+// as e; the encoding byte followed by the encoded text without
+// terminator.
+func EncodeText(text string, e TextEncoding) []byte {
+	body := []byte{e.Key()}
+	if e == UTF8 {
+		return append(body, []byte(text)...)
+	}
+	switch e {
+	case UTF16LEBOM:
+		body = append(body, 0xFF, 0xFE)
+	case UTF16BEBOM:
+		body = append(body, 0xFE, 0xFF)
+	}
+	for _, u := range utf16.Encode([]rune(text)) {
+		if e == UTF16LEBOM {
+			body = append(body, byte(u), byte(u>>8))
+		} else {
+			body = append(body, byte(u>>8), byte(u))
+		}
+	}
+	return body
+}
+
+// chapterTitleFrame returns the body of the TIT2 (or other text)
+// sub-frame of a CHAP frame with title.
+func (o *options) chapterTitleFrame(title string) []byte {
+	if o.textEncoding == nil {
+		return TextFrame(title)
+	}
+	return EncodeText(title, *o.textEncoding)
+}
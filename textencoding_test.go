@@ -0,0 +1,64 @@
+package id3v24
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+func TestEncodeText(t *testing.T) {
+	for _, tc := range []struct {
+		e        TextEncoding
+		expected []byte
+	}{
+		{UTF16LEBOM, []byte{0x01, 0xFF, 0xFE, 'A', 0x00, 0x3C, 0xD8, 0xA7, 0xDF}},
+		{UTF16BEBOM, []byte{0x01, 0xFE, 0xFF, 0x00, 'A', 0xD8, 0x3C, 0xDF, 0xA7}},
+		{UTF16BE, []byte{0x02, 0x00, 'A', 0xD8, 0x3C, 0xDF, 0xA7}},
+		{UTF8, []byte{0x03, 'A', 0xF0, 0x9F, 0x8E, 0xA7}},
+	} {
+		if got := EncodeText("A🎧", tc.e); !bytes.Equal(got, tc.expected) {
+			t.Errorf("encoding %d: expected % x, got % x", tc.e, tc.expected, got)
+		}
+	}
+}
+
+func TestWithTextEncoding(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := TrackInfo{
+		Title: "Avsnitt 1 – Början",
+		Chapters: []Chapter{
+			{Title: "Början 🎧", Start: "00:00:00.000"},
+			{Title: "第二章", Start: "00:00:01.000"},
+		},
+	}
+	for _, e := range []TextEncoding{UTF16LEBOM, UTF16BEBOM, UTF16BE, UTF8} {
+		var out bytes.Buffer
+		if err := WriteID3v2TagTo(&out, bytes.NewReader(mp3), input, WithTextEncoding(e)); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Contains(out.Bytes(), EncodeText(input.Chapters[1].Title, e)) {
+			t.Errorf("encoding %d: chapter title not encoded as expected", e)
+		}
+		tag, err := id3v2.ParseReader(bytes.NewReader(out.Bytes()), id3v2.Options{Parse: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if frame := tag.GetTextFrame("TIT2"); frame.Encoding.Key != e.Key() || frame.Text != input.Title {
+			t.Errorf("encoding %d: expected %q with key %d, got %q with key %d", e, input.Title, e.Key(), frame.Text, frame.Encoding.Key)
+		}
+		chapters, err := TagChapters(tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range chapters {
+			if chapters[i].Title != input.Chapters[i].Title {
+				t.Errorf("encoding %d: expected %q, got %q", e, input.Chapters[i].Title, chapters[i].Title)
+			}
+		}
+	}
+}
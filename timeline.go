@@ -0,0 +1,153 @@
+package id3v24
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"time"
+)
+
+// Timeline renders chapters as a PNG timeline for visual QA, e.g in a
+// release checklist or a web dashboard: a bar with a segment per
+// chapter, boundaries at every start, the numbered titles below (in
+// as many rows as needed to not overlap) and a time axis. Titles are
+// drawn in the 5x7 pixel font of TitleCard, with ? for runes outside
+// ASCII. The zero value renders 1200 pixels wide on white.
+type Timeline struct {
+	Width      int           // default 1200, the height follows from the titles
+	Background color.Color   // default white
+	Foreground color.Color   // default black, for titles, boundaries and the axis
+	Colors     []color.Color // of the chapter segments in turn, default two blues
+}
+
+// Layout of a Timeline in pixels.
+const (
+	timelineMargin    = 16
+	timelineBarHeight = 40
+	timelineRowGap    = 4
+	timelineTick      = 6
+	timelineMaxTitle  = 40 // runes of a title, longer titles are cut
+)
+
+// timelineSteps are the intervals of the axis ticks to pick from.
+var timelineSteps = []time.Duration{
+	time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second,
+	time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 6 * time.Hour,
+}
+
+// Render returns chapters, flattened (see FlattenChapters), on a
+// timeline of the audio duration as a PNG.
+func (t Timeline) Render(chapters []Chapter, duration time.Duration) ([]byte, error) {
+	if duration <= 0 {
+		return nil, ErrZeroDuration
+	}
+	chapters = FlattenChapters(chapters)
+	total := uint32(duration / time.Millisecond)
+	starts, ends, err := chapterTimes(chapters, total)
+	if err != nil {
+		return nil, err
+	}
+	width := t.Width
+	if width <= 0 {
+		width = 1200
+	}
+	bg, fg, colors := t.Background, t.Foreground, t.Colors
+	if bg == nil {
+		bg = color.White
+	}
+	if fg == nil {
+		fg = color.Black
+	}
+	if len(colors) == 0 {
+		colors = []color.Color{color.RGBA{0x46, 0x82, 0xB4, 0xFF}, color.RGBA{0x9C, 0xC3, 0xE6, 0xFF}}
+	}
+	span := width - 2*timelineMargin
+	xOf := func(ms uint32) int {
+		return timelineMargin + int(int64(ms)*int64(span)/int64(total))
+	}
+
+	// Place each title in the first row where it does not overlap
+	// the title before it.
+	const scale = 2
+	rowHeight := glyphHeight*scale + timelineRowGap
+	labels := make([]string, len(chapters))
+	rows := make([]int, len(chapters))
+	var rowEnds []int
+	for i, ch := range chapters {
+		title := []rune(ch.Title)
+		if len(title) > timelineMaxTitle {
+			title = append(title[:timelineMaxTitle-3], '.', '.', '.')
+		}
+		labels[i] = fmt.Sprintf("%d %s", i+1, string(title))
+		x := xOf(starts[i]) + 3
+		right := x + (len([]rune(labels[i]))+1)*glyphWidth*scale
+		row := 0
+		for row < len(rowEnds) && rowEnds[row] > x {
+			row++
+		}
+		if row == len(rowEnds) {
+			rowEnds = append(rowEnds, 0)
+		}
+		rowEnds[row], rows[i] = right, row
+	}
+
+	barTop := timelineMargin
+	labelsTop := barTop + timelineBarHeight + timelineRowGap
+	axisY := labelsTop + len(rowEnds)*rowHeight + timelineRowGap
+	height := axisY + timelineTick + glyphHeight + timelineMargin
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	fill := func(r image.Rectangle, c color.Color) {
+		draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+	}
+	src := image.NewUniform(fg)
+
+	for i := range chapters {
+		x0, x1 := xOf(starts[i]), min(xOf(ends[i]), width-timelineMargin)
+		fill(image.Rect(x0, barTop, max(x1, x0+1), barTop+timelineBarHeight), colors[i%len(colors)])
+	}
+	for i := range chapters {
+		x := xOf(starts[i])
+		y := labelsTop + rows[i]*rowHeight
+		fill(image.Rect(x, barTop, x+1, y+glyphHeight*scale), fg)
+		drawString(img, x+3, y, scale, labels[i], src)
+	}
+	fill(image.Rect(timelineMargin, barTop, width-timelineMargin, barTop+1), fg)
+	fill(image.Rect(timelineMargin, barTop+timelineBarHeight-1, width-timelineMargin, barTop+timelineBarHeight), fg)
+	fill(image.Rect(width-timelineMargin-1, barTop, width-timelineMargin, barTop+timelineBarHeight), fg)
+
+	step := timelineSteps[len(timelineSteps)-1]
+	for _, s := range timelineSteps {
+		if duration/s <= 10 {
+			step = s
+			break
+		}
+	}
+	fill(image.Rect(timelineMargin, axisY, width-timelineMargin, axisY+1), fg)
+	for at := time.Duration(0); at <= duration; at += step {
+		x := xOf(uint32(at / time.Millisecond))
+		fill(image.Rect(x, axisY, x+1, axisY+timelineTick), fg)
+		label := clockTime(at, duration >= time.Hour)
+		left := min(x-(len(label)*glyphWidth-1)/2, width-len(label)*glyphWidth)
+		drawString(img, max(left, 0), axisY+timelineTick+1, 1, label, src)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// clockTime returns d as M:SS, or H:MM:SS if hours is set.
+func clockTime(d time.Duration, hours bool) string {
+	s := int(d / time.Second)
+	if hours {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
@@ -0,0 +1,53 @@
+package id3v24
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+)
+
+func TestTimeline(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Intro", Start: "00:00:00"},
+		{Title: "The interview", Start: "00:05:00"},
+		{Title: "Listener questions and a very long title that is cut", Start: "00:05:30"},
+		{Title: "Outro", Start: "00:40:00"},
+	}
+	data, err := Timeline{Width: 800}.Render(chapters, 45*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 800 || img.Bounds().Dy() <= timelineBarHeight {
+		t.Errorf("expected 800 pixels wide and taller than the bar, got %v", img.Bounds())
+	}
+	// The chapters at 5:00 and 5:30 are too close for their titles to
+	// share a row.
+	rows := image.Rect(0, 0, 0, timelineMargin+timelineBarHeight+2*timelineRowGap+2*(glyphHeight*2+timelineRowGap))
+	if img.Bounds().Dy() < rows.Max.Y {
+		t.Errorf("expected at least two rows of titles, got a height of %d", img.Bounds().Dy())
+	}
+	segment := func(x int) color.RGBA {
+		return color.RGBAModel.Convert(img.At(x, timelineMargin+timelineBarHeight/2)).(color.RGBA)
+	}
+	if c := segment(timelineMargin + 20); c != (color.RGBA{0x46, 0x82, 0xB4, 0xFF}) {
+		t.Errorf("expected the first segment in the first color, got %v", c)
+	}
+	if c := segment(780); c != (color.RGBA{0x9C, 0xC3, 0xE6, 0xFF}) {
+		t.Errorf("expected the last segment in the second color, got %v", c)
+	}
+
+	if _, err := (Timeline{}).Render(chapters, 0); !errors.Is(err, ErrZeroDuration) {
+		t.Errorf("expected %v, got %v", ErrZeroDuration, err)
+	}
+	if _, err := (Timeline{}).Render(chapters, 30*time.Minute); !errors.Is(err, ErrChapterBeyondDuration) {
+		t.Errorf("expected %v, got %v", ErrChapterBeyondDuration, err)
+	}
+}
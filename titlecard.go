@@ -0,0 +1,196 @@
+package id3v24
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"strings"
+)
+
+// TitleCard renders simple chapter images: the chapter title in a
+// 5x7 pixel font, scaled to fit, centered on a background color or
+// image. The zero value renders white text on dark gray 640x640
+// JPEGs.
+type TitleCard struct {
+	Width, Height int         // default 640x640
+	Background    color.Color // default dark gray
+	Foreground    color.Color // default white
+	// BackgroundImage, if set, is scaled to cover the card instead
+	// of filling it with Background.
+	BackgroundImage image.Image
+}
+
+// WithChapterArt makes the chapter writers embed a title card
+// rendered by card as an APIC sub-frame in every CHAP frame of a
+// chapter without an Image, for players that show per-chapter images.
+func WithChapterArt(card TitleCard) Option {
+	return func(o *options) {
+		o.chapterArt = &card
+	}
+}
+
+// WithCoverArt makes the writers embed a cover rendered by card
+// from the title and artist of the track when TrackInfo has no
+// CoverData or CoverJPEG, so that files never ship without artwork.
+func WithCoverArt(card TitleCard) Option {
+	return func(o *options) {
+		o.coverArt = &card
+	}
+}
+
+// coverText returns the text of a generated cover for input; the
+// title and artist on separate lines.
+func coverText(input TrackInfo) string {
+	var lines []string
+	for _, s := range []string{input.Title, input.Artist} {
+		if s = strings.TrimSpace(s); s != "" {
+			lines = append(lines, s)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Render returns title rendered as a JPEG title card.
+func (c TitleCard) Render(title string) ([]byte, error) {
+	width, height := c.Width, c.Height
+	if width <= 0 || height <= 0 {
+		width, height = 640, 640
+	}
+	bg, fg := c.Background, c.Foreground
+	if bg == nil {
+		bg = color.Gray{Y: 0x30}
+	}
+	if fg == nil {
+		fg = color.White
+	}
+	card := image.NewRGBA(image.Rect(0, 0, width, height))
+	if c.BackgroundImage != nil {
+		scaleToCover(card, c.BackgroundImage)
+	} else {
+		draw.Draw(card, card.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	}
+	drawText(card, title, fg)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, card, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Glyph cell of font5x7 including spacing.
+const (
+	glyphWidth  = 6
+	glyphHeight = 9
+)
+
+// drawText draws text word wrapped in font5x7 centered on dst, at the
+// largest scale that fits within 80% of its width and height.
+func drawText(dst *image.RGBA, text string, fg color.Color) {
+	w, h := dst.Bounds().Dx()*8/10, dst.Bounds().Dy()*8/10
+	var lines []string
+	scale := w / glyphWidth
+	for ; scale > 1; scale-- {
+		lines = wrapText(text, w/(glyphWidth*scale))
+		if len(lines)*glyphHeight*scale <= h && lines != nil {
+			break
+		}
+	}
+	if scale <= 1 {
+		scale = 1
+		lines = wrapText(text, w/glyphWidth)
+	}
+	src := image.NewUniform(fg)
+	top := (dst.Bounds().Dy() - len(lines)*glyphHeight*scale) / 2
+	for i, line := range lines {
+		left := (dst.Bounds().Dx() - (len([]rune(line))*glyphWidth-1)*scale) / 2
+		drawString(dst, left, top+(i*glyphHeight+1)*scale, scale, line, src)
+	}
+}
+
+// drawString draws text in font5x7 at scale on dst, with the top left
+// corner of the first glyph at x, y. Runes outside printable ASCII are
+// drawn as ?.
+func drawString(dst *image.RGBA, x, y, scale int, text string, src image.Image) {
+	for j, r := range []rune(text) {
+		if r < 0x20 || r > 0x7E {
+			r = '?'
+		}
+		left := x + j*glyphWidth*scale
+		for col, bits := range font5x7[r-0x20] {
+			for row := 0; row < 8; row++ {
+				if bits&(1<<row) == 0 {
+					continue
+				}
+				px := image.Rect(left+col*scale, y+row*scale, left+(col+1)*scale, y+(row+1)*scale)
+				draw.Draw(dst, px, src, image.Point{}, draw.Src)
+			}
+		}
+	}
+}
+
+// wrapText splits text into lines of at most width runes, breaking at
+// newlines and, where possible, spaces. Returns nil if width is less
+// than 1.
+func wrapText(text string, width int) []string {
+	if width < 1 {
+		return nil
+	}
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		lines = append(lines, wrapParagraph(paragraph, width)...)
+	}
+	return lines
+}
+
+func wrapParagraph(text string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		for len([]rune(word)) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, string([]rune(word)[:width]))
+			word = string([]rune(word)[width:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case len([]rune(line))+1+len([]rune(word)) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" || lines == nil {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// scaleToCover draws src onto dst scaled (nearest neighbour) to cover
+// dst, cropping the overflowing sides.
+func scaleToCover(dst *image.RGBA, src image.Image) {
+	sb, db := src.Bounds(), dst.Bounds()
+	if sb.Empty() {
+		return
+	}
+	// Pick the scale of the axis that needs the larger one.
+	num, den := db.Dx(), sb.Dx()
+	if db.Dy()*sb.Dx() > db.Dx()*sb.Dy() {
+		num, den = db.Dy(), sb.Dy()
+	}
+	offX := (sb.Dx()*num/den - db.Dx()) / 2
+	offY := (sb.Dy()*num/den - db.Dy()) / 2
+	for y := 0; y < db.Dy(); y++ {
+		for x := 0; x < db.Dx(); x++ {
+			sx := sb.Min.X + (x+offX)*den/num
+			sy := sb.Min.Y + (y+offY)*den/num
+			dst.Set(db.Min.X+x, db.Min.Y+y, src.At(sx, sy))
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package id3v24
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"reflect"
+	"testing"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+func TestTitleCardRender(t *testing.T) {
+	data, err := TitleCard{Width: 320, Height: 180, Background: color.Black}.Render("Chapter 1: The beginning")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 320 || b.Dy() != 180 {
+		t.Errorf("expected 320x180, got %v", b)
+	}
+	lit := 0
+	for y := 0; y < 180; y++ {
+		for x := 0; x < 320; x++ {
+			if r, _, _, _ := img.At(x, y).RGBA(); r > 0x8000 {
+				lit++
+				if x < 32 || x >= 288 {
+					t.Fatalf("text outside the margin at %d,%d", x, y)
+				}
+			}
+		}
+	}
+	if lit == 0 {
+		t.Error("expected some text")
+	}
+	background := image.NewUniform(color.RGBA{R: 0xFF, A: 0xFF})
+	if _, err := (TitleCard{BackgroundImage: background}).Render(""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	expected := []string{"Chapter", "one of", "Supercal", "ifragili", "stic", "by", "Someone"}
+	if lines := wrapText("Chapter one of Supercalifragilistic\nby Someone", 8); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected %q, got %q", expected, lines)
+	}
+}
+
+func TestWithChapterArt(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chapters := []Chapter{
+		{Title: "Chapter 1", Start: "00:00:00.000"},
+		{Title: "Chapter 2", Start: "00:00:01.500"},
+	}
+	var out bytes.Buffer
+	if err := WriteID3v2TagTo(&out, bytes.NewReader(mp3), TrackInfo{Chapters: chapters}, WithChapterArt(TitleCard{Width: 64, Height: 64})); err != nil {
+		t.Fatal(err)
+	}
+	tag, err := id3v2.ParseReader(bytes.NewReader(out.Bytes()), id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range tag.GetFrames("CHAP") {
+		body := f.(id3v2.UnknownFrame).Body
+		i := bytes.Index(body, []byte("APIC"))
+		if i < 0 {
+			t.Fatal("expected an APIC sub-frame")
+		}
+		if !bytes.HasPrefix(body[i+10:], []byte("\x00image/jpeg\x00\x00\x00\xFF\xD8")) {
+			t.Errorf("unexpected APIC sub-frame %q", body[i:i+24])
+		}
+	}
+	got, err := TagChapters(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, chapters) {
+		t.Errorf("expected %v, got %v", chapters, got)
+	}
+}
+
+func TestWithCoverArt(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range []TrackInfo{
+		{Title: "Episode 1", Artist: "The Hosts"},
+		{Title: "Episode 1", CoverData: []byte("GIF89a")},
+	} {
+		var out bytes.Buffer
+		if err := WriteID3v2TagTo(&out, bytes.NewReader(mp3), input, WithCoverArt(TitleCard{Width: 32, Height: 32})); err != nil {
+			t.Fatal(err)
+		}
+		tag, err := id3v2.ParseReader(bytes.NewReader(out.Bytes()), id3v2.Options{Parse: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pictures := tag.GetFrames(tag.CommonID("Attached picture"))
+		if len(pictures) != 1 {
+			t.Fatalf("expected 1 picture, got %d", len(pictures))
+		}
+		picture := pictures[0].(id3v2.PictureFrame)
+		if input.CoverData != nil {
+			if !bytes.Equal(picture.Picture, input.CoverData) {
+				t.Errorf("expected the supplied cover, got %q", picture.Picture)
+			}
+			continue
+		}
+		if picture.MimeType != "image/jpeg" {
+			t.Errorf("expected %q, got %q", "image/jpeg", picture.MimeType)
+		}
+		if _, err := jpeg.Decode(bytes.NewReader(picture.Picture)); err != nil {
+			t.Error(err)
+		}
+	}
+	if text, expected := coverText(TrackInfo{Title: "Title", Artist: " Artist "}), "Title\nArtist"; text != expected {
+		t.Errorf("expected %q, got %q", expected, text)
+	}
+}
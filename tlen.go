@@ -0,0 +1,162 @@
+package id3v24
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	id3v2 "github.com/bogem/id3v2"
+	"github.com/sa6mwa/mp3duration"
+)
+
+// DurationMismatchError is passed to the WithWarnings handler when
+// the TLEN frame of a file and the scanned duration of its audio
+// differ by more than the tolerance given to WithTLENTolerance.
+type DurationMismatchError struct {
+	TLEN      time.Duration
+	Scanned   time.Duration
+	Tolerance time.Duration
+}
+
+func (e *DurationMismatchError) Error() string {
+	return fmt.Sprintf("TLEN %v and scanned duration %v differ by more than %v", e.TLEN, e.Scanned, e.Tolerance)
+}
+
+// TLENDuration returns the duration in the TLEN (length in
+// milliseconds) frame of tag, ok is false if tag has no valid TLEN
+// frame.
+func TLENDuration(tag *id3v2.Tag) (duration time.Duration, ok bool) {
+	text := strings.TrimSpace(tag.GetTextFrame("TLEN").Text)
+	if text == "" {
+		return 0, false
+	}
+	millis, err := strconv.ParseUint(text, 10, 32)
+	if err != nil || millis == 0 {
+		return 0, false
+	}
+	return time.Duration(millis) * time.Millisecond, true
+}
+
+// WithTLEN makes AddCHAPAndCTOC and WriteID3v2Tag use the duration in
+// an existing TLEN frame, when present, to calculate the end of the
+// last chapter instead of the scanned duration. WriteID3v2Tag then
+// skips scanning the audio altogether, unless WithTLENTolerance is
+// also given.
+func WithTLEN() Option {
+	return func(o *options) {
+		o.useTLEN = true
+	}
+}
+
+// WithTLENTolerance implies WithTLEN and validates the TLEN frame
+// against the scanned duration, reporting a *DurationMismatchError to
+// the WithWarnings handler when they differ by more than tolerance.
+// TLEN is still used for the chapter math.
+func WithTLENTolerance(tolerance time.Duration) Option {
+	return func(o *options) {
+		o.useTLEN = true
+		o.tlenTolerance = tolerance
+	}
+}
+
+// WithWriteTLEN makes WriteID3v2Tag write the duration of the audio,
+// the one used for the chapters, as a TLEN frame so that players need
+// not scan VBR files for it. Off by default.
+func WithWriteTLEN() Option {
+	return func(o *options) {
+		o.writeTLEN = true
+	}
+}
+
+// addTLEN sets the TLEN frame of tag to duration, in milliseconds, if
+// WithWriteTLEN was given.
+func (o *options) addTLEN(tag *id3v2.Tag, duration time.Duration) {
+	if !o.writeTLEN || duration < time.Millisecond {
+		return
+	}
+	tag.AddTextFrame("TLEN", tag.DefaultEncoding(), strconv.FormatInt(duration.Milliseconds(), 10))
+}
+
+// tlenDuration returns the TLEN duration of tag if WithTLEN was given
+// and tag has one, otherwise duration. With WithTLENTolerance, a
+// non-zero duration is validated against TLEN.
+func (o *options) tlenDuration(tag *id3v2.Tag, duration time.Duration) time.Duration {
+	if !o.useTLEN {
+		return duration
+	}
+	tlen, ok := TLENDuration(tag)
+	if !ok {
+		return duration
+	}
+	if duration != 0 {
+		o.checkTLEN(tlen, duration)
+	}
+	return tlen
+}
+
+func (o *options) checkTLEN(tlen, scanned time.Duration) {
+	if o.tlenTolerance <= 0 {
+		return
+	}
+	if diff := tlen - scanned; diff > o.tlenTolerance || -diff > o.tlenTolerance {
+		o.warn(&DurationMismatchError{TLEN: tlen, Scanned: scanned, Tolerance: o.tlenTolerance})
+	}
+}
+
+// resolveDuration returns the duration of the MP3 in r, whose leading
+// ID3v2 tag is tagSize bytes, by scanning the audio or, with WithTLEN,
+// from the TLEN frame of the existing tag. With WithExactDuration the
+// audio is always scanned.
+func (o *options) resolveDuration(r io.ReadSeeker, tagSize int64) (mp3duration.Info, error) {
+	var tlen time.Duration
+	if o.useTLEN && tagSize > 0 {
+		if _, err := r.Seek(0, io.SeekStart); err != nil {
+			return mp3duration.Info{}, err
+		}
+		existing, err := id3v2.ParseReader(io.LimitReader(r, tagSize), id3v2.Options{
+			Parse:       true,
+			ParseFrames: []string{"TLEN"},
+		})
+		if err == nil {
+			tlen, _ = TLENDuration(existing)
+		}
+		if tlen != 0 && o.tlenTolerance <= 0 && !o.exactDuration {
+			return durationInfo(tlen), nil
+		}
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return mp3duration.Info{}, err
+	}
+	began := time.Now()
+	info, err := ProbeReader(r)
+	if err != nil {
+		return info.Info, err
+	}
+	if o.exactDuration && info.Accuracy != DurationExact {
+		return info.Info, ErrInexactDuration
+	}
+	di := info.Info
+	o.durationScanned(di.TimeDuration, time.Since(began))
+	if tlen != 0 {
+		o.checkTLEN(tlen, di.TimeDuration)
+		di.TimeDuration = tlen
+		di.Seconds = tlen.Seconds()
+		di.SecondsInt = int(math.Round(di.Seconds))
+		di.Duration = mp3duration.FormatDuration(tlen)
+	}
+	return di, nil
+}
+
+// durationInfo returns an mp3duration.Info with the duration fields
+// set from d.
+func durationInfo(d time.Duration) mp3duration.Info {
+	return mp3duration.Info{
+		TimeDuration: d,
+		Seconds:      d.Seconds(),
+		SecondsInt:   int(math.Round(d.Seconds())),
+		Duration:     mp3duration.FormatDuration(d),
+	}
+}
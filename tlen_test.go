@@ -0,0 +1,94 @@
+package id3v24
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	id3v2 "github.com/bogem/id3v2"
+	"github.com/sa6mwa/mp3duration"
+)
+
+func TestAddCHAPAndCTOCWithTLEN(t *testing.T) {
+	chapters := []Chapter{{Title: "Chapter 1", Start: "00:00:00"}}
+	tag := id3v2.NewEmptyTag()
+	if err := AddCHAPAndCTOC(mp3duration.Info{}, tag, chapters, WithTLEN()); !errors.Is(err, ErrZeroDuration) {
+		t.Fatalf("expected ErrZeroDuration without TLEN, got %v", err)
+	}
+	tag.AddTextFrame("TLEN", tag.DefaultEncoding(), "42000")
+	if err := AddCHAPAndCTOC(mp3duration.Info{}, tag, chapters, WithTLEN()); err != nil {
+		t.Fatal(err)
+	}
+	chap := tag.GetFrames("CHAP")[0].(id3v2.UnknownFrame)
+	if end := binary.BigEndian.Uint32(chap.Body[6:10]); end != 42000 {
+		t.Errorf("expected chapter to end at TLEN 42000, got %d", end)
+	}
+}
+
+func TestWriteID3v2TagToTLENTolerance(t *testing.T) {
+	mp3, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Prepend a tag with a TLEN frame that is 10 seconds off.
+	tag := id3v2.NewEmptyTag()
+	tag.AddTextFrame("TLEN", tag.DefaultEncoding(), "13056")
+	var tagged bytes.Buffer
+	if _, err := tag.WriteTo(&tagged); err != nil {
+		t.Fatal(err)
+	}
+	tagged.Write(mp3)
+
+	var warnings []error
+	input := TrackInfo{Chapters: []Chapter{{Title: "Chapter 1", Start: "00:00:00"}}}
+	var out bytes.Buffer
+	err = WriteID3v2TagTo(&out, bytes.NewReader(tagged.Bytes()), input,
+		WithTLENTolerance(time.Second),
+		WithWarnings(func(err error) { warnings = append(warnings, err) }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mismatch *DurationMismatchError
+	if len(warnings) != 1 || !errors.As(warnings[0], &mismatch) {
+		t.Fatalf("expected one DurationMismatchError, got %v", warnings)
+	}
+	if mismatch.TLEN != 13056*time.Millisecond {
+		t.Errorf("unexpected TLEN %v", mismatch.TLEN)
+	}
+}
+
+func TestWithWriteTLEN(t *testing.T) {
+	name := copyTestMP3(t)
+	if err := WriteID3v2Tag(name, TrackInfo{Title: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	tag, err := id3v2.Open(name, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ok := TLENDuration(tag)
+	tag.Close()
+	if ok {
+		t.Errorf("expected no TLEN without WithWriteTLEN")
+	}
+	if err := WriteID3v2Tag(name, TrackInfo{Title: "x"}, WithWriteTLEN()); err != nil {
+		t.Fatal(err)
+	}
+	tag, err = id3v2.Open(name, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tag.Close()
+	info, err := mp3duration.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlen, ok := TLENDuration(tag)
+	if expected := info.TimeDuration.Truncate(time.Millisecond); !ok || tlen != expected {
+		t.Errorf("expected TLEN %v, got %v", expected, tlen)
+	}
+}
@@ -0,0 +1,161 @@
+package id3v24
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"unicode/utf16"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// TraktorOwner is the owner identifier of the PRIV frame in which
+// Traktor stores its track data, cue points included.
+const TraktorOwner = "TRAKTOR4"
+
+// Types of Traktor cue points.
+const (
+	traktorCueGrid = 4 // beat grid marker
+)
+
+// TraktorChapters decodes the cue points of the Traktor PRIV frame in
+// tag into chapters ordered by start time, see ParseTraktorCues.
+// Returns nil if tag has no Traktor data.
+func TraktorChapters(tag *id3v2.Tag) ([]Chapter, error) {
+	for _, f := range tag.GetFrames("PRIV") {
+		uf, ok := f.(id3v2.UnknownFrame)
+		if !ok {
+			continue
+		}
+		owner, data, ok := bytes.Cut(uf.Body, []byte{0x00})
+		if ok && string(owner) == TraktorOwner {
+			return ParseTraktorCues(data)
+		}
+	}
+	return nil, nil
+}
+
+// ParseTraktorCues decodes the cue points in the data of a Traktor
+// PRIV frame (after the owner identifier) into chapters ordered by
+// start time, titled by the cue name (or "Cue N" for unnamed cues).
+// Beat grid markers are left out. The data is a tree of frames, each
+// with a reversed four letter ID (e.g "DMRT" for TRMD), the little
+// endian size of its data and number of child frames, the cue points
+// being the data of the CUEP frame.
+func ParseTraktorCues(data []byte) ([]Chapter, error) {
+	cuep, err := findTraktorFrame(data, "CUEP")
+	if err != nil || cuep == nil {
+		return nil, err
+	}
+	r := &traktorReader{b: cuep}
+	count := r.uint32()
+	type cue struct {
+		index int
+		start float64
+		name  string
+	}
+	var cues []cue
+	for i := 0; i < int(count) && r.err == nil; i++ {
+		r.uint32() // always 1
+		name := r.utf16(int(r.uint32()))
+		r.uint32() // display order
+		kind := r.uint32()
+		start := r.float64()
+		r.float64() // length
+		r.uint32()  // repeats
+		hotcue := int32(r.uint32())
+		if r.err != nil || kind == traktorCueGrid {
+			continue
+		}
+		index := i
+		if hotcue >= 0 {
+			index = int(hotcue)
+		}
+		cues = append(cues, cue{index: index, start: start, name: name})
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	sort.SliceStable(cues, func(i, j int) bool { return cues[i].start < cues[j].start })
+	chapters := make([]Chapter, len(cues))
+	for i, c := range cues {
+		if c.start < 0 || c.start > math.MaxUint32 {
+			return nil, fmt.Errorf("%w: cue at %f ms", ErrBadTraktorCues, c.start)
+		}
+		title := c.name
+		if title == "" || title == "n.n." {
+			title = fmt.Sprintf("Cue %d", c.index+1)
+		}
+		chapters[i] = Chapter{Title: title, Start: MillisToStringTime(uint32(math.Round(c.start)))}
+	}
+	return chapters, nil
+}
+
+// findTraktorFrame returns the data of the first frame with id in the
+// Traktor frames in data, nil if there is none.
+func findTraktorFrame(data []byte, id string) ([]byte, error) {
+	for len(data) > 0 {
+		if len(data) < 12 {
+			return nil, ErrBadTraktorCues
+		}
+		frameID := []byte{data[3], data[2], data[1], data[0]}
+		size := binary.LittleEndian.Uint32(data[4:])
+		children := binary.LittleEndian.Uint32(data[8:])
+		data = data[12:]
+		if uint64(size) > uint64(len(data)) {
+			return nil, ErrBadTraktorCues
+		}
+		body := data[:size]
+		data = data[size:]
+		if string(frameID) == id {
+			return body, nil
+		}
+		if children > 0 {
+			if found, err := findTraktorFrame(body, id); err != nil || found != nil {
+				return found, err
+			}
+		}
+	}
+	return nil, nil
+}
+
+// traktorReader reads the little endian values of Traktor frame data,
+// err is set to ErrBadTraktorCues once the data runs out.
+type traktorReader struct {
+	b   []byte
+	err error
+}
+
+func (r *traktorReader) next(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.b) {
+		r.err = ErrBadTraktorCues
+		return make([]byte, max(n, 0))
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+func (r *traktorReader) uint32() uint32 {
+	return binary.LittleEndian.Uint32(r.next(4))
+}
+
+func (r *traktorReader) float64() float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(r.next(8)))
+}
+
+// utf16 reads a string of n UTF-16LE code units.
+func (r *traktorReader) utf16(n int) string {
+	if n > len(r.b)/2 {
+		r.err = ErrBadTraktorCues
+		return ""
+	}
+	b := r.next(2 * n)
+	units := make([]uint16, n)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,87 @@
+package id3v24
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+var ErrNothingToUndo error = errors.New("nothing to undo")
+
+// UndoSuffix is appended to the name of an MP3 file to get the name of
+// the file WithUndo saves its previous tag in.
+const UndoSuffix = ".id3v24-undo"
+
+// WithUndo makes WriteID3v2Tag save the tag it replaces (or the fact
+// that there was none) next to the MP3 file, see UndoSuffix, so that
+// the write can be reverted with Undo. Only the latest write can be
+// undone.
+func WithUndo() Option {
+	return func(o *options) {
+		o.undo = true
+	}
+}
+
+// Undo restores the tag mp3file had before the last WriteID3v2Tag
+// with WithUndo, removing the tag if there was none, and deletes the
+// saved tag. Returns ErrNothingToUndo if there is no saved tag.
+func Undo(mp3file string, opts ...Option) error {
+	o := newOptions(opts...)
+	previous, err := os.ReadFile(mp3file + UndoSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNothingToUndo
+	} else if err != nil {
+		return o.fail(MetricErrOpen, err)
+	}
+	if len(previous) > 0 && id3v2TagSize(previous) != int64(len(previous)) {
+		return o.fail(MetricErrOpen, ErrBadFrame)
+	}
+	if err := replaceLeadingTag(o, mp3file, previous); err != nil {
+		return err
+	}
+	return os.Remove(mp3file + UndoSuffix)
+}
+
+// replaceLeadingTag replaces the ID3v2 tag at the start of path (if
+// any) with the raw tag, which may be empty.
+func replaceLeadingTag(o *options, path string, tag []byte) error {
+	return rewriteFile(o, path, func(w io.Writer, r io.ReadSeeker) error {
+		current, err := readLeadingTag(r)
+		if err != nil {
+			return o.fail(MetricErrOpen, err)
+		}
+		if _, err := w.Write(tag); err != nil {
+			return o.fail(MetricErrSave, err)
+		}
+		if _, err := r.Seek(int64(len(current)), io.SeekStart); err != nil {
+			return o.fail(MetricErrSave, err)
+		}
+		if _, err := io.Copy(w, r); err != nil {
+			return o.fail(MetricErrSave, err)
+		}
+		return nil
+	})
+}
+
+// readLeadingTag returns the bytes of the ID3v2 tag at the start of
+// r, or none if r does not start with a tag.
+func readLeadingTag(r io.ReadSeeker) ([]byte, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	header := make([]byte, id3v2HeaderSize)
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	size := id3v2TagSize(header[:n])
+	if size == 0 {
+		return []byte{}, nil
+	}
+	tag := make([]byte, size)
+	copy(tag, header)
+	if _, err := io.ReadFull(r, tag[id3v2HeaderSize:]); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
@@ -0,0 +1,102 @@
+package id3v24
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+func TestUndo(t *testing.T) {
+	mp3file := copyTestMP3(t)
+	original, err := os.ReadFile(mp3file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Undo(mp3file); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("expected ErrNothingToUndo, got %v", err)
+	}
+	if err := WriteID3v2Tag(mp3file, TrackInfo{Title: "First"}, WithUndo()); err != nil {
+		t.Fatal(err)
+	}
+	first, err := os.ReadFile(mp3file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteID3v2Tag(mp3file, TrackInfo{Title: "Second"}, WithUndo()); err != nil {
+		t.Fatal(err)
+	}
+	if err := Undo(mp3file); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := os.ReadFile(mp3file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(restored, first) {
+		t.Error("expected the first tag to be restored")
+	}
+	if err := Undo(mp3file); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("expected ErrNothingToUndo, got %v", err)
+	}
+
+	// Undoing the first write of an untagged file removes the tag.
+	if err := os.WriteFile(mp3file, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteID3v2Tag(mp3file, TrackInfo{Title: "First"}, WithUndo()); err != nil {
+		t.Fatal(err)
+	}
+	if err := Undo(mp3file); err != nil {
+		t.Fatal(err)
+	}
+	if restored, err = os.ReadFile(mp3file); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(restored, original) {
+		t.Error("expected the tag to be removed")
+	}
+}
+
+func TestWithTagSnapshot(t *testing.T) {
+	mp3file := copyTestMP3(t)
+	if err := WriteID3v2Tag(mp3file, TrackInfo{Title: "Original"}); err != nil {
+		t.Fatal(err)
+	}
+	original, err := os.ReadFile(mp3file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, title := range []string{"Second", "Third"} {
+		if err := WriteID3v2Tag(mp3file, TrackInfo{Title: title}, WithTagSnapshot()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tag, err := id3v2.Open(mp3file, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := TagSnapshot(tag)
+	tag.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(original, snapshot) || len(snapshot) == 0 {
+		t.Error("expected a snapshot of the original tag")
+	}
+	if err := RestoreTagSnapshot(mp3file); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := os.ReadFile(mp3file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(restored, original) {
+		t.Error("expected the original tag to be restored")
+	}
+	if err := RestoreTagSnapshot(mp3file); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("expected ErrNothingToUndo, got %v", err)
+	}
+}
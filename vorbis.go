@@ -0,0 +1,162 @@
+package id3v24
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"sort"
+	"strings"
+
+	id3v2 "github.com/bogem/id3v2"
+)
+
+// MetadataBlockPicture returns the base64 encoded FLAC picture block
+// used as the value of the METADATA_BLOCK_PICTURE Vorbis comment,
+// which is how Ogg Vorbis and Opus files carry cover art.
+// pictureType is one of the id3v2.PT constants (the numbering is
+// shared with APIC). Width, height and color depth are read from JPEG
+// and PNG images and left as zero (unknown) for other formats.
+func MetadataBlockPicture(pictureType byte, mimeType, description string, data []byte) string {
+	return base64.StdEncoding.EncodeToString(pictureBlock(pictureType, mimeType, description, data))
+}
+
+// pictureBlock returns the (unencoded) FLAC picture block.
+func pictureBlock(pictureType byte, mimeType, description string, data []byte) []byte {
+	var width, height, depth uint32
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		width, height, depth = uint32(cfg.Width), uint32(cfg.Height), 24
+	}
+	block := binary.BigEndian.AppendUint32(nil, uint32(pictureType))
+	block = binary.BigEndian.AppendUint32(block, uint32(len(mimeType)))
+	block = append(block, []byte(mimeType)...)
+	block = binary.BigEndian.AppendUint32(block, uint32(len(description)))
+	block = append(block, []byte(description)...)
+	block = binary.BigEndian.AppendUint32(block, width)
+	block = binary.BigEndian.AppendUint32(block, height)
+	block = binary.BigEndian.AppendUint32(block, depth)
+	block = binary.BigEndian.AppendUint32(block, 0) // colors, 0 for non-indexed
+	block = binary.BigEndian.AppendUint32(block, uint32(len(data)))
+	return append(block, data...)
+}
+
+// VorbisComments returns input as Vorbis comments (KEY=value) for
+// Ogg Vorbis, Opus or FLAC files: the usual text fields, chapters in
+// the CHAPTERnnn/CHAPTERnnnNAME convention and the cover (CoverData
+// or CoverJPEG) as METADATA_BLOCK_PICTURE. Empty fields are left out.
+func VorbisComments(input TrackInfo, opts ...Option) ([]string, error) {
+	comments, picture, err := vorbisComments(newOptions(opts...), input)
+	if err != nil {
+		return nil, err
+	}
+	if picture != nil {
+		comments = append(comments, "METADATA_BLOCK_PICTURE="+base64.StdEncoding.EncodeToString(picture))
+	}
+	return comments, nil
+}
+
+// vorbisComments returns the Vorbis comments of input and the cover,
+// if any, as a FLAC picture block.
+func vorbisComments(o *options, input TrackInfo) (comments []string, picture []byte, err error) {
+	if input, err = ApplyLicense(input); err != nil {
+		return nil, nil, err
+	}
+	if err := o.resolveYear(&input); err != nil {
+		return nil, nil, err
+	}
+	add := func(key, value string) {
+		if len([]rune(value)) > 0 {
+			comments = append(comments, key+"="+value)
+		}
+	}
+	add("TITLE", input.Title)
+	add("ALBUM", input.Album)
+	add("ARTIST", input.Artist)
+	add("ALBUMARTIST", input.AlbumArtist)
+	add("COMPOSER", input.Composer)
+	add("GROUPING", input.Grouping)
+	add("GENRE", input.Genre)
+	if !input.Date.IsZero() {
+		add("DATE", formatDate(input.Date))
+	} else {
+		add("DATE", input.Year)
+	}
+	add("TRACKNUMBER", input.Track)
+	add("DISCNUMBER", input.Disc)
+	add("COMMENT", input.Comment)
+	add("DESCRIPTION", input.Description)
+	add("LANGUAGE", input.Language)
+	add("COPYRIGHT", input.Copyright)
+	add("LICENSE", input.CopyrightURL)
+	add("MOOD", input.Mood)
+	keys := make([]string, 0, len(input.Custom))
+	for key := range input.Custom {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key != "" && !strings.ContainsRune(key, '=') {
+			add(strings.ToUpper(key), input.Custom[key])
+		}
+	}
+	for i, ch := range FlattenChapters(input.Chapters) {
+		start, err := StringTimeToMillis(ch.Start)
+		if err != nil {
+			return nil, nil, err
+		}
+		key := fmt.Sprintf("CHAPTER%03d", i+1)
+		add(key, MillisToStringTime(start))
+		add(key+"NAME", ch.Title)
+		add(key+"URL", ch.URL)
+	}
+	mimeType, imgData, err := coverImage(o, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	if imgData != nil {
+		picture = pictureBlock(id3v2.PTFrontCover, mimeType, "Cover", imgData)
+	}
+	return comments, picture, nil
+}
+
+// parseVorbisComments returns the vendor string and comments of the
+// Vorbis comment header b, without packet type and magic. ok is false
+// if b has no vendor string, comments are nil if they can not be read.
+func parseVorbisComments(b []byte) (vendor string, comments []string, ok bool) {
+	if len(b) < 4 || int(binary.LittleEndian.Uint32(b)) > len(b)-4 {
+		return "", nil, false
+	}
+	n := int(binary.LittleEndian.Uint32(b))
+	vendor, b = string(b[4:4+n]), b[4+n:]
+	if len(b) < 4 {
+		return vendor, nil, true
+	}
+	count := binary.LittleEndian.Uint32(b)
+	b = b[4:]
+	for i := uint32(0); i < count; i++ {
+		if len(b) < 4 || int(binary.LittleEndian.Uint32(b)) > len(b)-4 {
+			return vendor, nil, true
+		}
+		n := int(binary.LittleEndian.Uint32(b))
+		comments, b = append(comments, string(b[4:4+n])), b[4+n:]
+	}
+	return vendor, comments, true
+}
+
+// vorbisFields returns comments by upper case key, for
+// fieldJournalRecord, with the FLAC picture blocks as PICTURE.
+func vorbisFields(comments []string, pictures ...[]byte) map[string]string {
+	fields := map[string]string{}
+	for _, c := range comments {
+		key, value, _ := strings.Cut(c, "=")
+		key = strings.ToUpper(key)
+		fields[key] += value + "\x00"
+	}
+	for _, p := range pictures {
+		fields["PICTURE"] += string(p) + "\x00"
+	}
+	return fields
+}
@@ -0,0 +1,60 @@
+package id3v24
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestVorbisCommentsPicture(t *testing.T) {
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	input := TrackInfo{
+		Title:     "Hello world",
+		Year:      "2024",
+		CoverData: img.Bytes(),
+		Chapters:  []Chapter{{Title: "Intro", Start: "00:00:00"}},
+	}
+	comments, err := VorbisComments(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 5 {
+		t.Fatalf("expected 5 comments, got %q", comments)
+	}
+	expected := []string{"TITLE=Hello world", "DATE=2024", "CHAPTER001=00:00:00.000", "CHAPTER001NAME=Intro"}
+	for i, c := range expected {
+		if comments[i] != c {
+			t.Errorf("expected %q, got %q", c, comments[i])
+		}
+	}
+	value, ok := strings.CutPrefix(comments[4], "METADATA_BLOCK_PICTURE=")
+	if !ok {
+		t.Fatalf("expected METADATA_BLOCK_PICTURE, got %q", comments[4])
+	}
+	block, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pictureType := binary.BigEndian.Uint32(block); pictureType != 3 {
+		t.Errorf("expected front cover picture type, got %d", pictureType)
+	}
+	mimeLen := binary.BigEndian.Uint32(block[4:])
+	if mime := string(block[8 : 8+mimeLen]); mime != "image/png" {
+		t.Errorf("expected image/png, got %q", mime)
+	}
+	rest := block[8+mimeLen:]
+	rest = rest[4+binary.BigEndian.Uint32(rest):] // description
+	if w, h := binary.BigEndian.Uint32(rest), binary.BigEndian.Uint32(rest[4:]); w != 3 || h != 2 {
+		t.Errorf("expected 3x2, got %dx%d", w, h)
+	}
+	if data := rest[20:]; !bytes.Equal(data, img.Bytes()) {
+		t.Error("picture data does not match")
+	}
+}